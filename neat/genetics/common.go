@@ -36,9 +36,80 @@ const (
 	PlainGenomeEncoding GenomeEncoding = iota + 1
 	// The rich text in YAML
 	YAMLGenomeEncoding
+	// The plain text written by the original C++ NEAT implementation (read only)
+	LegacyNEATGenomeEncoding
 )
 
+// The supported versions of genome serialization format
+const (
+	// The original layout used when version is not specified. The node bias and gene frozen flag are optional.
+	GenomeFormatVersion1 = 1
+	// The extended layout written when the node bias, node time constant or gene frozen flag is set. The node activation
+	// is mandatory in plain text encoding, while the node bias, time constant and gene frozen flag remain optional
+	// trailing fields.
+	GenomeFormatVersion2 = 2
+)
+
+// Defines strategy to select organisms for migration between populations
+type MigrationStrategy byte
+
+const (
+	// The N most fit organisms of the population
+	BestNMigration MigrationStrategy = iota + 1
+	// The N random organisms of the population
+	RandomNMigration
+	// The champion of each species
+	BestPerSpeciesMigration
+)
+
+// Defines the phase of phased search, i.e., whether structural mutations are allowed
+type SearchPhase byte
+
+const (
+	// The structure of genomes grows by structural mutations
+	ComplexifyingPhase SearchPhase = iota
+	// The structure of genomes shrinks as only weights mutated and genes disabled
+	SimplifyingPhase
+)
+
+// Defines kind of reproduction failure
+type ReproductionErrorKind byte
+
+const (
+	// The reproduction of species without organisms attempted
+	EmptySpeciesReproductionError ReproductionErrorKind = iota + 1
+	// The compatibility threshold is zero and offspring can not be speciated
+	ZeroCompatThresholdReproductionError
+	// The mutation of offspring genome failed
+	MutationFailedReproductionError
+	// The mating of parents failed
+	MatingFailedReproductionError
+)
+
+// The error returned if reproduction failed. It holds the kind of failure and the underlying error.
+type ReproductionError struct {
+	// The kind of reproduction failure
+	Kind ReproductionErrorKind
+	// The underlying error
+	Err  error
+}
+
+// Creates new reproduction error of given kind wrapping provided error
+func newReproductionError(kind ReproductionErrorKind, err error) *ReproductionError {
+	return &ReproductionError{Kind:kind, Err:err}
+}
+
+func (e *ReproductionError) Error() string {
+	return e.Err.Error()
+}
+
+// Returns the underlying error
+func (e *ReproductionError) Unwrap() error {
+	return e.Err
+}
+
 var (
+	ErrUnsupportedMigrationStrategy = errors.New("unsupported migration strategy")
 	ErrUnsupportedGenomeEncoding = errors.New("unsupported genome encoding")
 )
 
@@ -0,0 +1,47 @@
+package genetics
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// The metric to compute compatibility distance between two genomes. The bigger returned value the less compatible
+// the genomes. Fully compatible genomes should have 0.0 returned.
+type CompatibilityMetric interface {
+	// Returns compatibility distance between genomes a and b
+	Distance(a, b *Genome) float64
+}
+
+// The adapter to use ordinary function as compatibility metric
+type CompatibilityMetricFunc func(a, b *Genome) float64
+
+// Distance calls f(a, b)
+func (f CompatibilityMetricFunc) Distance(a, b *Genome) float64 {
+	return f(a, b)
+}
+
+var (
+	// The registered compatibility metrics by name
+	compatibilityMetrics = make(map[string]CompatibilityMetric)
+	// The mutex to guard registered metrics
+	compatibilityMetricsMutex sync.RWMutex
+)
+
+// Registers compatibility metric with given name, so it can be referenced by context.CompatibilityMetric.
+// The metric previously registered with the same name will be replaced.
+func RegisterCompatibilityMetric(name string, metric CompatibilityMetric) {
+	compatibilityMetricsMutex.Lock()
+	defer compatibilityMetricsMutex.Unlock()
+	compatibilityMetrics[name] = metric
+}
+
+// Returns compatibility metric registered with given name or error if not found
+func CompatibilityMetricByName(name string) (CompatibilityMetric, error) {
+	compatibilityMetricsMutex.RLock()
+	defer compatibilityMetricsMutex.RUnlock()
+	if metric, ok := compatibilityMetrics[name]; ok {
+		return metric, nil
+	}
+	return nil, errors.New(fmt.Sprintf("Unknown compatibility metric: %s", name))
+}
@@ -0,0 +1,51 @@
+package genetics
+
+import (
+	"testing"
+	"math/rand"
+	"github.com/yaricom/goNEAT/neat"
+)
+
+func TestCompatibilityMetric_speciate(t *testing.T) {
+	rand.Seed(42)
+	in, out, nmax, n := 3, 2, 15, 3
+	RegisterCompatibilityMetric("ZeroCompatibilityMetric", CompatibilityMetricFunc(func(a, b *Genome) float64 {
+		return 0.0
+	}))
+	RegisterCompatibilityMetric("InfiniteCompatibilityMetric", CompatibilityMetricFunc(func(a, b *Genome) float64 {
+		return 1000.0
+	}))
+
+	conf := neat.NewNeatContext()
+	conf.CompatThreshold = 0.5
+	conf.PopSize = 20
+	gen := newGenomeRand(1, in, out, n, nmax, false, 0.8)
+
+	// all organisms are compatible
+	conf.CompatibilityMetric = "ZeroCompatibilityMetric"
+	pop, err := NewPopulation(gen, conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(pop.Species) != 1 {
+		t.Error("len(pop.Species) != 1", len(pop.Species))
+	}
+
+	// each organism in its own species
+	conf.CompatibilityMetric = "InfiniteCompatibilityMetric"
+	pop, err = NewPopulation(gen, conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(pop.Species) != conf.PopSize {
+		t.Error("len(pop.Species) != conf.PopSize", len(pop.Species))
+	}
+
+	// unknown metric
+	conf.CompatibilityMetric = "UnknownCompatibilityMetric"
+	if _, err = NewPopulation(gen, conf); err == nil {
+		t.Error("Error expected for unknown compatibility metric")
+	}
+}
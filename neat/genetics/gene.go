@@ -24,6 +24,8 @@ type Gene struct {
 	MutationNum   float64
 	// If true the gene is enabled
 	IsEnabled     bool
+	// If true the gene is protected from mutation of its link weight and from splitting by new node
+	IsFrozen      bool
 }
 
 // Creates new Gene
@@ -39,8 +41,10 @@ recurrent bool, inov_num int64, mut_num float64) *Gene {
 
 // Construct a gene off of another gene as a duplicate
 func NewGeneCopy(g *Gene, trait *neat.Trait, in_node, out_node *network.NNode) *Gene {
-	return newGene(network.NewLinkWithTrait(trait, g.Link.Weight, in_node, out_node, g.Link.IsRecurrent),
+	gene := newGene(network.NewLinkWithTrait(trait, g.Link.Weight, in_node, out_node, g.Link.IsRecurrent),
 		g.InnovationNum, g.MutationNum, true)
+	gene.IsFrozen = g.IsFrozen
+	return gene
 }
 
 func newGene(link *network.Link, inov_num int64, mut_num float64, enabled bool) *Gene {
@@ -61,6 +65,9 @@ func (g *Gene) String() string {
 	if g.Link.IsRecurrent {
 		recurr_str = " -RECUR-"
 	}
+	if g.IsFrozen {
+		recurr_str += " -FROZEN-"
+	}
 	trait_str := ""
 	if g.Link.Trait != nil {
 		trait_str = fmt.Sprintf(" Link's trait_id: %d", g.Link.Trait.Id)
@@ -10,6 +10,9 @@ import (
 	"errors"
 	"math"
 	"reflect"
+	"hash/fnv"
+	"encoding/binary"
+	"sort"
 )
 
 // A Genome is the primary source of genotype information used to create  a phenotype.
@@ -41,6 +44,21 @@ type Genome struct {
 
 	// Allows Genome to be matched with its Network
 	Phenotype    *network.Network
+
+	// The flag to indicate that genome was mutated after its Phenotype was built
+	dirty        bool
+}
+
+// The breakdown of the Genome complexity
+type GenomeComplexity struct {
+	// The number of nodes
+	Nodes          int
+	// The number of enabled genes
+	EnabledGenes   int
+	// The number of disabled genes
+	DisabledGenes  int
+	// The number of genes with recurrent links (both enabled and disabled)
+	RecurrentLinks int
 }
 
 // Constructor which takes full genome specs and puts them into the new one
@@ -64,6 +82,74 @@ func NewModularGenome(id int, t []*neat.Trait, n []*network.NNode, g []*Gene, mi
 	}
 }
 
+// Creates the minimal genome with in inputs connected to out outputs by links with random weights according to
+// context.InitialConnectivity. If context.WithBiasNode is set, the bias node connected to all outputs will be added
+// after the inputs. The outputs left without incoming links by sparse initial connectivity will be connected to the
+// bias node (or the first input if no bias), while unconnected genome has no genes at all and relies on structural
+// mutations to grow connections. The innovation number of each gene is determined by the pair of nodes it connects,
+// thus genomes created with sparse connectivity share innovation numbers of the same links.
+func NewMinimalGenome(id, in, out int, context *neat.NeatContext) *Genome {
+	// Create a dummy trait (this is for future expansion of the system)
+	new_trait := neat.NewTrait()
+	new_trait.Id = 1
+
+	gnome := Genome{
+		Id:id,
+		Traits:[]*neat.Trait{new_trait},
+		Nodes:make([]*network.NNode, 0),
+		Genes:make([]*Gene, 0),
+	}
+
+	// Build the sensor nodes
+	sensors := make([]*network.NNode, 0)
+	for ncount := 1; ncount <= in; ncount++ {
+		sensors = append(sensors, network.NewNNode(ncount, network.InputNeuron))
+	}
+	if context.WithBiasNode {
+		sensors = append(sensors, network.NewNNode(in + 1, network.BiasNeuron))
+	}
+	// Build the output nodes
+	outputs := make([]*network.NNode, out)
+	for i := range outputs {
+		outputs[i] = network.NewNNode(len(sensors) + i + 1, network.OutputNeuron)
+	}
+	for _, n := range append(sensors, outputs...) {
+		n.Trait = new_trait
+		gnome.Nodes = append(gnome.Nodes, n)
+	}
+
+	if context.InitialConnectivity == neat.Unconnected {
+		return &gnome
+	}
+
+	// Connect sensors to outputs
+	for o, out_node := range outputs {
+		connected := false
+		for i, in_node := range sensors {
+			if context.InitialConnectivity == neat.SparseConnected && rand.Float64() >= context.InitialConnectionFraction {
+				continue
+			}
+			innov_num := int64(o * len(sensors) + i + 1)
+			weight := float64(utils.RandSign()) * rand.Float64()
+			gene := NewGeneWithTrait(new_trait, weight, in_node, out_node, false, innov_num, weight)
+			gnome.Genes = append(gnome.Genes, gene)
+			connected = true
+		}
+		if !connected && len(sensors) > 0 {
+			// keep output connected to bias or the first input
+			i := len(sensors) - 1
+			if !context.WithBiasNode {
+				i = 0
+			}
+			innov_num := int64(o * len(sensors) + i + 1)
+			weight := float64(utils.RandSign()) * rand.Float64()
+			gene := NewGeneWithTrait(new_trait, weight, sensors[i], out_node, false, innov_num, weight)
+			gnome.Genes = append(gnome.Genes, gene)
+		}
+	}
+	return &gnome
+}
+
 // This special constructor creates a Genome with in inputs, out outputs, n out of nmax hidden units, and random
 // connectivity.  If rec is true then recurrent connections will be included. The last input is a bias
 // link_prob is the probability of a link. The created genome is not modular.
@@ -254,6 +340,246 @@ func (g *Genome) Extrons() int {
 	return total
 }
 
+// Returns the breakdown of this genome complexity
+func (g *Genome) Complexity() GenomeComplexity {
+	c := GenomeComplexity{Nodes:len(g.Nodes)}
+	for _, gene := range g.Genes {
+		if gene.IsEnabled {
+			c.EnabledGenes++
+		} else {
+			c.DisabledGenes++
+		}
+		if gene.Link.IsRecurrent {
+			c.RecurrentLinks++
+		}
+	}
+	return c
+}
+
+// Returns hash of this genome topology computed from types and activation functions of nodes and from innovation
+// numbers, enabled flags and connected nodes of sorted genes. The link weights are ignored. Genetically identical genomes has equal hashes regardless of
+// the genes order.
+func (g *Genome) StructuralHash() uint64 {
+	return g.hash(false)
+}
+
+// Returns hash of this genome topology and parameters, i.e., includes link weights, node biases and parameters of node
+// traits into the StructuralHash computation.
+func (g *Genome) FullHash() uint64 {
+	return g.hash(true)
+}
+
+// Computes genome hash optionally including its weights
+func (g *Genome) hash(with_weights bool) uint64 {
+	h := fnv.New64a()
+	write := func(v interface{}) {
+		binary.Write(h, binary.LittleEndian, v)
+	}
+
+	nodes := make([]*network.NNode, len(g.Nodes))
+	copy(nodes, g.Nodes)
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Id < nodes[j].Id
+	})
+	for _, n := range nodes {
+		write(int64(n.Id))
+		write(n.NeuronType)
+		write(n.ActivationType)
+		if with_weights {
+			write(n.Bias)
+			if n.Trait != nil {
+				write(n.Trait.Params)
+			}
+		}
+	}
+
+	genes := make([]*Gene, len(g.Genes))
+	copy(genes, g.Genes)
+	sort.Slice(genes, func(i, j int) bool {
+		return genes[i].InnovationNum < genes[j].InnovationNum
+	})
+	for _, gn := range genes {
+		write(gn.InnovationNum)
+		write(int64(gn.Link.InNode.Id))
+		write(int64(gn.Link.OutNode.Id))
+		write(gn.IsEnabled)
+		write(gn.Link.IsRecurrent)
+		if with_weights {
+			write(gn.Link.Weight)
+		}
+	}
+
+	c_genes := make([]*MIMOControlGene, len(g.ControlGenes))
+	copy(c_genes, g.ControlGenes)
+	sort.Slice(c_genes, func(i, j int) bool {
+		return c_genes[i].InnovationNum < c_genes[j].InnovationNum
+	})
+	for _, cg := range c_genes {
+		write(cg.InnovationNum)
+		write(int64(cg.ControlNode.Id))
+		write(cg.ControlNode.ActivationType)
+		write(cg.IsEnabled)
+	}
+	return h.Sum64()
+}
+
+// PruneUnreachableNodes removes hidden nodes which has no forward path through enabled genes to any output node along
+// with all genes connected to them. The input, output and nodes connected to control genes are never removed.
+// Returns the number of removed nodes. The phenotype should be rebuilt with Genesis if any node was removed.
+func (g *Genome) PruneUnreachableNodes() int {
+	// collect nodes with path to output walking backward from outputs, the sensors are always kept
+	reachable := make(map[int]bool)
+	for _, n := range g.Nodes {
+		if n.NeuronType != network.HiddenNeuron {
+			reachable[n.Id] = true
+		}
+	}
+	for _, cg := range g.ControlGenes {
+		for _, l := range cg.ControlNode.Incoming {
+			reachable[l.InNode.Id] = true
+		}
+		for _, l := range cg.ControlNode.Outgoing {
+			reachable[l.OutNode.Id] = true
+		}
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, gene := range g.Genes {
+			if gene.IsEnabled && reachable[gene.Link.OutNode.Id] && !reachable[gene.Link.InNode.Id] {
+				reachable[gene.Link.InNode.Id] = true
+				changed = true
+			}
+		}
+	}
+
+	// remove unreachable nodes and their genes, the input and output nodes are immutable
+	nodes := make([]*network.NNode, 0, len(g.Nodes))
+	for _, n := range g.Nodes {
+		if reachable[n.Id] || n.NeuronType != network.HiddenNeuron {
+			nodes = append(nodes, n)
+		}
+	}
+	removed := len(g.Nodes) - len(nodes)
+	if removed == 0 {
+		return 0
+	}
+	genes := make([]*Gene, 0, len(g.Genes))
+	for _, gene := range g.Genes {
+		if reachable[gene.Link.InNode.Id] && reachable[gene.Link.OutNode.Id] {
+			genes = append(genes, gene)
+		}
+	}
+	g.Nodes, g.Genes = nodes, genes
+	g.dirty = true
+
+	return removed
+}
+
+// Compact removes disabled genes which are superseded by enabled bypass through hidden node (i.e. the genes split by add
+// node mutation). The innovation numbers of remaining genes are kept intact. As removal of genes affects compatibility
+// with other genomes, this method is never called by the library itself. Returns the number of removed genes.
+func (g *Genome) Compact() int {
+	// index enabled links
+	enabled := make(map[[2]int]bool)
+	for _, gene := range g.Genes {
+		if gene.IsEnabled {
+			enabled[[2]int{gene.Link.InNode.Id, gene.Link.OutNode.Id}] = true
+		}
+	}
+
+	genes := make([]*Gene, 0, len(g.Genes))
+	for _, gene := range g.Genes {
+		if !gene.IsEnabled && !gene.IsFrozen && g.hasBypass(gene, enabled) {
+			continue
+		}
+		genes = append(genes, gene)
+	}
+	removed := len(g.Genes) - len(genes)
+	if removed > 0 {
+		g.Genes = genes
+		g.dirty = true
+	}
+
+	return removed
+}
+
+// Returns true if there is hidden node connected by enabled links both from IN node and to OUT node of provided gene
+func (g *Genome) hasBypass(gene *Gene, enabled map[[2]int]bool) bool {
+	in_id, out_id := gene.Link.InNode.Id, gene.Link.OutNode.Id
+	for _, n := range g.Nodes {
+		if n.NeuronType == network.HiddenNeuron && enabled[[2]int{in_id, n.Id}] && enabled[[2]int{n.Id, out_id}] {
+			return true
+		}
+	}
+	return false
+}
+
+// SyncMutationNumbers sets the mutation number of each gene equal to the current weight of its link. The mutation
+// numbers are used in the weight difference term of compatibility and may diverge from the actual weights after mating.
+func (g *Genome) SyncMutationNumbers() {
+	g.dirty = true
+	for _, gene := range g.Genes {
+		gene.MutationNum = gene.Link.Weight
+	}
+}
+
+// HasCycle returns true if graph of enabled genes of this genome has any cycle (recurrence). The recurrent flags of genes
+// are ignored, because they can be stale after mating.
+func (g *Genome) HasCycle() bool {
+	return len(g.Cycles()) > 0
+}
+
+// Cycles returns the cycles found by depth-first search over enabled genes of this genome. Each cycle is listed as IDs
+// of nodes along it, starting from the node where cycle was closed. The self-loop is reported as cycle with one node.
+// Only one cycle per back edge is reported, thus not all elementary cycles of densely connected graph may be listed.
+func (g *Genome) Cycles() [][]int {
+	adjacent := make(map[int][]int)
+	for _, gene := range g.Genes {
+		if gene.IsEnabled {
+			in_id := gene.Link.InNode.Id
+			adjacent[in_id] = append(adjacent[in_id], gene.Link.OutNode.Id)
+		}
+	}
+
+	const (
+		unvisited = iota
+		inPath
+		done
+	)
+	state := make(map[int]int)
+	path := make([]int, 0)
+	cycles := make([][]int, 0)
+	var visit func(id int)
+	visit = func(id int) {
+		state[id] = inPath
+		path = append(path, id)
+		for _, next := range adjacent[id] {
+			switch state[next] {
+			case unvisited:
+				visit(next)
+			case inPath:
+				// back edge found - the cycle is the tail of path starting at next node
+				for i := len(path) - 1; i >= 0; i-- {
+					if path[i] == next {
+						cycle := make([]int, len(path) - i)
+						copy(cycle, path[i:])
+						cycles = append(cycles, cycle)
+						break
+					}
+				}
+			}
+		}
+		path = path[:len(path) - 1]
+		state[id] = done
+	}
+	for _, n := range g.Nodes {
+		if state[n.Id] == unvisited {
+			visit(n.Id)
+		}
+	}
+	return cycles
+}
+
 // Tests if given genome is equal to this one genetically and phenotypically. This method will check that both genomes has the same traits, nodes and genes.
 // If mismatch detected the error will be returned with mismatch details.
 func (g *Genome) IsEqual(og *Genome) (bool, error) {
@@ -322,11 +648,9 @@ func (g *Genome) getLastNodeId() (int, error) {
 // Return innovation number of last gene in Genome + 1
 func (g *Genome) getNextGeneInnovNum() (int64, error) {
 	inn_num := int64(0)
-	// check connection genes
+	// check connection genes, the genome may have no genes with unconnected initial connectivity
 	if len(g.Genes) > 0 {
 		inn_num = g.Genes[len(g.Genes) - 1].InnovationNum
-	} else {
-		return -1, errors.New("Genome has no Genes")
 	}
 	// check control genes if any
 	if len(g.ControlGenes) > 0 {
@@ -366,8 +690,21 @@ func (g *Genome) hasGene(gene *Gene) bool {
 	return false
 }
 
-// Generate a Network phenotype from this Genome with specified id
+// Invalidate marks this genome as modified, so that the next Genesis builds new phenotype instead of returning the cached
+// one. It must be called after genome was modified directly rather than by its methods, e.g., by changing gene weights.
+func (g *Genome) Invalidate() {
+	g.dirty = true
+}
+
+// Generate a Network phenotype from this Genome with specified id. The created network will be also stored as
+// Phenotype of this Genome. This method must be called before the network of genome can be activated, i.e. after
+// genome was read from file or modified by mutation. If genome was not mutated since the last invocation, the previously
+// built Phenotype with the same id is returned.
 func (g *Genome) Genesis(net_id int) (*network.Network, error) {
+	if !g.dirty && g.Phenotype != nil && g.Phenotype.Id == net_id {
+		return g.Phenotype, nil
+	}
+
 	// Inputs and outputs will be collected here for the network.
 	// All nodes are collected in an all_list -
 	// this is useful for network traversing routines
@@ -394,10 +731,6 @@ func (g *Genome) Genesis(net_id int) (*network.Network, error) {
 		n.PhenotypeAnalogue = new_node
 	}
 
-	if len(g.Genes) == 0 {
-		return nil, errors.New("The network built whitout GENES; the result can be unpredictable")
-	}
-
 	if len(out_list) == 0 {
 		return nil, errors.New(fmt.Sprintf("The network whitout OUTPUTS; the result can be unpredictable. Genome: %s", g))
 	}
@@ -462,6 +795,7 @@ func (g *Genome) Genesis(net_id int) (*network.Network, error) {
 	// Attach genotype and phenotype together:
 	// genotype points to owner phenotype (new_net)
 	g.Phenotype = new_net
+	g.dirty = false
 
 	return new_net, nil
 }
@@ -567,9 +901,6 @@ func (g *Genome) duplicate(new_id int) (*Genome, error) {
 // For debugging: A number of tests can be run on a genome to check its integrity.
 // Note: Some of these tests do not indicate a bug, but rather are meant to be used to detect specific system states.
 func (g *Genome) verify() (bool, error) {
-	if len(g.Genes) == 0 {
-		return false, errors.New("Genome has no Genes")
-	}
 	if len(g.Nodes) == 0 {
 		return false, errors.New("Genome has no Nodes")
 	}
@@ -601,6 +932,11 @@ func (g *Genome) verify() (bool, error) {
 		}
 	}
 
+	// Check input and output nodes
+	if err := g.verifyIO(); err != nil {
+		return false, err
+	}
+
 	// Check for NNodes being out of order
 	last_id := 0
 	for _, n := range g.Nodes {
@@ -632,6 +968,62 @@ func (g *Genome) verify() (bool, error) {
 	return true, nil
 }
 
+// Checks that input and output nodes of this genome are intact: genome has at least one input and one output node,
+// the IDs of input and output nodes are unique, all sensors are of input or bias type and no gene or control gene
+// leads into sensor node.
+func (g *Genome) verifyIO() error {
+	inputs, outputs := 0, 0
+	io_ids := make(map[int]bool)
+	for _, n := range g.Nodes {
+		if n.NeuronType == network.HiddenNeuron {
+			continue
+		}
+		if io_ids[n.Id] {
+			return errors.New(fmt.Sprintf("Duplicate ID of input/output node: %d", n.Id))
+		}
+		io_ids[n.Id] = true
+		switch n.NeuronType {
+		case network.InputNeuron, network.BiasNeuron:
+			if n.NodeType() != network.SensorNode {
+				return errors.New(fmt.Sprintf("Input node %d is not a sensor", n.Id))
+			}
+			inputs++
+		case network.OutputNeuron:
+			outputs++
+		}
+	}
+	if inputs == 0 {
+		return errors.New("Genome has no input nodes")
+	}
+	if outputs == 0 {
+		return errors.New("Genome has no output nodes")
+	}
+	for _, gn := range g.Genes {
+		if t := gn.Link.OutNode.NeuronType; t == network.InputNeuron || t == network.BiasNeuron {
+			return errors.New(fmt.Sprintf("Gene leads into input node: %s", gn))
+		}
+	}
+	for _, cg := range g.ControlGenes {
+		for _, l := range cg.ControlNode.Outgoing {
+			if t := l.OutNode.NeuronType; t == network.InputNeuron || t == network.BiasNeuron {
+				return errors.New(fmt.Sprintf("Control gene %d leads into input node: %d", cg.ControlNode.Id, l.OutNode.Id))
+			}
+		}
+	}
+	return nil
+}
+
+// Returns the trait of this genome at the position stored by link innovation. The number of traits may differ between
+// genomes (see mutateAddTrait and mutateMergeTraits), thus the position is clamped to the traits of this genome.
+func (g *Genome) innovationTrait(trait_num int) *neat.Trait {
+	if trait_num >= len(g.Traits) {
+		trait_num = len(g.Traits) - 1
+	} else if trait_num < 0 {
+		trait_num = 0
+	}
+	return g.Traits[trait_num]
+}
+
 // Inserts a NNode into a given ordered list of NNodes in ascending order by NNode ID
 func nodeInsert(nodes[]*network.NNode, n *network.NNode) []*network.NNode {
 	index := len(nodes)
@@ -706,10 +1098,7 @@ func geneInsert(genes[]*Gene, g *Gene) []*Gene {
 // 	(2) you don't need to know a priori what the important features of the domain are.
 // If all sensors already connected than do nothing.
 func (g *Genome) mutateConnectSensors(pop *Population, context *neat.NeatContext) (bool, error) {
-
-	if len(g.Genes) == 0 {
-		return false, errors.New("Genome has no genes")
-	}
+	g.dirty = true
 
 	// Find all the sensors and outputs
 	sensors := make([]*network.NNode, 0)
@@ -769,10 +1158,11 @@ func (g *Genome) mutateConnectSensors(pop *Population, context *neat.NeatContext
 					inn.OutNodeId == output.Id &&
 					inn.IsRecurrent == false {
 
-					new_gene = NewGeneWithTrait(g.Traits[inn.NewTraitNum], inn.NewWeight,
+					new_gene = NewGeneWithTrait(g.innovationTrait(inn.NewTraitNum), inn.NewWeight,
 						sensor, output, false, inn.InnovationNum, 0)
 
 					innovation_found = true
+					pop.innovationReusedSynced()
 					break
 				}
 			}
@@ -782,7 +1172,7 @@ func (g *Genome) mutateConnectSensors(pop *Population, context *neat.NeatContext
 				// Choose a random trait
 				trait_num := rand.Intn(len(g.Traits))
 				// Choose the new weight
-				new_weight := float64(utils.RandSign()) * rand.Float64() * 10.0
+				new_weight := capWeight(float64(utils.RandSign()) * rand.Float64() * 10.0, context.WeightCap)
 				// read next innovation id
 				next_innov_id := pop.getNextInnovationNumberAndIncrement()
 
@@ -816,6 +1206,7 @@ func (g *Genome) mutateConnectSensors(pop *Population, context *neat.NeatContext
 // Mutate the genome by adding a new link between two random NNodes,
 // if NNodes are already connected, keep trying conf.NewLinkTries times
 func (g *Genome) mutateAddLink(pop *Population, context *neat.NeatContext) (bool, error) {
+	g.dirty = true
 	// If the phenotype does not exist, exit on false, print error
 	// Note: This should never happen - if it does there is a bug
 	if g.Phenotype == nil {
@@ -823,12 +1214,18 @@ func (g *Genome) mutateAddLink(pop *Population, context *neat.NeatContext) (bool
 	} else if len(g.Nodes) == 0 {
 		return false, errors.New("Genome has no nodes to be connected by new link")
 	}
+	if context.MaxGenes > 0 && len(g.Genes) >= context.MaxGenes {
+		// The genome already reached the maximal number of genes allowed
+		neat.DebugLog(fmt.Sprintf("GENOME: Add link skipped for genome [%d] with genes limit [%d] reached",
+			g.Id, context.MaxGenes))
+		return false, nil
+	}
 
 	nodes_len := len(g.Nodes)
 
-	// Decide whether to make link recurrent
+	// Decide whether to make link recurrent (never if only feed-forward networks allowed)
 	do_recur := false
-	if rand.Float64() < context.RecurOnlyProb {
+	if !context.FeedForwardOnly && rand.Float64() < context.RecurOnlyProb {
 		do_recur = true
 	}
 
@@ -841,6 +1238,11 @@ func (g *Genome) mutateAddLink(pop *Population, context *neat.NeatContext) (bool
 			break
 		}
 	}
+	if first_non_sensor == nodes_len {
+		// There is no nodes to receive new link
+		neat.DebugLog(fmt.Sprintf("GENOME: Add link skipped for genome [%d] without non sensor nodes", g.Id))
+		return false, nil
+	}
 
 	// Made attempts to find an unconnected pair
 	try_count := 0
@@ -895,6 +1297,12 @@ func (g *Genome) mutateAddLink(pop *Population, context *neat.NeatContext) (bool
 			}
 		}
 
+		if !link_exists && context.FeedForwardOnly && (node_1.Id == node_2.Id || g.hasPath(node_2.Id, node_1.Id, true)) {
+			// The link would close a cycle through enabled genes. The phenotype recurrence check is not enough here,
+			// because the phenotype can be stale after previous mutations of this genome.
+			link_exists = true
+		}
+
 		if !link_exists {
 			// These are used to avoid getting stuck in an infinite loop checking for recursion
 			// Note that we check for recursion to control the frequency of adding recurrent links rather
@@ -936,9 +1344,10 @@ func (g *Genome) mutateAddLink(pop *Population, context *neat.NeatContext) (bool
 				inn.IsRecurrent == do_recur {
 
 				// Create new gene
-				new_gene = NewGeneWithTrait(g.Traits[inn.NewTraitNum], inn.NewWeight, node_1, node_2, do_recur, inn.InnovationNum, 0)
+				new_gene = NewGeneWithTrait(g.innovationTrait(inn.NewTraitNum), inn.NewWeight, node_1, node_2, do_recur, inn.InnovationNum, 0)
 
 				innovation_found = true
+				pop.innovationReusedSynced()
 				break
 			}
 		}
@@ -947,7 +1356,7 @@ func (g *Genome) mutateAddLink(pop *Population, context *neat.NeatContext) (bool
 			// Choose a random trait
 			trait_num := rand.Intn(len(g.Traits))
 			// Choose the new weight
-			new_weight := float64(utils.RandSign()) * rand.Float64() * 10.0
+			new_weight := capWeight(float64(utils.RandSign()) * rand.Float64() * 10.0, context.WeightCap)
 			// read next innovation id
 			next_innov_id := pop.getNextInnovationNumberAndIncrement()
 
@@ -988,9 +1397,17 @@ func (g *Genome) mutateAddLink(pop *Population, context *neat.NeatContext) (bool
 // whether they match. If they do, the same innovation numbers will be assigned to the new genes. If a disabled link
 // is chosen, then the method just exits with false.
 func (g *Genome) mutateAddNode(pop *Population, context *neat.NeatContext) (bool, error) {
+	g.dirty = true
 	if len(g.Genes) == 0 {
 		return false, nil // it's possible to have such a network without any link
 	}
+	if (context.MaxNodes > 0 && len(g.Nodes) >= context.MaxNodes) ||
+		(context.MaxGenes > 0 && len(g.Genes) >= context.MaxGenes) {
+		// The genome already reached the maximal size allowed
+		neat.DebugLog(fmt.Sprintf("GENOME: Add node skipped for genome [%d] with nodes/genes limit [%d/%d] reached",
+			g.Id, context.MaxNodes, context.MaxGenes))
+		return false, nil
+	}
 
 	// First, find a random gene already in the genome
 	found := false
@@ -1001,7 +1418,7 @@ func (g *Genome) mutateAddNode(pop *Population, context *neat.NeatContext) (bool
 	if len(g.Genes) < 15 {
 		for _, gn := range g.Genes {
 			// Now randomize which gene is chosen.
-			if gn.IsEnabled && gn.Link.InNode.NeuronType != network.BiasNeuron && rand.Float32() >= 0.3 {
+			if gn.IsEnabled && !gn.IsFrozen && gn.Link.InNode.NeuronType != network.BiasNeuron && rand.Float32() >= 0.3 {
 				gene = gn
 				found = true
 				break
@@ -1013,7 +1430,7 @@ func (g *Genome) mutateAddNode(pop *Population, context *neat.NeatContext) (bool
 		for try_count < 20 && !found {
 			gene_num := rand.Intn(len(g.Genes))
 			gene = g.Genes[gene_num]
-			if gene.IsEnabled && gene.Link.InNode.NeuronType != network.BiasNeuron {
+			if gene.IsEnabled && !gene.IsFrozen && gene.Link.InNode.NeuronType != network.BiasNeuron {
 				found = true
 			}
 			try_count++
@@ -1063,12 +1480,17 @@ func (g *Genome) mutateAddNode(pop *Population, context *neat.NeatContext) (bool
 			// By convention, it will point to the first trait
 			// Note: In future may want to change this
 			new_node.Trait = g.Traits[0]
+			if context.DefaultHiddenActivation != 0 {
+				new_node.ActivationType = context.DefaultHiddenActivation
+			}
 
 			// Create the new Genes
-			new_gene_1 = NewGeneWithTrait(trait, 1.0, in_node, new_node, link.IsRecurrent, inn.InnovationNum, 0)
-			new_gene_2 = NewGeneWithTrait(trait, old_weight, new_node, out_node, false, inn.InnovationNum2, 0)
+			weight_1, weight_2 := splitWeights(old_weight, context)
+			new_gene_1 = NewGeneWithTrait(trait, weight_1, in_node, new_node, link.IsRecurrent, inn.InnovationNum, 0)
+			new_gene_2 = NewGeneWithTrait(trait, weight_2, new_node, out_node, false, inn.InnovationNum2, 0)
 
 			innovation_found = true
+			pop.innovationReusedSynced()
 			break
 		}
 	}
@@ -1081,22 +1503,26 @@ func (g *Genome) mutateAddNode(pop *Population, context *neat.NeatContext) (bool
 		new_node = network.NewNNode(new_node_id, network.HiddenNeuron)
 		// By convention, it will point to the first trait
 		new_node.Trait = g.Traits[0]
-		// Set node activation function as random from a list of types registered with context
-		if act_type, err := context.RandomNodeActivationType(); err != nil {
+		// Set node activation function either as configured default or as random from a list of types registered
+		// with context
+		if context.DefaultHiddenActivation != 0 {
+			new_node.ActivationType = context.DefaultHiddenActivation
+		} else if act_type, err := context.RandomNodeActivationType(); err != nil {
 			return false, err
 		} else {
 			new_node.ActivationType = act_type
 		}
 
+		weight_1, weight_2 := splitWeights(old_weight, context)
 		// get the next innovation id for gene 1
 		gene_innov_1 := pop.getNextInnovationNumberAndIncrement()
 		// create gene with the current gene innovation
-		new_gene_1 = NewGeneWithTrait(trait, 1.0, in_node, new_node, link.IsRecurrent, gene_innov_1, 0);
+		new_gene_1 = NewGeneWithTrait(trait, weight_1, in_node, new_node, link.IsRecurrent, gene_innov_1, 0);
 
 		// get the next innovation id for gene 2
 		gene_innov_2 := pop.getNextInnovationNumberAndIncrement()
 		// create the second gene with this innovation incremented
-		new_gene_2 = NewGeneWithTrait(trait, old_weight, new_node, out_node, false, gene_innov_2, 0);
+		new_gene_2 = NewGeneWithTrait(trait, weight_2, new_node, out_node, false, gene_innov_2, 0);
 
 		// Store innovation
 		innov := NewInnovationForNode(in_node.Id, out_node.Id, gene_innov_1, gene_innov_2, new_node.Id, gene.InnovationNum)
@@ -1123,11 +1549,31 @@ func (g *Genome) mutateAddNode(pop *Population, context *neat.NeatContext) (bool
 	return true, nil
 }
 
+// Returns weights of the links into and out of new node created by splitting the link with given weight according to
+// context.AddNodeSplitWeights
+func splitWeights(old_weight float64, context *neat.NeatContext) (float64, float64) {
+	switch context.AddNodeSplitWeights {
+	case neat.PreserveSplitWeights:
+		weight := math.Sqrt(math.Abs(old_weight))
+		if old_weight < 0 {
+			return weight, -weight
+		}
+		return weight, weight
+	case neat.RandomSplitWeights:
+		return float64(utils.RandSign()) * rand.Float64(), float64(utils.RandSign()) * rand.Float64()
+	default:
+		return 1.0, old_weight
+	}
+}
+
 // Adds Gaussian noise to link weights either GAUSSIAN or COLD_GAUSSIAN (from zero).
-// The COLD_GAUSSIAN means ALL connection weights will be given completely new values
-func (g *Genome) mutateLinkWeights(power, rate float64, mutation_type mutatorType) (bool, error) {
+// The COLD_GAUSSIAN means ALL connection weights will be given completely new values.
+// If weight_cap is positive the resulting weights are clamped to [-weight_cap, weight_cap]. The power of mutation
+// of genes leading to the output nodes is scaled by output_bias.
+func (g *Genome) mutateLinkWeights(power, rate, weight_cap, output_bias float64, mutation_type mutatorType) (bool, error) {
+	g.dirty = true
 	if len(g.Genes) == 0 {
-		return false, errors.New("Genome has no genes")
+		return false, nil // it's possible to have such a network without any link
 	}
 
 	// Once in a while really shake things up
@@ -1142,6 +1588,11 @@ func (g *Genome) mutateLinkWeights(power, rate float64, mutation_type mutatorTyp
 	var gauss_point, cold_gauss_point float64
 
 	for _, gene := range g.Genes {
+		if gene.IsFrozen {
+			// The weights of frozen genes never change
+			num += 1.0
+			continue
+		}
 		// The following if determines the probabilities of doing cold gaussian
 		// mutation, meaning the probability of replacing a link weight with
 		// another, entirely random weight. It is meant to bias such mutations
@@ -1165,7 +1616,11 @@ func (g *Genome) mutateLinkWeights(power, rate float64, mutation_type mutatorTyp
 			}
 		}
 
-		rand_val := float64(utils.RandSign()) * rand.Float64() * power
+		gene_power := power
+		if gene.Link.OutNode.NeuronType == network.OutputNeuron {
+			gene_power *= output_bias
+		}
+		rand_val := float64(utils.RandSign()) * rand.Float64() * gene_power
 		if mutation_type == gaussianMutator {
 			rand_choice := rand.Float64()
 			if rand_choice > gauss_point {
@@ -1176,6 +1631,7 @@ func (g *Genome) mutateLinkWeights(power, rate float64, mutation_type mutatorTyp
 		} else if mutation_type == goldGaussianMutator {
 			gene.Link.Weight = rand_val
 		}
+		gene.Link.Weight = capWeight(gene.Link.Weight, weight_cap)
 
 		// Record the innovation
 		gene.MutationNum = gene.Link.Weight
@@ -1188,6 +1644,7 @@ func (g *Genome) mutateLinkWeights(power, rate float64, mutation_type mutatorTyp
 
 // Perturb params in one trait
 func (g *Genome) mutateRandomTrait(context *neat.NeatContext) (bool, error) {
+	g.dirty = true
 	if len(g.Traits) == 0 {
 		return false, errors.New("Genome has no traits")
 	}
@@ -1202,8 +1659,11 @@ func (g *Genome) mutateRandomTrait(context *neat.NeatContext) (bool, error) {
 
 // This chooses a random gene, extracts the link from it and re-points the link to a random trait
 func (g *Genome) mutateLinkTrait(times int) (bool, error) {
-	if len(g.Traits) == 0 || len(g.Genes) == 0 {
-		return false, errors.New("Genome has either no traits od genes")
+	g.dirty = true
+	if len(g.Traits) == 0 {
+		return false, errors.New("Genome has no traits")
+	} else if len(g.Genes) == 0 {
+		return false, nil // it's possible to have such a network without any link
 	}
 	for loop := 0; loop < times; loop++ {
 		// Choose a random trait number
@@ -1221,6 +1681,7 @@ func (g *Genome) mutateLinkTrait(times int) (bool, error) {
 
 // This chooses a random node and re-points the node to a random trait specified number of times
 func (g *Genome) mutateNodeTrait(times int) (bool, error) {
+	g.dirty = true
 	if len(g.Traits) == 0 || len(g.Nodes) == 0 {
 		return false, errors.New("Genome has either no traits or nodes")
 	}
@@ -1237,10 +1698,110 @@ func (g *Genome) mutateNodeTrait(times int) (bool, error) {
 	return true, nil
 }
 
+// Adds new trait with random parameters to this genome and re-points a random node to it. The ID of new trait follows
+// the largest ID of existing traits, so the traits stay numbered contiguously as expected by mating.
+func (g *Genome) mutateAddTrait(context *neat.NeatContext) (bool, error) {
+	g.dirty = true
+	if len(g.Traits) == 0 || len(g.Nodes) == 0 {
+		return false, errors.New("Genome has either no traits or nodes")
+	}
+	last := g.Traits[0]
+	for _, tr := range g.Traits {
+		if tr.Id > last.Id {
+			last = tr
+		}
+	}
+	new_trait := neat.NewTraitCopy(last)
+	new_trait.Id = last.Id + 1
+	for i := range new_trait.Params {
+		new_trait.Params[i] = rand.Float64()
+	}
+	g.Traits = append(g.Traits, new_trait)
+
+	// express new trait by random node
+	node_num := rand.Intn(len(g.Nodes))
+	g.Nodes[node_num].Trait = new_trait
+
+	return true, nil
+}
+
+// Merges two random traits of this genome into one with averaged parameters. All nodes, links and control nodes
+// referencing the removed trait are re-pointed to the merged one, and IDs of traits are renumbered to stay contiguous.
+func (g *Genome) mutateMergeTraits() (bool, error) {
+	if len(g.Traits) < 2 {
+		// nothing to merge
+		return false, nil
+	}
+	g.dirty = true
+	// Choose two different random traits
+	i := rand.Intn(len(g.Traits) - 1)
+	j := i + 1 + rand.Intn(len(g.Traits) - i - 1)
+	kept, removed := g.Traits[i], g.Traits[j]
+	merged, err := neat.NewTraitAvrg(kept, removed)
+	if err != nil {
+		return false, err
+	}
+	kept.Params = merged.Params
+
+	// re-point references
+	for _, nd := range g.Nodes {
+		if nd.Trait == removed {
+			nd.Trait = kept
+		}
+	}
+	for _, gn := range g.Genes {
+		if gn.Link.Trait == removed {
+			gn.Link.Trait = kept
+		}
+	}
+	for _, cg := range g.ControlGenes {
+		if cg.ControlNode.Trait == removed {
+			cg.ControlNode.Trait = kept
+		}
+	}
+
+	// remove trait and renumber the rest
+	g.Traits = append(g.Traits[:j], g.Traits[j + 1:]...)
+	for _, tr := range g.Traits {
+		if tr.Id > removed.Id {
+			tr.Id--
+		}
+	}
+	return true, nil
+}
+
+// Clamps the weight to [-weight_cap, weight_cap]. The non positive weight_cap means no limit
+func capWeight(weight, weight_cap float64) float64 {
+	if weight_cap <= 0 {
+		return weight
+	}
+	return math.Max(-weight_cap, math.Min(weight_cap, weight))
+}
+
+// This chooses a random neuron node and perturbs its bias by random value within [-power, power]
+func (g *Genome) mutateNodeBias(power float64) (bool, error) {
+	g.dirty = true
+	neurons := make([]*network.NNode, 0)
+	for _, n := range g.Nodes {
+		if n.IsNeuron() {
+			neurons = append(neurons, n)
+		}
+	}
+	if len(neurons) == 0 {
+		return false, nil
+	}
+	// Choose a random neuron and perturb its bias
+	node := neurons[rand.Intn(len(neurons))]
+	node.Bias += float64(utils.RandSign()) * rand.Float64() * power
+
+	return true, nil
+}
+
 // Toggle genes from enable on to enable off or vice versa.  Do it specified number of times.
 func (g *Genome) mutateToggleEnable(times int) (bool, error) {
+	g.dirty = true
 	if len(g.Genes) == 0 {
-		return false, errors.New("Genome has no genes to toggle")
+		return false, nil // it's possible to have such a network without any link
 	}
 	for loop := 0; loop < times; loop++ {
 		// Choose a random gene number
@@ -1264,10 +1825,191 @@ func (g *Genome) mutateToggleEnable(times int) (bool, error) {
 	}
 	return true, nil
 }
+// Disables random enabled gene if another enabled gene connects out of its in-node, so that no section of network
+// will break off and become isolated. Returns true if gene was disabled.
+func (g *Genome) mutateGeneDisable() (bool, error) {
+	g.dirty = true
+	if len(g.Genes) == 0 {
+		return false, nil // it's possible to have such a network without any link
+	}
+	for _, gene_num := range rand.Perm(len(g.Genes)) {
+		gene := g.Genes[gene_num]
+		if !gene.IsEnabled || gene.IsFrozen {
+			continue
+		}
+		for _, check_gene := range g.Genes {
+			if check_gene.Link.InNode.Id == gene.Link.InNode.Id &&
+				check_gene.IsEnabled && check_gene.InnovationNum != gene.InnovationNum {
+				gene.IsEnabled = false
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// Disables random enabled link gene which is not critical, i.e., its removal doesn't disconnect any output from the
+// inputs of network. Returns true if link was disabled.
+func (g *Genome) mutateRemoveLink() (bool, error) {
+	g.dirty = true
+	if len(g.Genes) == 0 {
+		return false, nil // it's possible to have such a network without any link
+	}
+	connected := g.connectedOutputs()
+	for _, gene_num := range rand.Perm(len(g.Genes)) {
+		gene := g.Genes[gene_num]
+		if !gene.IsEnabled || gene.IsFrozen {
+			continue
+		}
+		// check that all outputs connected before stay connected without this link
+		gene.IsEnabled = false
+		critical := false
+		for id, c := range g.connectedOutputs() {
+			if connected[id] && !c {
+				critical = true
+				break
+			}
+		}
+		if !critical {
+			return true, nil
+		}
+		gene.IsEnabled = true
+	}
+	return false, nil
+}
+
+// Returns true if there is a path through genes from the node with from_id to the node with to_id. If enabled_only
+// is set the disabled genes are not considered as part of the path.
+func (g *Genome) hasPath(from_id, to_id int, enabled_only bool) bool {
+	reached := map[int]bool{from_id:true}
+	for changed := true; changed && !reached[to_id]; {
+		changed = false
+		for _, gene := range g.Genes {
+			if (gene.IsEnabled || !enabled_only) && reached[gene.Link.InNode.Id] && !reached[gene.Link.OutNode.Id] {
+				reached[gene.Link.OutNode.Id] = true
+				changed = true
+			}
+		}
+	}
+	return reached[to_id]
+}
+
+// Returns map with IDs of output nodes and flags indicating whether output connected to any input node by enabled genes
+func (g *Genome) connectedOutputs() map[int]bool {
+	reached := make(map[int]bool)
+	for _, n := range g.Nodes {
+		if n.NeuronType == network.InputNeuron {
+			reached[n.Id] = true
+		}
+	}
+	// propagate reachability through enabled links until no more nodes found
+	for changed := true; changed; {
+		changed = false
+		for _, gene := range g.Genes {
+			if gene.IsEnabled && reached[gene.Link.InNode.Id] && !reached[gene.Link.OutNode.Id] {
+				reached[gene.Link.OutNode.Id] = true
+				changed = true
+			}
+		}
+		for _, cg := range g.ControlGenes {
+			if !cg.IsEnabled || reached[cg.ControlNode.Id] {
+				continue
+			}
+			for _, l := range cg.ControlNode.Incoming {
+				if reached[l.InNode.Id] {
+					reached[cg.ControlNode.Id] = true
+					changed = true
+					for _, ol := range cg.ControlNode.Outgoing {
+						reached[ol.OutNode.Id] = true
+					}
+					break
+				}
+			}
+		}
+	}
+
+	connected := make(map[int]bool)
+	for _, n := range g.Nodes {
+		if n.NeuronType == network.OutputNeuron {
+			connected[n.Id] = reached[n.Id]
+		}
+	}
+	return connected
+}
+
+// Adds new disabled link gene between randomly selected nodes which are not connected yet. The new gene changes genotype
+// of this genome and participates in compatibility calculations, but doesn't change its phenotype until enabled.
+// The gene closing a cycle is marked as recurrent and never added if context.FeedForwardOnly is set.
+// Returns true if gene was added.
+func (g *Genome) mutateNeutral(pop *Population, context *neat.NeatContext) (bool, error) {
+	g.dirty = true
+
+	if len(g.Traits) == 0 {
+		return false, errors.New("Genome has no traits")
+	}
+	// The possible targets of the new link
+	targets := make([]*network.NNode, 0)
+	for _, n := range g.Nodes {
+		if !n.IsSensor() {
+			targets = append(targets, n)
+		}
+	}
+	if len(g.Nodes) == 0 || len(targets) == 0 {
+		return false, nil
+	}
+
+	// Find nodes which are not connected
+	var node_1, node_2 *network.NNode
+	found := false
+	for try_count := 0; try_count < context.NewLinkTries && !found; try_count++ {
+		node_1 = g.Nodes[rand.Intn(len(g.Nodes))]
+		node_2 = targets[rand.Intn(len(targets))]
+		found = node_1 != node_2
+		for _, gn := range g.Genes {
+			if gn.Link.InNode.Id == node_1.Id && gn.Link.OutNode.Id == node_2.Id {
+				found = false
+				break
+			}
+		}
+		// The disabled genes are considered as well, because any of them can be re-enabled later
+		if found && context.FeedForwardOnly && g.hasPath(node_2.Id, node_1.Id, false) {
+			found = false
+		}
+	}
+	if !found {
+		return false, nil
+	}
+	is_recur := g.hasPath(node_2.Id, node_1.Id, false)
+
+	var new_gene *Gene
+	// Check to see if this innovation already occurred in the population
+	for _, inn := range pop.Innovations {
+		if inn.innovationType == newLinkInnType && inn.InNodeId == node_1.Id && inn.OutNodeId == node_2.Id &&
+			inn.IsRecurrent == is_recur {
+			new_gene = NewGeneWithTrait(g.innovationTrait(inn.NewTraitNum), inn.NewWeight, node_1, node_2, is_recur, inn.InnovationNum, 0)
+			pop.innovationReusedSynced()
+			break
+		}
+	}
+	// The innovation is totally novel
+	if new_gene == nil {
+		trait_num := rand.Intn(len(g.Traits))
+		new_weight := capWeight(float64(utils.RandSign()) * rand.Float64(), context.WeightCap)
+		next_innov_id := pop.getNextInnovationNumberAndIncrement()
+		new_gene = NewGeneWithTrait(g.Traits[trait_num], new_weight, node_1, node_2, is_recur, next_innov_id, new_weight)
+		pop.addInnovationSynced(NewInnovationForRecurrentLink(node_1.Id, node_2.Id, next_innov_id, new_weight, trait_num, is_recur))
+	}
+	new_gene.IsEnabled = false
+	g.Genes = geneInsert(g.Genes, new_gene)
+
+	return true, nil
+}
+
 // Finds first disabled gene and enable it
 func (g *Genome) mutateGeneReenable() (bool, error) {
+	g.dirty = true
 	if len(g.Genes) == 0 {
-		return false, errors.New("Genome has no genes to re-enable")
+		return false, nil // it's possible to have such a network without any link
 	}
 	for _, gene := range g.Genes {
 		if !gene.IsEnabled {
@@ -1278,8 +2020,9 @@ func (g *Genome) mutateGeneReenable() (bool, error) {
 	return true, nil
 }
 
-// Applies all non-structural mutations to this genome
-func (g *Genome) mutateAllNonstructural(context *neat.NeatContext) (bool, error) {
+// Applies all non-structural mutations to this genome. The link weights mutation power is decayed according to
+// provided generation (see NeatContext#EffectiveWeightMutPower).
+func (g *Genome) mutateAllNonstructural(generation int, context *neat.NeatContext) (bool, error) {
 	res := false
 	var err error
 	if rand.Float64() < context.MutateRandomTraitProb {
@@ -1297,9 +2040,19 @@ func (g *Genome) mutateAllNonstructural(context *neat.NeatContext) (bool, error)
 		res, err = g.mutateNodeTrait(1)
 	}
 
+	if err == nil && context.MutateAddTraitProb > 0 && rand.Float64() < context.MutateAddTraitProb {
+		// mutate add trait
+		res, err = g.mutateAddTrait(context)
+	}
+
+	if err == nil && context.MutateMergeTraitsProb > 0 && rand.Float64() < context.MutateMergeTraitsProb {
+		// mutate merge traits
+		res, err = g.mutateMergeTraits()
+	}
+
 	if err == nil && rand.Float64() < context.MutateLinkWeightsProb {
 		// mutate link weight
-		res, err = g.mutateLinkWeights(context.WeightMutPower, 1.0, gaussianMutator)
+		res, err = g.mutateLinkWeights(context.EffectiveWeightMutPower(generation), 1.0, context.WeightCap, context.OutputWeightMutBias, gaussianMutator)
 	}
 
 	if err == nil && rand.Float64() < context.MutateToggleEnableProb {
@@ -1311,6 +2064,16 @@ func (g *Genome) mutateAllNonstructural(context *neat.NeatContext) (bool, error)
 		// mutate gene reenable
 		res, err = g.mutateGeneReenable();
 	}
+
+	if err == nil && context.MutateNodeBiasProb > 0 && rand.Float64() < context.MutateNodeBiasProb {
+		// mutate node bias
+		res, err = g.mutateNodeBias(context.WeightMutPower)
+	}
+
+	if err == nil && !context.WeightsOnly && context.MutateRemoveLinkProb > 0 && rand.Float64() < context.MutateRemoveLinkProb {
+		// mutate remove link, it changes topology and is not allowed when only weights are evolved
+		res, err = g.mutateRemoveLink()
+	}
 	return res, err
 }
 
@@ -1318,17 +2081,13 @@ func (g *Genome) mutateAllNonstructural(context *neat.NeatContext) (bool, error)
 
 // This method mates this Genome with another Genome g. For every point in each Genome, where each Genome shares
 // the innovation number, the Gene is chosen randomly from either parent.  If one parent has an innovation absent in
-// the other, the baby may inherit the innovation if it is from the more fit parent.
-// The new Genome is given the id in the genomeid argument.
-func (gen *Genome) mateMultipoint(og *Genome, genomeid int, fitness1, fitness2 float64) (*Genome, error) {
-	// Check if genomes has equal number of traits
-	if len(gen.Traits) != len(og.Traits) {
-		return nil, errors.New(fmt.Sprintf("Genomes has different traits count, %d != %d", len(gen.Traits), len(og.Traits)))
-	}
-
-	// First, average the Traits from the 2 parents to form the baby's Traits. It is assumed that trait vectors are
-	// the same length. In the future, may decide on a different method for trait mating.
-	new_traits, err := gen.mateTraits(og)
+// the other, the baby may inherit the innovation if it is from the more fit parent, or from the parent selected by
+// disjoint_inheritance. The new Genome is given the id in the genomeid argument.
+func (gen *Genome) mateMultipoint(og *Genome, genomeid int, fitness1, fitness2 float64,
+	disjoint_inheritance neat.DisjointInheritanceType) (*Genome, error) {
+	// First, pick randomly the Traits from either of 2 parents to form the baby's Traits. It is assumed that trait
+	// vectors are the same length.
+	new_traits, err := gen.mateTraits(og, false)
 	if err != nil {
 		return nil, err
 	}
@@ -1363,12 +2122,16 @@ func (gen *Genome) mateMultipoint(og *Genome, genomeid int, fitness1, fitness2 f
 		(fitness1 == fitness2 && len(gen.Genes) < len(og.Genes)) {
 		p1better = true
 	}
+	if disjoint_inheritance == neat.MoreComplexDisjointInheritance && len(gen.Genes) != len(og.Genes) {
+		// The more complex genome gives its disjoint and excess genes
+		p1better = len(gen.Genes) > len(og.Genes)
+	}
 
 	// Now loop through the Genes of each parent
 	i1, i2, size1, size2 := 0, 0, len(gen.Genes), len(og.Genes)
 	var chosen_gene *Gene
 	for i1 < size1 || i2 < size2 {
-		skip, disable := false, false
+		skip, disable, matching := false, false, false
 
 		// choose best gene
 		if i1 >= size1 {
@@ -1404,6 +2167,7 @@ func (gen *Genome) mateMultipoint(og *Genome, genomeid int, fitness1, fitness2 f
 				}
 				i1++
 				i2++
+				matching = true
 			} else if p1innov < p2innov {
 				chosen_gene = p1gene
 				i1++
@@ -1419,6 +2183,11 @@ func (gen *Genome) mateMultipoint(og *Genome, genomeid int, fitness1, fitness2 f
 			}
 		}
 
+		if !matching && disjoint_inheritance == neat.RandomDisjointInheritance {
+			// Inherit disjoint and excess genes from any parent randomly
+			skip = rand.Float64() < 0.5
+		}
+
 		// Uncomment this line to let growth go faster (from both parents excesses)
 		// skip=false
 
@@ -1510,14 +2279,9 @@ func (gen *Genome) mateMultipoint(og *Genome, genomeid int, fitness1, fitness2 f
 // This method mates like multipoint but instead of selecting one or the other when the innovation numbers match,
 // it averages their weights.
 func (gen *Genome) mateMultipointAvg(og *Genome, genomeid int, fitness1, fitness2 float64) (*Genome, error) {
-	// Check if genomes has equal number of traits
-	if len(gen.Traits) != len(og.Traits) {
-		return nil, errors.New(fmt.Sprintf("Genomes has different traits count, %d != %d", len(gen.Traits), len(og.Traits)))
-	}
-
 	// First, average the Traits from the 2 parents to form the baby's Traits. It is assumed that trait vectors are
 	// the same length. In the future, may decide on a different method for trait mating.
-	new_traits, err := gen.mateTraits(og)
+	new_traits, err := gen.mateTraits(og, true)
 	if err != nil {
 		return nil, err
 	}
@@ -1723,14 +2487,9 @@ func (gen *Genome) mateMultipointAvg(og *Genome, genomeid int, fitness1, fitness
 // mating methods. A Gene is chosen in the smaller Genome for splitting. When the Gene is reached, it is averaged with
 // the matching Gene from the larger Genome, if one exists. Then every other Gene is taken from the larger Genome.
 func (gen *Genome) mateSinglepoint(og *Genome, genomeid int) (*Genome, error) {
-	// Check if genomes has equal number of traits
-	if len(gen.Traits) != len(og.Traits) {
-		return nil, errors.New(fmt.Sprintf("Genomes has different traits count, %d != %d", len(gen.Traits), len(og.Traits)))
-	}
-
 	// First, average the Traits from the 2 parents to form the baby's Traits. It is assumed that trait vectors are
 	// the same length. In the future, may decide on a different method for trait mating.
-	new_traits, err := gen.mateTraits(og)
+	new_traits, err := gen.mateTraits(og, true)
 	if err != nil {
 		return nil, err
 	}
@@ -1983,14 +2742,46 @@ func findModulesIntersection(nodes map[int]*network.NNode, genes []*MIMOControlG
 	return modules
 }
 
-// Builds array of traits for child genome during crossover
-func (g *Genome) mateTraits(og *Genome) ([]*neat.Trait, error) {
-	new_traits := make([]*neat.Trait, len(g.Traits))
+// Builds array of traits for child genome during crossover. The traits of parents matched by ID and if avg is true
+// the parameters of matching traits will be averaged, otherwise the trait of random parent will be inherited.
+// The traits present only in one parent are inherited as is.
+func (g *Genome) mateTraits(og *Genome, avg bool) ([]*neat.Trait, error) {
+	new_traits := make([]*neat.Trait, len(g.Traits), len(g.Traits) + len(og.Traits))
 	var err error
 	for i, tr := range g.Traits {
-		new_traits[i], err = neat.NewTraitAvrg(tr, og.Traits[i]) // construct by averaging
-		if err != nil {
-			return nil, err
+		// find matching trait of other parent
+		var o_tr *neat.Trait
+		for _, t := range og.Traits {
+			if t.Id == tr.Id {
+				o_tr = t
+				break
+			}
+		}
+		if o_tr == nil {
+			// no match - inherit as is
+			new_traits[i] = neat.NewTraitCopy(tr)
+		} else if avg {
+			new_traits[i], err = neat.NewTraitAvrg(tr, o_tr) // construct by averaging
+			if err != nil {
+				return nil, err
+			}
+		} else if rand.Float64() < 0.5 {
+			new_traits[i] = neat.NewTraitCopy(tr)
+		} else {
+			new_traits[i] = neat.NewTraitCopy(o_tr)
+		}
+	}
+	// the traits of other parent missing in this one (e.g., added by mutation) are inherited as is
+	for _, o_tr := range og.Traits {
+		found := false
+		for _, tr := range g.Traits {
+			if tr.Id == o_tr.Id {
+				found = true
+				break
+			}
+		}
+		if !found {
+			new_traits = append(new_traits, neat.NewTraitCopy(o_tr))
 		}
 	}
 	return new_traits, nil
@@ -2004,20 +2795,57 @@ func (g *Genome) mateTraits(og *Genome) ([]*neat.Trait, error) {
 // is:  disjoint_coeff * pdg + excess_coeff * peg + mutdiff_coeff * mdmg
 // The three coefficients are global system parameters.
 // The bigger returned value the less compatible the genomes. Fully compatible genomes has 0.0 returned.
+// If context.CompatibilityMetric is set, the distance is computed by the registered metric with that name instead.
 func (g *Genome) compatibility(og *Genome, context *neat.NeatContext) float64 {
+	if len(context.CompatibilityMetric) > 0 {
+		if metric, err := CompatibilityMetricByName(context.CompatibilityMetric); err == nil {
+			return metric.Distance(g, og)
+		} else {
+			neat.WarnLog(fmt.Sprintf("GENOME: %s, the classic compatibility used instead", err))
+		}
+	}
+	var comp float64
 	if context.GenCompatMethod == 0 {
-		return g.compatLinear(og, context)
+		comp = g.compatLinear(og, context)
 	} else {
-		return g.compatFast(og, context)
+		comp = g.compatFast(og, context)
+	}
+	if context.ActivationDiffCoeff > 0 {
+		comp += context.ActivationDiffCoeff * float64(g.activationDiffs(og))
 	}
+	return comp
 }
 
-// The compatibility checking method with linear performance depending on the size of the lognest genome in comparison.
-// When genomes are small this method is compatible in performance with Genome#compatFast method.
-// The compatibility formula remains the same: disjoint_coeff * pdg + excess_coeff * peg + mutdiff_coeff * mdmg
-// where: pdg - PERCENT DISJOINT GENES, peg - PERCENT EXCESS GENES, and mdmg - MUTATIONAL DIFFERENCE WITHIN MATCHING GENES
-func (g *Genome) compatLinear(og *Genome, context *neat.NeatContext) float64 {
-	num_disjoint, num_excess, mut_diff_total, num_matching := 0.0, 0.0, 0.0, 0.0
+// Returns the number of nodes with the same ID in both genomes which has different activation functions
+func (g *Genome) activationDiffs(og *Genome) int {
+	activations := make(map[int]utils.NodeActivationType, len(og.Nodes))
+	for _, n := range og.Nodes {
+		activations[n.Id] = n.ActivationType
+	}
+	diffs := 0
+	for _, n := range g.Nodes {
+		if a, ok := activations[n.Id]; ok && a != n.ActivationType {
+			diffs++
+		}
+	}
+	return diffs
+}
+
+// Calculates compatibility distance between this genome and provided one using given coefficients of excess genes,
+// disjoint genes and average mutational (weight) difference of matching genes. The gene counts are not normalized by
+// genome size and no context settings are applied. The genes are compared by linear walk as in Genome#compatLinear.
+func (g *Genome) Compatibility(og *Genome, excess_coeff, disjoint_coeff, weight_diff_coeff float64) float64 {
+	num_disjoint, num_excess, mut_diff_total, num_matching := g.compatGenesCount(og)
+	comp := disjoint_coeff * num_disjoint + excess_coeff * num_excess
+	if num_matching > 0 {
+		comp += weight_diff_coeff * mut_diff_total / num_matching
+	}
+	return comp
+}
+
+// Returns the number of disjoint, excess and matching genes along with total mutational difference of matching genes
+// found by linear walk through both genomes.
+func (g *Genome) compatGenesCount(og *Genome) (num_disjoint, num_excess, mut_diff_total, num_matching float64) {
 	size1, size2 := len(g.Genes), len(og.Genes)
 	max_genome_size := size2
 	if size1 > size2 {
@@ -2052,14 +2880,24 @@ func (g *Genome) compatLinear(og *Genome, context *neat.NeatContext) float64 {
 			}
 		}
 	}
+	return num_disjoint, num_excess, mut_diff_total, num_matching
+}
+
+// The compatibility checking method with linear performance depending on the size of the lognest genome in comparison.
+// When genomes are small this method is compatible in performance with Genome#compatFast method.
+// The compatibility formula remains the same: disjoint_coeff * pdg + excess_coeff * peg + mutdiff_coeff * mdmg
+// where: pdg - PERCENT DISJOINT GENES, peg - PERCENT EXCESS GENES, and mdmg - MUTATIONAL DIFFERENCE WITHIN MATCHING GENES
+func (g *Genome) compatLinear(og *Genome, context *neat.NeatContext) float64 {
+	num_disjoint, num_excess, mut_diff_total, num_matching := g.compatGenesCount(og)
+	size1, size2 := len(g.Genes), len(og.Genes)
 
 	//fmt.Printf("num_disjoint: %.f num_excess: %.f mut_diff_total: %.f num_matching: %.f\n", num_disjoint, num_excess, mut_diff_total, num_matching)
 
 	// Return the compatibility number using compatibility formula
 	// Note that mut_diff_total/num_matching gives the AVERAGE difference between mutation_nums for any two matching
 	// Genes in the Genome. Look at disjointedness and excess in the absolute (ignoring size)
-	comp := context.DisjointCoeff * num_disjoint + context.ExcessCoeff * num_excess +
-		context.MutdiffCoeff * (mut_diff_total / num_matching)
+	comp := (context.DisjointCoeff * num_disjoint + context.ExcessCoeff * num_excess) /
+		compatNormalizer(size1, size2, context) + context.MutdiffCoeff * (mut_diff_total / num_matching)
 
 	return comp
 }
@@ -2085,12 +2923,12 @@ func (g *Genome) compatFast(og *Genome, context *neat.NeatContext) float64 {
 	}
 	if list1_count == 0 {
 		// All list2 genes are excess.
-		return float64(list2_count) * context.ExcessCoeff
+		return float64(list2_count) * context.ExcessCoeff / compatNormalizer(list1_count, list2_count, context)
 	}
 
 	if list2_count == 0 {
 		// All list1 genes are excess.
-		return float64(list1_count) * context.ExcessCoeff
+		return float64(list1_count) * context.ExcessCoeff / compatNormalizer(list1_count, list2_count, context)
 	}
 
 	excess_genes_switch, num_matching := 0, 0
@@ -2170,9 +3008,30 @@ func (g *Genome) compatFast(og *Genome, context *neat.NeatContext) float64 {
 
 		gene1, gene2 = g.Genes[list1_idx], og.Genes[list2_idx]
 	}
+	compatibility /= compatNormalizer(list1_count, list2_count, context)
 	if num_matching > 0 {
 		compatibility += mut_diff * context.MutdiffCoeff / float64(num_matching)
 	}
 	return compatibility
 }
 
+// The minimal number of genes in the larger genome to apply normalization of compatibility by genome size
+const compatNormalizationMinGenes = 20
+
+// Returns the number to divide contribution of disjoint and excess genes to compatibility of genomes with given sizes.
+// If normalization enabled in context it is the size of larger genome or 1 if both genomes are small (see
+// compatNormalizationMinGenes).
+func compatNormalizer(size1, size2 int, context *neat.NeatContext) float64 {
+	if !context.NormalizeCompatByGenomeSize {
+		return 1.0
+	}
+	n := size1
+	if size2 > n {
+		n = size2
+	}
+	if n < compatNormalizationMinGenes {
+		return 1.0
+	}
+	return float64(n)
+}
+
@@ -4,9 +4,11 @@ import (
 	"io"
 	"fmt"
 	"bufio"
+	"bytes"
 	"errors"
 	"strings"
 	"strconv"
+	"math"
 	"gopkg.in/yaml.v2"
 	"github.com/spf13/cast"
 	"github.com/yaricom/goNEAT/neat"
@@ -28,11 +30,78 @@ func NewGenomeReader(r io.Reader, encoding GenomeEncoding) (GenomeReader, error)
 		return &plainGenomeReader{r: bufio.NewReader(r)}, nil
 	case YAMLGenomeEncoding:
 		return &yamlGenomeReader{r: bufio.NewReader(r)}, nil
+	case LegacyNEATGenomeEncoding:
+		return &legacyGenomeReader{scanner:bufio.NewScanner(r)}, nil
 	default:
 		return nil, ErrUnsupportedGenomeEncoding
 	}
 }
 
+// Reads all genomes from provided reader with specified encoding format. The plain text genomes are delimited by
+// genomestart/genomeend lines (optionally led by genomeversion line) and the YAML genomes are separate documents of the YAML stream (separated by ---).
+// The legacy C++ NEAT genomes are delimited by genomestart/genomeend lines as well.
+func ReadGenomes(r io.Reader, encoding GenomeEncoding) ([]*Genome, error) {
+	genomes := make([]*Genome, 0)
+	switch encoding {
+	case PlainGenomeEncoding:
+		scanner := bufio.NewScanner(r)
+		scanner.Split(bufio.ScanLines)
+		var g_buff *bytes.Buffer
+		version_line := ""
+		for scanner.Scan() {
+			line := scanner.Text()
+			if g_buff == nil && strings.HasPrefix(line, "genomeversion") {
+				// the format version header leading the next genome
+				version_line = line
+				continue
+			}
+			if strings.HasPrefix(line, "genomestart") {
+				g_buff = bytes.NewBufferString("")
+				if len(version_line) > 0 {
+					fmt.Fprintln(g_buff, version_line)
+					version_line = ""
+				}
+			}
+			if g_buff == nil {
+				// skip everything between genomes
+				continue
+			}
+			if len(strings.TrimSpace(line)) > 0 {
+				fmt.Fprintln(g_buff, line)
+			}
+			if strings.HasPrefix(line, "genomeend") {
+				gr := &plainGenomeReader{r: bufio.NewReader(g_buff)}
+				gnome, err := gr.Read()
+				if err != nil {
+					return nil, err
+				}
+				genomes = append(genomes, gnome)
+				g_buff = nil
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		if g_buff != nil {
+			return nil, errors.New("Unexpected end of stream, genomeend not found")
+		}
+	case YAMLGenomeEncoding, LegacyNEATGenomeEncoding:
+		gr, _ := NewGenomeReader(r, encoding)
+		for {
+			gnome, err := gr.Read()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, err
+			}
+			genomes = append(genomes, gnome)
+		}
+	default:
+		return nil, ErrUnsupportedGenomeEncoding
+	}
+	return genomes, nil
+}
+
 // A PlainGenomeReader reads genome data from plain text file.
 type plainGenomeReader struct {
 	r *bufio.Reader
@@ -46,6 +115,7 @@ func (pgr *plainGenomeReader) Read() (*Genome, error) {
 	}
 
 	var g_id int
+	version := GenomeFormatVersion1
 	// Loop until file is finished, parsing each line
 	scanner := bufio.NewScanner(pgr.r)
 	scanner.Split(bufio.ScanLines)
@@ -58,6 +128,15 @@ func (pgr *plainGenomeReader) Read() (*Genome, error) {
 		lr := strings.NewReader(parts[1])
 
 		switch parts[0] {
+		case "genomeversion":
+			// Read format version
+			if _, err := fmt.Fscanf(lr, "%d", &version); err != nil {
+				return nil, err
+			}
+			if version != GenomeFormatVersion1 && version != GenomeFormatVersion2 {
+				return nil, errors.New(fmt.Sprintf("Unsupported genome format version: %d", version))
+			}
+
 		case "trait":
 			// Read a Trait
 			new_trait, err := readPlainTrait(lr)
@@ -72,6 +151,9 @@ func (pgr *plainGenomeReader) Read() (*Genome, error) {
 			gnome.Traits = append(gnome.Traits, new_trait)
 
 		case "node":
+			if n_fields := len(strings.Fields(parts[1])); version == GenomeFormatVersion2 && (n_fields < 5 || n_fields > 7) {
+				return nil, errors.New(fmt.Sprintf("Node line: [%s] has wrong number of fields for version: %d", line, version))
+			}
 			// Read a Network Node
 			new_node, err := readPlainNetworkNode(lr, gnome.Traits)
 			if err != nil {
@@ -85,6 +167,9 @@ func (pgr *plainGenomeReader) Read() (*Genome, error) {
 			gnome.Nodes = append(gnome.Nodes, new_node)
 
 		case "gene":
+			if n_fields := len(strings.Fields(parts[1])); version == GenomeFormatVersion2 && (n_fields < 8 || n_fields > 9) {
+				return nil, errors.New(fmt.Sprintf("Gene line: [%s] has wrong number of fields for version: %d", line, version))
+			}
 			// Read a Gene
 			new_gene, err := readPlainConnectionGene(lr, gnome.Traits, gnome.Nodes)
 			if err != nil {
@@ -154,8 +239,22 @@ func readPlainNetworkNode(r io.Reader, traits []*neat.Trait) (*network.NNode, er
 		n.NeuronType = network.NodeNeuronType(n_NeuronType)
 	}
 
-	if len(parts) == 5 {
-		n.ActivationType, err = utils.NodeActivators.ActivationTypeFromName(parts[4])
+	if len(parts) >= 5 {
+		if n.ActivationType, err = utils.NodeActivators.ActivationTypeFromName(parts[4]); err != nil {
+			return nil, err
+		}
+	}
+	if len(parts) >= 6 {
+		// read optional bias
+		if n.Bias, err = strconv.ParseFloat(parts[5], 64); err != nil {
+			return nil, err
+		}
+	}
+	if len(parts) >= 7 {
+		// read optional time constant
+		if n.TimeConstant, err = strconv.ParseFloat(parts[6], 64); err != nil {
+			return nil, err
+		}
 	}
 
 	return n, err
@@ -173,6 +272,18 @@ func readPlainConnectionGene(r io.Reader, traits []*neat.Trait, nodes []*network
 		return nil, err
 	}
 
+	// read optional frozen flag, the EOF means that it is absent
+	var frozen bool
+	var frozen_str string
+	if _, err := fmt.Fscan(r, &frozen_str); err != nil && err != io.EOF {
+		return nil, err
+	}
+	if len(frozen_str) > 0 {
+		if frozen, err = strconv.ParseBool(frozen_str); err != nil {
+			return nil, err
+		}
+	}
+
 	trait := traitWithId(traitId, traits)
 	var inNode, outNode *network.NNode
 	for _, np := range nodes {
@@ -183,22 +294,215 @@ func readPlainConnectionGene(r io.Reader, traits []*neat.Trait, nodes []*network
 			outNode = np
 		}
 	}
+	var gene *Gene
 	if trait != nil {
-		return newGene(network.NewLinkWithTrait(trait, weight, inNode, outNode, recurrent), inov_num, mut_num, enabled), nil
+		gene = newGene(network.NewLinkWithTrait(trait, weight, inNode, outNode, recurrent), inov_num, mut_num, enabled)
 	} else {
-		return newGene(network.NewLink(weight, inNode, outNode, recurrent), inov_num, mut_num, enabled), nil
+		gene = newGene(network.NewLink(weight, inNode, outNode, recurrent), inov_num, mut_num, enabled)
 	}
+	gene.IsFrozen = frozen
+	return gene, nil
+}
+
+// A legacyGenomeReader reads genome data from plain text file written by the original C++ NEAT implementation. The
+// subsequent reads will return the next genome in the stream or io.EOF if no more genomes found.
+type legacyGenomeReader struct {
+	scanner *bufio.Scanner
+}
+
+func (lgr *legacyGenomeReader) Read() (*Genome, error) {
+	var gnome *Genome
+	for lgr.scanner.Scan() {
+		fields := strings.Fields(lgr.scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "/*") {
+			// skip empty lines and comments
+			continue
+		}
+		if gnome == nil {
+			// skip everything between genomes
+			if fields[0] == "genomestart" {
+				if len(fields) != 2 {
+					return nil, errors.New(fmt.Sprintf("Wrong genomestart line: %s", fields))
+				}
+				g_id, err := strconv.Atoi(fields[1])
+				if err != nil {
+					return nil, err
+				}
+				gnome = &Genome{
+					Id:g_id,
+					Traits:make([]*neat.Trait, 0),
+					Nodes:make([]*network.NNode, 0),
+					Genes:make([]*Gene, 0),
+				}
+			}
+			continue
+		}
+
+		switch fields[0] {
+		case "trait":
+			new_trait, err := readLegacyTrait(fields[1:])
+			if err != nil {
+				return nil, err
+			}
+			if traitWithId(new_trait.Id, gnome.Traits) != nil {
+				return nil, errors.New(fmt.Sprintf("Trait ID: %d is not unique", new_trait.Id))
+			}
+			gnome.Traits = append(gnome.Traits, new_trait)
+
+		case "node":
+			new_node, err := readLegacyNetworkNode(fields[1:], gnome.Traits)
+			if err != nil {
+				return nil, err
+			}
+			if nodeWithId(new_node.Id, gnome.Nodes) != nil {
+				return nil, errors.New(fmt.Sprintf("Node ID: %d is not unique", new_node.Id))
+			}
+			gnome.Nodes = append(gnome.Nodes, new_node)
+
+		case "gene":
+			new_gene, err := readLegacyConnectionGene(fields[1:], gnome.Traits, gnome.Nodes)
+			if err != nil {
+				return nil, err
+			}
+			gnome.Genes = append(gnome.Genes, new_gene)
+
+		case "genomeend":
+			if len(fields) != 2 || fields[1] != strconv.Itoa(gnome.Id) {
+				return nil, errors.New(
+					fmt.Sprintf("Wrong genomeend line: %s, expected genome ID: %d", fields, gnome.Id))
+			}
+			return gnome, nil
+
+		default:
+			return nil, errors.New(fmt.Sprintf("Unsupported line: [%s] when reading legacy Genome",
+				lgr.scanner.Text()))
+		}
+	}
+	if err := lgr.scanner.Err(); err != nil {
+		return nil, err
+	}
+	if gnome != nil {
+		return nil, errors.New("Unexpected end of stream, genomeend not found")
+	}
+	return nil, io.EOF
+}
+
+// Reads Trait from fields of legacy trait line: trait_id param_1 ... param_n
+func readLegacyTrait(fields []string) (*neat.Trait, error) {
+	if len(fields) < 1 || len(fields) > neat.Num_trait_params + 1 {
+		return nil, errors.New(fmt.Sprintf("Wrong number of trait fields: %d", len(fields)))
+	}
+	nt := neat.NewTrait()
+	var err error
+	if nt.Id, err = strconv.Atoi(fields[0]); err != nil {
+		return nil, err
+	}
+	for i, f := range fields[1:] {
+		if nt.Params[i], err = strconv.ParseFloat(f, 64); err != nil {
+			return nil, err
+		}
+	}
+	return nt, nil
+}
+
+// Reads NNode from fields of legacy node line: node_id trait_id type gen_node_label, where type is
+// 0 for NEURON and 1 for SENSOR, and gen_node_label is 0 for HIDDEN, 1 for INPUT, 2 for OUTPUT and 3 for BIAS
+func readLegacyNetworkNode(fields []string, traits []*neat.Trait) (*network.NNode, error) {
+	if len(fields) != 4 {
+		return nil, errors.New(fmt.Sprintf("Wrong number of node fields: %d", len(fields)))
+	}
+	values := make([]int, len(fields))
+	for i, f := range fields {
+		v, err := strconv.Atoi(f)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	n := network.NewNetworkNode()
+	n.Id = values[0]
+	n.Trait = traitWithId(values[1], traits)
+	n.NeuronType = network.NodeNeuronType(values[3])
+	if n.NeuronType > network.BiasNeuron {
+		return nil, errors.New(fmt.Sprintf("Unsupported node label: %d of node: %d", values[3], n.Id))
+	}
+	if is_sensor := values[2] == 1; is_sensor != n.IsSensor() {
+		return nil, errors.New(
+			fmt.Sprintf("Node type: %d doesn't match node label: %d of node: %d", values[2], values[3], n.Id))
+	}
+	return n, nil
+}
+
+// Reads Gene from fields of legacy gene line: trait_id in_node_id out_node_id weight recurrent innovation_num
+// mutation_num enabled, where innovation number may be written as floating point value and flags as 0/1
+func readLegacyConnectionGene(fields []string, traits []*neat.Trait, nodes []*network.NNode) (*Gene, error) {
+	if len(fields) != 8 {
+		return nil, errors.New(fmt.Sprintf("Wrong number of gene fields: %d", len(fields)))
+	}
+	trait_id, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return nil, err
+	}
+	in_node_id, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return nil, err
+	}
+	out_node_id, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return nil, err
+	}
+	weight, err := strconv.ParseFloat(fields[3], 64)
+	if err != nil {
+		return nil, err
+	}
+	recurrent, err := strconv.ParseBool(fields[4])
+	if err != nil {
+		return nil, err
+	}
+	innov_num, err := strconv.ParseFloat(fields[5], 64)
+	if err != nil {
+		return nil, err
+	}
+	if innov_num != math.Trunc(innov_num) {
+		// the truncated value may duplicate innovation number of another gene
+		return nil, errors.New(fmt.Sprintf("Innovation number is not integral: %s", fields[5]))
+	}
+	mut_num, err := strconv.ParseFloat(fields[6], 64)
+	if err != nil {
+		return nil, err
+	}
+	enabled, err := strconv.ParseBool(fields[7])
+	if err != nil {
+		return nil, err
+	}
+
+	in_node, out_node := nodeWithId(in_node_id, nodes), nodeWithId(out_node_id, nodes)
+	if in_node == nil || out_node == nil {
+		return nil, errors.New(
+			fmt.Sprintf("Nodes of gene not found, in: %d, out: %d", in_node_id, out_node_id))
+	}
+	var link *network.Link
+	if trait := traitWithId(trait_id, traits); trait != nil {
+		link = network.NewLinkWithTrait(trait, weight, in_node, out_node, recurrent)
+	} else {
+		link = network.NewLink(weight, in_node, out_node, recurrent)
+	}
+	return newGene(link, int64(innov_num), mut_num, enabled), nil
 }
 
 // A YAMLGenomeReader reads genome data from YAML encoded text file
 type yamlGenomeReader struct {
-	r *bufio.Reader
+	r   *bufio.Reader
+	// The decoder of YAML documents stream, subsequent reads will return the next document in the stream
+	dec *yaml.Decoder
 }
 
 func (ygr *yamlGenomeReader) Read() (*Genome, error) {
 	m := make(map[interface{}]interface{})
-	dec := yaml.NewDecoder(ygr.r)
-	err := dec.Decode(&m)
+	if ygr.dec == nil {
+		ygr.dec = yaml.NewDecoder(ygr.r)
+	}
+	err := ygr.dec.Decode(&m)
 	if err != nil {
 		return nil, err
 	}
@@ -213,6 +517,16 @@ func (ygr *yamlGenomeReader) Read() (*Genome, error) {
 	if err != nil {
 		return nil, err
 	}
+	// read format version if present
+	if v, ok := gm["version"]; ok {
+		version, err := cast.ToIntE(v)
+		if err != nil {
+			return nil, err
+		}
+		if version != GenomeFormatVersion1 && version != GenomeFormatVersion2 {
+			return nil, errors.New(fmt.Sprintf("Unsupported genome format version: %d", version))
+		}
+	}
 	gnome := &Genome{
 		Id:gen_id,
 		Traits:make([]*neat.Trait, 0),
@@ -306,6 +620,13 @@ func readGene(conf map[interface{}]interface{}, traits []*neat.Trait, nodes []*n
 	if err != nil {
 		return nil, err
 	}
+	frozen := false
+	if f, ok := conf["frozen"]; ok {
+		// read optional frozen flag
+		if frozen, err = cast.ToBoolE(f); err != nil {
+			return nil, err
+		}
+	}
 
 	trait := traitWithId(traitId, traits)
 	var inNode, outNode *network.NNode
@@ -317,11 +638,14 @@ func readGene(conf map[interface{}]interface{}, traits []*neat.Trait, nodes []*n
 			outNode = np
 		}
 	}
+	var gene *Gene
 	if trait != nil {
-		return newGene(network.NewLinkWithTrait(trait, weight, inNode, outNode, recurrent), inov_num, mut_num, enabled), nil
+		gene = newGene(network.NewLinkWithTrait(trait, weight, inNode, outNode, recurrent), inov_num, mut_num, enabled)
 	} else {
-		return newGene(network.NewLink(weight, inNode, outNode, recurrent), inov_num, mut_num, enabled), nil
+		gene = newGene(network.NewLink(weight, inNode, outNode, recurrent), inov_num, mut_num, enabled)
 	}
+	gene.IsFrozen = frozen
+	return gene, nil
 }
 
 // Reads MIMOControlGene configuration
@@ -415,6 +739,18 @@ func readNNode(conf map[interface{}]interface{}, traits []*neat.Trait) (*network
 	if err != nil {
 		return nil, err
 	}
+	if bias, ok := conf["bias"]; ok {
+		// read optional bias
+		if nd.Bias, err = cast.ToFloat64E(bias); err != nil {
+			return nil, err
+		}
+	}
+	if time_constant, ok := conf["time_constant"]; ok {
+		// read optional time constant
+		if nd.TimeConstant, err = cast.ToFloat64E(time_constant); err != nil {
+			return nil, err
+		}
+	}
 	activation := conf["activation"].(string)
 	nd.ActivationType, err = utils.NodeActivators.ActivationTypeFromName(activation)
 	return nd, err
@@ -8,6 +8,9 @@ import (
 	"os"
 	"github.com/yaricom/goNEAT/neat"
 	"github.com/yaricom/goNEAT/neat/utils"
+	"bytes"
+	"bufio"
+	"io"
 )
 
 func TestPlainGenomeReader_Read(t *testing.T) {
@@ -150,6 +153,95 @@ func TestReadGene_ReadPlainNNode(t *testing.T) {
 	}
 }
 
+// Tests that NNode with CPPN activation survives write/read round trip
+func TestReadGene_ReadPlainNNode_activation(t *testing.T) {
+	trait := neat.NewTrait()
+	trait.Id = 10
+	traits := []*neat.Trait{trait}
+
+	node := network.NewNNode(1, network.HiddenNeuron)
+	node.Trait = trait
+	node.ActivationType = utils.GaussianActivation
+
+	out_buffer := bytes.NewBufferString("")
+	wr := plainGenomeWriter{w:bufio.NewWriter(out_buffer)}
+	if err := wr.writeNetworkNode(node); err != nil {
+		t.Error(err)
+		return
+	}
+	wr.w.Flush()
+
+	r_node, err := readPlainNetworkNode(strings.NewReader(out_buffer.String()), traits)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if r_node.ActivationType != utils.GaussianActivation {
+		t.Error("r_node.ActivationType != utils.GaussianActivation", r_node.ActivationType)
+	}
+}
+
+// Tests that NNode bias survives write/read round trip
+func TestReadGene_ReadPlainNNode_bias(t *testing.T) {
+	trait := neat.NewTrait()
+	trait.Id = 10
+	traits := []*neat.Trait{trait}
+
+	node := network.NewNNode(1, network.HiddenNeuron)
+	node.Trait = trait
+	node.Bias = -0.75
+
+	out_buffer := bytes.NewBufferString("")
+	wr := plainGenomeWriter{w:bufio.NewWriter(out_buffer)}
+	if err := wr.writeNetworkNode(node); err != nil {
+		t.Error(err)
+		return
+	}
+	wr.w.Flush()
+
+	r_node, err := readPlainNetworkNode(strings.NewReader(out_buffer.String()), traits)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if r_node.Bias != node.Bias {
+		t.Error("r_node.Bias != node.Bias", r_node.Bias)
+	}
+	if r_node.ActivationType != node.ActivationType {
+		t.Error("r_node.ActivationType != node.ActivationType", r_node.ActivationType)
+	}
+}
+
+func TestReadGene_ReadPlainNNode_timeConstant(t *testing.T) {
+	trait := neat.NewTrait()
+	trait.Id = 10
+	traits := []*neat.Trait{trait}
+
+	node := network.NewNNode(1, network.HiddenNeuron)
+	node.Trait = trait
+	node.TimeConstant = 0.25
+
+	out_buffer := bytes.NewBufferString("")
+	wr := plainGenomeWriter{w:bufio.NewWriter(out_buffer)}
+	if err := wr.writeNetworkNode(node); err != nil {
+		t.Error(err)
+		return
+	}
+	wr.w.Flush()
+
+	r_node, err := readPlainNetworkNode(strings.NewReader(out_buffer.String()), traits)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if r_node.TimeConstant != node.TimeConstant {
+		t.Error("r_node.TimeConstant != node.TimeConstant", r_node.TimeConstant)
+	}
+	if r_node.Bias != node.Bias {
+		t.Error("r_node.Bias != node.Bias", r_node.Bias)
+	}
+}
+
 // Tests Gene ReadGene
 func TestReadGene_ReadPlainGene(t *testing.T) {
 	// gene  1 1 4 1.1983046913458986 0 1.0 1.1983046913458986 0
@@ -199,6 +291,54 @@ func TestReadGene_ReadPlainGene(t *testing.T) {
 	}
 }
 
+func TestReadGene_ReadPlainGene_frozen(t *testing.T) {
+	gene_str := "0 1 4 1.1983046913458986 false 1 1.1983046913458986 true true"
+	nodes := []*network.NNode{
+		network.NewNNode(1, network.InputNeuron),
+		network.NewNNode(4, network.HiddenNeuron),
+	}
+
+	gene, err := readPlainConnectionGene(strings.NewReader(gene_str), []*neat.Trait{}, nodes)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !gene.IsFrozen {
+		t.Error("!gene.IsFrozen")
+	}
+	if !gene.IsEnabled {
+		t.Error("!gene.IsEnabled")
+	}
+
+	// write it back and check that frozen flag preserved
+	out_buf := bytes.NewBufferString("")
+	wr := plainGenomeWriter{w:bufio.NewWriter(out_buf)}
+	if err = wr.writeConnectionGene(gene); err != nil {
+		t.Error(err)
+		return
+	}
+	wr.w.Flush()
+	if out_buf.String() != gene_str {
+		t.Errorf("Wrong Gene serialization\n[%s]\n[%s]", gene_str, out_buf.String())
+	}
+
+	// the absent frozen flag
+	gene, err = readPlainConnectionGene(strings.NewReader(strings.TrimSuffix(gene_str, " true")), []*neat.Trait{}, nodes)
+	if err != nil {
+		t.Error(err)
+	} else if gene.IsFrozen {
+		t.Error("gene.IsFrozen")
+	}
+
+	// the malformed frozen flag
+	for _, frozen_str := range []string{"yes", "frozen=yes"} {
+		malformed_str := strings.TrimSuffix(gene_str, "true") + frozen_str
+		if _, err = readPlainConnectionGene(strings.NewReader(malformed_str), []*neat.Trait{}, nodes); err == nil {
+			t.Error("Error expected for malformed frozen flag", frozen_str)
+		}
+	}
+}
+
 func TestPlainGenomeReader_ReadFile(t *testing.T) {
 	genomePath := "../../data/xorstartgenes"
 	genomeFile, err := os.Open(genomePath)
@@ -436,3 +576,193 @@ func TestYAMLGenomeReader_Read(t *testing.T) {
 		id_count++
 	}
 }
+
+func TestReadGenomes(t *testing.T) {
+	for _, encoding := range []GenomeEncoding{PlainGenomeEncoding, YAMLGenomeEncoding} {
+		// write genomes into one buffer
+		out_buf := bytes.NewBufferString("")
+		wr, err := NewGenomeWriter(bufio.NewWriter(out_buf), encoding)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		ids := []int{3, 5, 7}
+		for _, id := range ids {
+			if err = wr.WriteGenome(buildTestGenome(id)); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+
+		// read them back
+		genomes, err := ReadGenomes(bytes.NewReader(out_buf.Bytes()), encoding)
+		if err != nil {
+			t.Error(err, encoding)
+			return
+		}
+		if len(genomes) != len(ids) {
+			t.Error("len(genomes) != len(ids)", len(genomes), encoding)
+			return
+		}
+		for i, gnome := range genomes {
+			if gnome.Id != ids[i] {
+				t.Error("gnome.Id != ids[i]", gnome.Id, ids[i], encoding)
+			}
+			if len(gnome.Genes) != 3 {
+				t.Error("len(gnome.Genes) != 3", len(gnome.Genes), encoding)
+			}
+		}
+	}
+}
+
+func TestPlainGenomeReader_Read_versions(t *testing.T) {
+	v1_str := "genomestart 1\n" +
+		"trait 1 0.1 0 0 0 0 0 0 0\n" +
+		"node 1 0 1 1\n" +
+		"node 2 0 0 2 SigmoidSteepenedActivation\n" +
+		"gene 1 1 2 1.5 false 1 0 true\n" +
+		"genomeend 1\n"
+	v2_str := "genomeversion 2\n" +
+		"genomestart 2\n" +
+		"trait 1 0.1 0 0 0 0 0 0 0\n" +
+		"node 1 0 1 1 NullActivation 0\n" +
+		"node 2 0 0 2 SigmoidSteepenedActivation 0.5 0.25\n" +
+		"gene 1 1 2 1.5 false 1 0 true true\n" +
+		"genomeend 2\n"
+
+	for i, g_str := range []string{v1_str, v2_str} {
+		r, err := NewGenomeReader(strings.NewReader(g_str), PlainGenomeEncoding)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		gnome, err := r.Read()
+		if err != nil {
+			t.Error(err, i)
+			continue
+		}
+		if gnome.Id != i + 1 {
+			t.Error("gnome.Id != i + 1", gnome.Id, i)
+		}
+		if len(gnome.Nodes) != 2 || len(gnome.Genes) != 1 {
+			t.Error("Wrong genome structure", gnome)
+		}
+	}
+
+	// check new fields read
+	r, _ := NewGenomeReader(strings.NewReader(v2_str), PlainGenomeEncoding)
+	gnome, err := r.Read()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if gnome.Nodes[1].Bias != 0.5 {
+		t.Error("gnome.Nodes[1].Bias != 0.5", gnome.Nodes[1].Bias)
+	}
+	if gnome.Nodes[1].TimeConstant != 0.25 {
+		t.Error("gnome.Nodes[1].TimeConstant != 0.25", gnome.Nodes[1].TimeConstant)
+	}
+	if !gnome.Genes[0].IsFrozen {
+		t.Error("!gnome.Genes[0].IsFrozen")
+	}
+
+	// the version 2 accepts omitted optional trailing fields
+	v2_short_str := strings.Replace(v2_str, " true true\n", " true\n", 1)
+	v2_short_str = strings.Replace(v2_short_str, "NullActivation 0\n", "NullActivation\n", 1)
+	r, _ = NewGenomeReader(strings.NewReader(v2_short_str), PlainGenomeEncoding)
+	if gnome, err = r.Read(); err != nil {
+		t.Error(err)
+	} else if gnome.Genes[0].IsFrozen {
+		t.Error("gnome.Genes[0].IsFrozen")
+	}
+
+	// the version 2 requires node activation
+	r, _ = NewGenomeReader(strings.NewReader(strings.Replace(v2_str, " NullActivation 0\n", "\n", 1)), PlainGenomeEncoding)
+	if _, err = r.Read(); err == nil {
+		t.Error("Error expected for missing node fields in version 2")
+	}
+
+	// unsupported version
+	r, _ = NewGenomeReader(strings.NewReader(strings.Replace(v2_str, "genomeversion 2", "genomeversion 3", 1)), PlainGenomeEncoding)
+	if _, err = r.Read(); err == nil {
+		t.Error("Error expected for unsupported version")
+	}
+}
+
+func TestLegacyGenomeReader_ReadFile(t *testing.T) {
+	genomePath := "../../data/legacy_neat_xorwinner"
+	genomeFile, err := os.Open(genomePath)
+	if err != nil {
+		t.Error("Failed to open genome file")
+		return
+	}
+	defer genomeFile.Close()
+	r, err := NewGenomeReader(genomeFile, LegacyNEATGenomeEncoding)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	genome, err := r.Read()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if genome.Id != 371 {
+		t.Error("genome.Id != 371", genome.Id)
+	}
+	if len(genome.Traits) != 3 {
+		t.Error("len(genome.Traits) != 3", len(genome.Traits))
+	}
+	if len(genome.Nodes) != 5 {
+		t.Error("len(genome.Nodes) != 5", len(genome.Nodes))
+	}
+	if len(genome.Genes) != 8 {
+		t.Error("len(genome.Genes) != 8", len(genome.Genes))
+		return
+	}
+	last := genome.Genes[7]
+	if last.InnovationNum != 27 || !last.Link.IsRecurrent || last.IsEnabled {
+		t.Error("Wrong last gene", last)
+	}
+	if _, err = r.Read(); err != io.EOF {
+		t.Error("io.EOF expected after the last genome", err)
+	}
+
+	// check that loaded genome activates
+	netw, err := genome.Genesis(genome.Id)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if err = netw.LoadSensors([]float64{1.0, 0.0, 1.0}); err != nil {
+		t.Error(err)
+		return
+	}
+	depth, err := netw.MaxDepth()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if res, err := netw.ActivateSteps(depth); !res || err != nil {
+		t.Error("Failed to activate network", err)
+	}
+}
+
+func TestReadLegacyConnectionGene_innovationNumber(t *testing.T) {
+	nodes := []*network.NNode{
+		network.NewNNode(1, network.InputNeuron),
+		network.NewNNode(2, network.OutputNeuron),
+	}
+	gene, err := readLegacyConnectionGene(strings.Fields("0 1 2 1.5 0 3.0 0 1"), nil, nodes)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if gene.InnovationNum != 3 {
+		t.Error("gene.InnovationNum != 3", gene.InnovationNum)
+	}
+
+	if _, err = readLegacyConnectionGene(strings.Fields("0 1 2 1.5 0 3.5 0 1"), nil, nodes); err == nil {
+		t.Error("Error expected for not integral innovation number")
+	}
+}
@@ -6,6 +6,9 @@ import (
 	"github.com/yaricom/goNEAT/neat"
 	"math/rand"
 	"github.com/yaricom/goNEAT/neat/utils"
+	"math"
+	"strings"
+	"bytes"
 )
 
 const gnome_str = "genomestart 1\n" +
@@ -128,6 +131,179 @@ func TestGenome_Genesis(t *testing.T) {
 	}
 }
 
+func TestGenome_Genesis_cached(t *testing.T) {
+	gnome := buildTestGenome(1)
+
+	net, err := gnome.Genesis(1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	cached, err := gnome.Genesis(1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if cached != net {
+		t.Error("cached != net")
+	}
+
+	// rebuild after mutation
+	if _, err = gnome.mutateLinkWeights(1.0, 1.0, 0, 1.0, gaussianMutator); err != nil {
+		t.Error(err)
+		return
+	}
+	rebuilt, err := gnome.Genesis(1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if rebuilt == net {
+		t.Error("rebuilt == net")
+	}
+
+	// rebuild after direct modification of genome
+	gnome.Genes[0].Link.Weight = 10.0
+	gnome.Invalidate()
+	modified, err := gnome.Genesis(1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if modified == rebuilt {
+		t.Error("modified == rebuilt")
+	}
+}
+
+func TestGenome_Complexity(t *testing.T) {
+	gnome := buildTestGenome(1)
+
+	c := gnome.Complexity()
+	expected := GenomeComplexity{Nodes:4, EnabledGenes:3}
+	if c != expected {
+		t.Error("c != expected", c, expected)
+	}
+
+	// disable one gene and make another recurrent
+	gnome.Genes[0].IsEnabled = false
+	gnome.Genes[1].Link.IsRecurrent = true
+	c = gnome.Complexity()
+	expected = GenomeComplexity{Nodes:4, EnabledGenes:2, DisabledGenes:1, RecurrentLinks:1}
+	if c != expected {
+		t.Error("c != expected", c, expected)
+	}
+}
+
+// Tests Genome Hash
+func TestGenome_Hash(t *testing.T) {
+	gnome1 := buildTestGenome(1)
+	gnome2, err := gnome1.duplicate(2)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if gnome1.FullHash() != gnome2.FullHash() {
+		t.Error("gnome1.FullHash() != gnome2.FullHash()")
+	}
+	if gnome1.StructuralHash() != gnome2.StructuralHash() {
+		t.Error("gnome1.StructuralHash() != gnome2.StructuralHash()")
+	}
+	if gnome1.StructuralHash() == gnome1.FullHash() {
+		t.Error("gnome1.StructuralHash() == gnome1.FullHash()")
+	}
+
+	// check that order of genes doesn't matter
+	gnome2.Genes[0], gnome2.Genes[2] = gnome2.Genes[2], gnome2.Genes[0]
+	if gnome1.FullHash() != gnome2.FullHash() {
+		t.Error("gnome1.FullHash() != gnome2.FullHash() after genes reordering")
+	}
+
+	// check that weights affects only full hash
+	gnome2.Genes[1].Link.Weight += 0.1
+	if gnome1.FullHash() == gnome2.FullHash() {
+		t.Error("gnome1.FullHash() == gnome2.FullHash() after weight changed")
+	}
+	if gnome1.StructuralHash() != gnome2.StructuralHash() {
+		t.Error("gnome1.StructuralHash() != gnome2.StructuralHash() after weight changed")
+	}
+
+	// check that disabled gene changes both hashes
+	full_hash, struct_hash := gnome1.FullHash(), gnome1.StructuralHash()
+	gnome1.Genes[1].IsEnabled = false
+	if gnome1.FullHash() == full_hash {
+		t.Error("gnome1.FullHash() == full_hash after gene disabled")
+	}
+	if gnome1.StructuralHash() == struct_hash {
+		t.Error("gnome1.StructuralHash() == struct_hash after gene disabled")
+	}
+
+	// check that node trait parameters affects only full hash
+	gnome3 := buildTestGenome(3)
+	gnome3.Nodes[3].Trait = gnome3.Traits[0]
+	full_hash, struct_hash = gnome3.FullHash(), gnome3.StructuralHash()
+	gnome3.Traits[0].Params[1] = 0.5
+	if gnome3.FullHash() == full_hash {
+		t.Error("gnome3.FullHash() == full_hash after node trait changed")
+	}
+	if gnome3.StructuralHash() != struct_hash {
+		t.Error("gnome3.StructuralHash() != struct_hash after node trait changed")
+	}
+
+	// check that activation type of control node changes both hashes
+	m_gnome := buildTestModularGenome(4)
+	full_hash, struct_hash = m_gnome.FullHash(), m_gnome.StructuralHash()
+	m_gnome.ControlGenes[0].ControlNode.ActivationType = utils.MaxModuleActivation
+	if m_gnome.FullHash() == full_hash {
+		t.Error("m_gnome.FullHash() == full_hash after control node activation changed")
+	}
+	if m_gnome.StructuralHash() == struct_hash {
+		t.Error("m_gnome.StructuralHash() == struct_hash after control node activation changed")
+	}
+}
+
+// Tests genome reading, phenotype building and network activation end-to-end
+func TestGenome_Genesis_activate(t *testing.T) {
+	gnome, err := ReadGenome(strings.NewReader(gnome_str), 1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	net, err := gnome.Genesis(gnome.Id)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if gnome.Phenotype != net {
+		t.Error("gnome.Phenotype != net")
+	}
+
+	// load inputs with default bias
+	inputs := []float64{1.0, 0.5}
+	if err = net.LoadSensors(inputs); err != nil {
+		t.Error(err)
+		return
+	}
+	if res, err := net.Activate(); !res || err != nil {
+		t.Error("Failed to activate network", err)
+		return
+	}
+	outputs := net.ReadOutputs()
+	if len(outputs) != 1 {
+		t.Error("len(outputs) != 1", len(outputs))
+		return
+	}
+	expected, err := utils.NodeActivators.ActivateByType(1.5 * 1.0 + 2.5 * 0.5 + 3.5 * 1.0, nil,
+		utils.SigmoidSteepenedActivation)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if outputs[0] != expected {
+		t.Error("outputs[0] != expected", outputs[0], expected)
+	}
+}
+
 func TestGenome_GenesisModular(t *testing.T) {
 	gnome := buildTestModularGenome(1)
 
@@ -326,6 +502,33 @@ func TestGenome_Compatibility_Linear(t *testing.T) {
 	}
 }
 
+func TestGenome_Compatibility(t *testing.T) {
+	gnome1 := buildTestGenome(1)
+	gnome2 := buildTestGenome(2)
+	gnome2.Genes[1].MutationNum = 2.0
+	gnome2.Genes = append(gnome2.Genes, NewGene(1.0, network.NewNNode(1, network.InputNeuron),
+		network.NewNNode(1, network.OutputNeuron), false, 10, 1.0))
+	gnome1.Genes = append(gnome1.Genes, NewGene(2.0, network.NewNNode(1, network.InputNeuron),
+		network.NewNNode(1, network.OutputNeuron), false, 5, 1.0))
+
+	conf := neat.NeatContext{
+		DisjointCoeff:0.7,
+		ExcessCoeff:1.0,
+		MutdiffCoeff:0.4,
+		GenCompatMethod:0,
+	}
+	expected := gnome1.compatibility(gnome2, &conf)
+	comp := gnome1.Compatibility(gnome2, conf.ExcessCoeff, conf.DisjointCoeff, conf.MutdiffCoeff)
+	if comp != expected {
+		t.Error("comp != expected", comp, expected)
+	}
+
+	// no matching genes
+	if comp := gnome1.Compatibility(NewGenome(2, nil, nil, nil), 1.0, 1.0, 1.0); comp != 4.0 {
+		t.Error("comp != 4.0", comp)
+	}
+}
+
 func TestGenome_Compatibility_Fast(t *testing.T) {
 	rand.Seed(42)
 	gnome1 := buildTestGenome(1)
@@ -367,6 +570,48 @@ func TestGenome_Compatibility_Fast(t *testing.T) {
 	}
 }
 
+func TestGenome_Compatibility_Normalized(t *testing.T) {
+	rand.Seed(42)
+	gnome1 := buildTestGenome(1)
+	gnome2 := buildTestGenome(2)
+	// add extra gene to make genomes different
+	gnome2.Genes = append(gnome2.Genes,
+		newGene(network.NewLinkWithTrait(gnome2.Traits[2], 5.5, gnome2.Nodes[2], gnome2.Nodes[3], false), 4, 0, true))
+
+	conf := neat.NeatContext{
+		DisjointCoeff:0.5,
+		ExcessCoeff:0.5,
+		MutdiffCoeff:0.5,
+	}
+	for _, method := range []int{0, 1} {
+		conf.GenCompatMethod = method
+
+		// small genomes are not normalized
+		conf.NormalizeCompatByGenomeSize = false
+		comp := gnome1.compatibility(gnome2, &conf)
+		conf.NormalizeCompatByGenomeSize = true
+		norm_comp := gnome1.compatibility(gnome2, &conf)
+		if comp != 0.5 || norm_comp != comp {
+			t.Error("Small genomes compatibility should not be normalized", comp, norm_comp)
+		}
+
+		// large genomes normalized by the size of the larger one
+		for len(gnome2.Genes) < 25 {
+			innov := int64(len(gnome2.Genes) + 1)
+			gnome2.Genes = append(gnome2.Genes,
+				newGene(network.NewLinkWithTrait(gnome2.Traits[2], 5.5, gnome2.Nodes[1], gnome2.Nodes[3], false), innov, 0, true))
+		}
+		conf.NormalizeCompatByGenomeSize = false
+		comp = gnome1.compatibility(gnome2, &conf)
+		conf.NormalizeCompatByGenomeSize = true
+		norm_comp = gnome1.compatibility(gnome2, &conf)
+		if comp != 11.0 || norm_comp != comp / 25.0 {
+			t.Error("Large genomes compatibility should be normalized", comp, norm_comp)
+		}
+		gnome2.Genes = gnome2.Genes[:4]
+	}
+}
+
 func TestGenome_Compatibility_Duplicate(t *testing.T) {
 	rand.Seed(42)
 	gnome1 := buildTestGenome(1)
@@ -390,6 +635,37 @@ func TestGenome_Compatibility_Duplicate(t *testing.T) {
 	}
 }
 
+func TestGenome_Compatibility_Activation(t *testing.T) {
+	rand.Seed(42)
+	gnome1 := buildTestGenome(1)
+	gnome2, err := gnome1.duplicate(2)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	gnome2.Nodes[3].ActivationType = utils.GaussianActivation
+
+	// Configuration
+	conf := neat.NeatContext{
+		DisjointCoeff:0.5,
+		ExcessCoeff:0.5,
+		MutdiffCoeff:0.5,
+	}
+
+	// Test activation difference ignored by default
+	for _, method := range []int{0, 1} {
+		conf.GenCompatMethod = method
+		conf.ActivationDiffCoeff = 0
+		if comp := gnome1.compatibility(gnome2, &conf); comp != 0 {
+			t.Error("comp != 0 ", comp)
+		}
+		conf.ActivationDiffCoeff = 0.4
+		if comp := gnome1.compatibility(gnome2, &conf); comp != 0.4 {
+			t.Error("comp != 0.4 ", comp)
+		}
+	}
+}
+
 func TestGenome_mutateAddLink(t *testing.T) {
 	rand.Seed(42)
 	gnome1 := buildTestGenome(1)
@@ -460,6 +736,60 @@ func TestGenome_mutateAddLink(t *testing.T) {
 	//t.Log(gnome1.Genes[4])
 }
 
+func TestGenome_mutateAddLink_noCandidates(t *testing.T) {
+	rand.Seed(42)
+	// the test genome is fully connected
+	gnome1 := buildTestGenome(1)
+	conf := neat.NeatContext{
+		NewLinkTries:20,
+	}
+	gnome1.Genesis(1)
+	pop := newPopulation()
+	genes_count := len(gnome1.Genes)
+
+	res, err := gnome1.mutateAddLink(pop, &conf)
+	if err != nil {
+		t.Error(err)
+	}
+	if res {
+		t.Error("New link added to fully connected genome")
+	}
+	if len(gnome1.Genes) != genes_count {
+		t.Error("len(gnome1.Genes) != genes_count", len(gnome1.Genes))
+	}
+
+	// genome without nodes to receive links
+	gnome1.Nodes = gnome1.Nodes[:3]
+	gnome1.Genesis(1)
+	if res, err = gnome1.mutateAddLink(pop, &conf); res || err != nil {
+		t.Error("No link should be added without error to the genome of sensors", res, err)
+	}
+}
+
+// Tests that built-in add link mutation operator returns error of phenotype building
+func TestGenome_mutateAddLink_operatorGenesisError(t *testing.T) {
+	gnome1 := buildTestGenome(1)
+	// the genome without outputs can not build phenotype
+	for _, n := range gnome1.Nodes {
+		if n.NeuronType == network.OutputNeuron {
+			n.NeuronType = network.HiddenNeuron
+		}
+	}
+	conf := neat.NeatContext{
+		NewLinkTries:20,
+	}
+	op, err := MutationOperatorByName(AddLinkMutation)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err = op.Mutate(gnome1, newPopulation(), &conf); err == nil {
+		t.Error("Error expected for genome without outputs")
+	} else if !strings.Contains(err.Error(), "OUTPUTS") {
+		t.Error("The phenotype building error is not returned", err)
+	}
+}
+
 func TestGenome_mutateConnectSensors(t *testing.T) {
 	rand.Seed(42)
 	gnome1 := buildTestGenome(1)
@@ -506,6 +836,49 @@ func TestGenome_mutateConnectSensors(t *testing.T) {
 	}
 }
 
+func TestGenome_mutateAddLink_feedForwardOnly(t *testing.T) {
+	// Configuration
+	conf := neat.NewNeatContext()
+	conf.RecurOnlyProb = 0.5
+	conf.NewLinkTries = 10
+	conf.FeedForwardOnly = true
+
+	for _, seed := range []int64{1, 7, 42} {
+		rand.Seed(seed)
+		gnome1 := buildTestGenome(1)
+
+		// The population (DUMMY)
+		pop := newPopulation()
+		pop.nextInnovNum = int64(4)
+		pop.nextNodeId = int32(5)
+
+		for i := 0; i < 100; i++ {
+			gnome1.Genesis(1)
+			if i % 10 == 0 {
+				// grow the number of hidden neurons
+				if _, err := gnome1.mutateAddNode(pop, conf); err != nil {
+					t.Error(err)
+					return
+				}
+			} else if _, err := gnome1.mutateAddLink(pop, conf); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+		if len(gnome1.Genes) <= 3 + 2 * 10 {
+			t.Error("No links was added", len(gnome1.Genes), seed)
+		}
+		for _, gene := range gnome1.Genes {
+			if gene.Link.IsRecurrent {
+				t.Error("Recurrent gene found", gene, seed)
+			}
+		}
+		if gnome1.HasCycle() {
+			t.Error("Cycle found in feed forward only genome", gnome1.Cycles(), seed)
+		}
+	}
+}
+
 func TestGenome_mutateAddNode(t *testing.T) {
 	rand.Seed(42)
 	gnome1 := buildTestGenome(1)
@@ -539,74 +912,676 @@ func TestGenome_mutateAddNode(t *testing.T) {
 	}
 }
 
-func TestGenome_mutateLinkWeights(t *testing.T) {
+func TestGenome_mutateAddNode_defaultHiddenActivation(t *testing.T) {
 	rand.Seed(42)
 	gnome1 := buildTestGenome(1)
-	// Configuration
-	conf := neat.NeatContext{
-		WeightMutPower:0.5,
-	}
+	pop := newPopulation()
+	pop.nextInnovNum = int64(4)
+	pop.nextNodeId = int32(5)
 
-	res, err := gnome1.mutateLinkWeights(conf.WeightMutPower, 1.0, gaussianMutator)
-	if !res || err != nil {
-		t.Error("Failed to mutate link weights")
+	context := neat.NewNeatContext()
+	context.DefaultHiddenActivation = utils.ReLUActivation
+
+	if res, err := gnome1.mutateAddNode(pop, context); !res || err != nil {
+		t.Error("Failed to add new node:", err)
+		return
 	}
-	for i, gn := range gnome1.Genes {
-		if gn.Link.Weight == float64(i) + 1.5 {
-			t.Error("Found not mutrated gene:", gn)
+	hidden := 0
+	for _, n := range gnome1.Nodes {
+		if n.NeuronType == network.HiddenNeuron {
+			hidden++
+			if n.ActivationType != utils.ReLUActivation {
+				t.Error("n.ActivationType != utils.ReLUActivation", n.ActivationType)
+			}
 		}
 	}
+	if hidden != 1 {
+		t.Error("hidden != 1", hidden)
+	}
 }
 
-func TestGenome_mutateRandomTrait(t *testing.T) {
-	rand.Seed(42)
+func TestGenome_mutateAddNode_innovationReuse(t *testing.T) {
 	gnome1 := buildTestGenome(1)
-	// Configuration
-	conf := neat.NeatContext{
-		TraitMutationPower:0.3,
-		TraitParamMutProb:0.5,
-	}
-	res, err := gnome1.mutateRandomTrait(&conf)
-	if !res || err != nil {
-		t.Error("Failed to mutate random trait")
+	gnome2, err := gnome1.duplicate(2)
+	if err != nil {
+		t.Error(err)
+		return
 	}
-	mutation_found := false
-	for _, tr := range gnome1.Traits {
-		for pi, p := range tr.Params {
-			if pi == 0 && p != float64(tr.Id) / 10.0 {
-				mutation_found = true
-				break
-			} else if pi > 0 && p != 0 {
-				mutation_found = true
-				break
-			}
-		}
-		if mutation_found {
-			break
+
+	// The population (DUMMY)
+	pop := newPopulation()
+	pop.nextNodeId = 4
+	pop.nextInnovNum = 3
+	context := neat.NewNeatContext()
+
+	// apply the same mutation to identical genomes
+	for _, gnome := range []*Genome{gnome1, gnome2} {
+		rand.Seed(42)
+		gnome.Genesis(gnome.Id)
+		if res, err := gnome.mutateAddNode(pop, context); !res || err != nil {
+			t.Error("Failed to add new node:", err)
+			return
 		}
 	}
-	if !mutation_found {
-		t.Error("No mutation found in genome traits")
+	if pop.InnovationsCreated != 1 {
+		t.Error("pop.InnovationsCreated != 1", pop.InnovationsCreated)
+	}
+	if pop.InnovationsReused != 1 {
+		t.Error("pop.InnovationsReused != 1", pop.InnovationsReused)
+	}
+	if len(pop.Innovations) != 1 {
+		t.Error("len(pop.Innovations) != 1", len(pop.Innovations))
 	}
 }
 
-func TestGenome_mutateLinkTrait(t *testing.T) {
+func TestGenome_mutateAddNode_preserveWeights(t *testing.T) {
 	rand.Seed(42)
-	gnome1 := buildTestGenome(1)
+	context := neat.NewNeatContext()
+	context.NodeActivators = []utils.NodeActivationType{utils.LinearActivation}
+	context.NodeActivatorsProb = []float64{1.0}
+	context.AddNodeSplitWeights = neat.PreserveSplitWeights
 
-	res, err := gnome1.mutateLinkTrait(10)
+	gnome := NewMinimalGenome(1, 2, 1, context)
+	gnome.Genes[0].Link.Weight = -2.5
+	gnome.Genes[1].Link.Weight = 1.5
+	pop := newPopulation()
+	pop.nextNodeId = 4
+	pop.nextInnovNum = 3
+
+	activate := func() float64 {
+		netw, err := gnome.Genesis(1)
+		if err != nil {
+			t.Error(err)
+			return 0
+		}
+		netw.LoadSensors([]float64{0.5, 0.8})
+		for i := 0; i < 3; i++ {
+			if _, err = netw.Activate(); err != nil {
+				t.Error(err)
+			}
+		}
+		return netw.ReadOutputs()[0]
+	}
+	expected := activate()
+
+	res, err := gnome.mutateAddNode(pop, context)
 	if !res || err != nil {
-		t.Error("Failed to mutate link trait")
+		t.Error("Failed to add new node:", err)
+		return
 	}
-	mutation_found := false
-	for i, gn := range gnome1.Genes {
-		if gn.Link.Trait.Id != i + 1 {
-			mutation_found = true
-			break
+	if len(gnome.Nodes) != 4 {
+		t.Error("len(gnome.Nodes) != 4", len(gnome.Nodes))
+	}
+	// both new links have the same magnitude of weight
+	var split_weights []float64
+	for _, gn := range gnome.Genes {
+		if gn.Link.InNode.NeuronType == network.HiddenNeuron || gn.Link.OutNode.NeuronType == network.HiddenNeuron {
+			split_weights = append(split_weights, gn.Link.Weight)
 		}
 	}
-	if !mutation_found {
-		t.Error("No mutation found in gene links traits")
+	if len(split_weights) != 2 || math.Abs(split_weights[0]) != math.Abs(split_weights[1]) {
+		t.Error("Wrong split weights", split_weights)
+	}
+	if output := activate(); math.Abs(output - expected) > 1e-9 {
+		t.Error("output != expected", output, expected)
+	}
+}
+
+func TestGenome_mutateNeutral(t *testing.T) {
+	rand.Seed(42)
+	context := neat.NewNeatContext()
+	context.NewLinkTries = 20
+	gnome := NewMinimalGenome(1, 2, 2, context)
+	pop := newPopulation()
+	pop.nextNodeId = 5
+	pop.nextInnovNum = 5
+
+	activate := func() []float64 {
+		netw, err := gnome.Genesis(1)
+		if err != nil {
+			t.Error(err)
+			return nil
+		}
+		netw.LoadSensors([]float64{0.5, 0.8})
+		if _, err = netw.Activate(); err != nil {
+			t.Error(err)
+		}
+		return netw.ReadOutputs()
+	}
+	outputs := activate()
+	hash := gnome.StructuralHash()
+	genes_count := len(gnome.Genes)
+
+	res, err := gnome.mutateNeutral(pop, context)
+	if !res || err != nil {
+		t.Error("Failed to apply neutral mutation", err)
+		return
+	}
+	if len(gnome.Genes) != genes_count + 1 {
+		t.Error("len(gnome.Genes) != genes_count + 1", len(gnome.Genes))
+	}
+	if len(pop.Innovations) != 1 {
+		t.Error("len(pop.Innovations) != 1", len(pop.Innovations))
+	}
+	if gnome.StructuralHash() == hash {
+		t.Error("StructuralHash was not changed")
+	}
+	neutral_outputs := activate()
+	for i, o := range outputs {
+		if neutral_outputs[i] != o {
+			t.Error("neutral_outputs[i] != o", neutral_outputs[i], o)
+		}
+	}
+}
+
+func TestGenome_mutateNeutral_feedForwardOnly(t *testing.T) {
+	for _, seed := range []int64{1, 7, 42} {
+		rand.Seed(seed)
+		context := neat.NewNeatContext()
+		context.NewLinkTries = 20
+		context.FeedForwardOnly = true
+		gnome := NewMinimalGenome(1, 2, 3, context)
+		pop := newPopulation()
+		pop.nextNodeId = 6
+		pop.nextInnovNum = 7
+
+		for i := 0; i < 10; i++ {
+			if _, err := gnome.mutateNeutral(pop, context); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+		// the neutral genes may be re-enabled later
+		for _, gn := range gnome.Genes {
+			gn.IsEnabled = true
+			if gn.Link.IsRecurrent {
+				t.Error("Recurrent gene added to feed-forward only genome", gn)
+			}
+		}
+		if gnome.HasCycle() {
+			t.Error("Neutral mutation created cycle", seed, gnome.Cycles())
+		}
+	}
+}
+
+func TestGenome_mutateAddNode_nodesLimit(t *testing.T) {
+	rand.Seed(42)
+	gnome1 := buildTestGenome(1)
+
+	// The population (DUMMY)
+	pop := newPopulation()
+	// Create gnome phenotype
+	gnome1.Genesis(1)
+
+	context := neat.NewNeatContext()
+	context.MaxNodes = len(gnome1.Nodes)
+
+	res, err := gnome1.mutateAddNode(pop, context)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if res {
+		t.Error("Structural mutation reported for genome at nodes limit")
+	}
+	if len(gnome1.Nodes) != context.MaxNodes {
+		t.Error("len(gnome1.Nodes) != context.MaxNodes", len(gnome1.Nodes))
+	}
+	if len(gnome1.Genes) != 3 {
+		t.Error("len(gnome1.Genes) != 3", len(gnome1.Genes))
+	}
+	if len(pop.Innovations) != 0 {
+		t.Error("len(pop.Innovations) != 0", len(pop.Innovations))
+	}
+}
+
+func TestGenome_PruneUnreachableNodes(t *testing.T) {
+	gnome := buildTestGenome(1)
+	// add dangling hidden nodes: 5 is fed by input and 6 is fed by 5, none connected to output
+	node_5 := network.NewNNode(5, network.HiddenNeuron)
+	node_6 := network.NewNNode(6, network.HiddenNeuron)
+	gnome.Nodes = append(gnome.Nodes, node_5, node_6)
+	gnome.Genes = append(gnome.Genes,
+		NewGene(1.0, gnome.Nodes[0], node_5, false, 4, 0),
+		NewGene(1.0, node_5, node_6, false, 5, 0))
+
+	activate := func() []float64 {
+		netw, err := gnome.Genesis(1)
+		if err != nil {
+			t.Error(err)
+			return nil
+		}
+		netw.LoadSensors([]float64{0.5, 1.5, 1.0})
+		if _, err = netw.Activate(); err != nil {
+			t.Error(err)
+		}
+		return netw.ReadOutputs()
+	}
+	outputs := activate()
+
+	if removed := gnome.PruneUnreachableNodes(); removed != 2 {
+		t.Error("removed != 2", removed)
+	}
+	if len(gnome.Nodes) != 4 {
+		t.Error("len(gnome.Nodes) != 4", len(gnome.Nodes))
+	}
+	if len(gnome.Genes) != 3 {
+		t.Error("len(gnome.Genes) != 3", len(gnome.Genes))
+	}
+	pruned_outputs := activate()
+	for i, o := range outputs {
+		if pruned_outputs[i] != o {
+			t.Error("pruned_outputs[i] != o", pruned_outputs[i], o)
+		}
+	}
+
+	// nothing to prune
+	if removed := gnome.PruneUnreachableNodes(); removed != 0 {
+		t.Error("removed != 0", removed)
+	}
+	if gnome.dirty {
+		t.Error("Genome is invalidated without pruning")
+	}
+}
+
+func TestGenome_PruneUnreachableNodes_ioInvariant(t *testing.T) {
+	rand.Seed(42)
+	countIO := func(g *Genome) (int, int) {
+		inputs, outputs := 0, 0
+		for _, n := range g.Nodes {
+			if n.IsSensor() {
+				inputs++
+			} else if n.NeuronType == network.OutputNeuron {
+				outputs++
+			}
+		}
+		return inputs, outputs
+	}
+	for i := 0; i < 10; i++ {
+		gnome := newGenomeRand(i, 3, 2, 10, 10, false, 0.9)
+		if err := gnome.verifyIO(); err != nil {
+			t.Error(err)
+			return
+		}
+		inputs, outputs := countIO(gnome)
+
+		// disable all genes and prune, only input and output nodes must stay
+		for _, gn := range gnome.Genes {
+			gn.IsEnabled = false
+		}
+		gnome.PruneUnreachableNodes()
+		if r_inputs, r_outputs := countIO(gnome); r_inputs != inputs || r_outputs != outputs {
+			t.Error("Input/output nodes changed by pruning", inputs, r_inputs, outputs, r_outputs)
+		}
+		if len(gnome.Nodes) != inputs + outputs {
+			t.Error("len(gnome.Nodes) != inputs + outputs", len(gnome.Nodes), inputs + outputs)
+		}
+		if err := gnome.verifyIO(); err != nil {
+			t.Error(err)
+		}
+	}
+
+	// gene leading into input node
+	gnome := buildTestGenome(1)
+	gnome.Genes = append(gnome.Genes, NewGene(1.0, gnome.Nodes[3], gnome.Nodes[0], true, 4, 0))
+	if err := gnome.verifyIO(); err == nil {
+		t.Error("The gene leading into input node expected to be detected")
+	}
+}
+
+func TestGenome_Compact(t *testing.T) {
+	gnome := buildTestGenome(1)
+	// split the first gene by hidden node
+	node_5 := network.NewNNode(5, network.HiddenNeuron)
+	gnome.Nodes = append(gnome.Nodes, node_5)
+	gnome.Genes[0].IsEnabled = false
+	gnome.Genes = append(gnome.Genes,
+		NewGene(1.0, gnome.Nodes[0], node_5, false, 4, 0),
+		NewGene(1.5, node_5, gnome.Nodes[3], false, 5, 0))
+	// disabled gene without bypass must be kept
+	gnome.Genes[1].IsEnabled = false
+
+	activate := func() []float64 {
+		netw, err := gnome.Genesis(1)
+		if err != nil {
+			t.Error(err)
+			return nil
+		}
+		netw.LoadSensors([]float64{0.5, 1.5, 1.0})
+		for i := 0; i < 3; i++ {
+			if _, err = netw.Activate(); err != nil {
+				t.Error(err)
+			}
+		}
+		return netw.ReadOutputs()
+	}
+	outputs := activate()
+
+	if removed := gnome.Compact(); removed != 1 {
+		t.Error("removed != 1", removed)
+	}
+	if len(gnome.Genes) != 4 {
+		t.Error("len(gnome.Genes) != 4", len(gnome.Genes))
+	}
+	for _, gn := range gnome.Genes {
+		if gn.InnovationNum == 1 {
+			t.Error("Superseded gene was not removed", gn)
+		}
+	}
+	compact_outputs := activate()
+	for i, o := range outputs {
+		if compact_outputs[i] != o {
+			t.Error("compact_outputs[i] != o", compact_outputs[i], o)
+		}
+	}
+
+	// nothing to compact
+	if removed := gnome.Compact(); removed != 0 {
+		t.Error("removed != 0", removed)
+	}
+	if gnome.dirty {
+		t.Error("Genome is invalidated without compaction")
+	}
+}
+
+func TestGenome_Cycles(t *testing.T) {
+	gnome := buildTestGenome(1)
+	if gnome.HasCycle() {
+		t.Error("Cycle found in feed forward genome", gnome.Cycles())
+	}
+
+	// add hidden node 5 with recurrent loop 4 -> 5 -> 4 and self-loop at 5, the recurrent flags left unset on purpose
+	node_5 := network.NewNNode(5, network.HiddenNeuron)
+	gnome.Nodes = append(gnome.Nodes, node_5)
+	gnome.Genes = append(gnome.Genes,
+		NewGene(1.0, gnome.Nodes[3], node_5, false, 4, 0),
+		NewGene(1.0, node_5, gnome.Nodes[3], false, 5, 0),
+		NewGene(1.0, node_5, node_5, false, 6, 0))
+	if !gnome.HasCycle() {
+		t.Error("Cycle not found in recurrent genome")
+	}
+	cycles := gnome.Cycles()
+	if len(cycles) != 2 {
+		t.Error("len(cycles) != 2", cycles)
+		return
+	}
+	if len(cycles[0]) != 2 || cycles[0][0] != 4 || cycles[0][1] != 5 {
+		t.Error("Wrong cycle found", cycles[0])
+	}
+	if len(cycles[1]) != 1 || cycles[1][0] != 5 {
+		t.Error("Wrong self-loop found", cycles[1])
+	}
+
+	// the cycles through disabled genes are ignored
+	gnome.Genes[4].IsEnabled = false
+	gnome.Genes[5].IsEnabled = false
+	if gnome.HasCycle() {
+		t.Error("Cycle found through disabled genes", gnome.Cycles())
+	}
+}
+
+func TestNewMinimalGenome_connectivity(t *testing.T) {
+	rand.Seed(42)
+	in, out := 10, 3
+	conf := neat.NeatContext{WithBiasNode:true}
+
+	conf.InitialConnectivity = neat.FullyConnected
+	gnome := NewMinimalGenome(1, in, out, &conf)
+	if len(gnome.Genes) != (in + 1) * out {
+		t.Error("len(gnome.Genes) != (in + 1) * out", len(gnome.Genes))
+	}
+
+	conf.InitialConnectivity = neat.SparseConnected
+	conf.InitialConnectionFraction = 0.3
+	gnome = NewMinimalGenome(1, in, out, &conf)
+	if len(gnome.Genes) < out || len(gnome.Genes) >= (in + 1) * out {
+		t.Error("Unexpected number of genes with sparse connectivity", len(gnome.Genes))
+	}
+	if _, err := gnome.Genesis(1); err != nil {
+		t.Error(err)
+	}
+
+	// the innovation number is determined by connected nodes
+	for _, gn := range gnome.Genes {
+		expected := int64((gn.Link.OutNode.Id - in - 2) * (in + 1) + gn.Link.InNode.Id)
+		if gn.InnovationNum != expected {
+			t.Error("gn.InnovationNum != expected", gn.InnovationNum, expected)
+		}
+	}
+
+	// no genes at all
+	conf.InitialConnectivity = neat.Unconnected
+	gnome = NewMinimalGenome(1, in, out, &conf)
+	if len(gnome.Genes) != 0 {
+		t.Error("len(gnome.Genes) != 0", len(gnome.Genes))
+	}
+	if len(gnome.Nodes) != in + 1 + out {
+		t.Error("len(gnome.Nodes) != in + 1 + out", len(gnome.Nodes))
+	}
+	if res, err := gnome.verify(); !res || err != nil {
+		t.Error("Genome verification failed", err)
+	}
+	if _, err := gnome.Genesis(1); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNewMinimalGenome(t *testing.T) {
+	rand.Seed(42)
+	in, out := 3, 2
+	conf := neat.NeatContext{}
+	gnome := NewMinimalGenome(1, in, out, &conf)
+	if len(gnome.Nodes) != in + out {
+		t.Error("len(gnome.Nodes) != in + out", len(gnome.Nodes))
+	}
+	if len(gnome.Genes) != in * out {
+		t.Error("len(gnome.Genes) != in * out", len(gnome.Genes))
+	}
+
+	// with bias node
+	conf.WithBiasNode = true
+	gnome = NewMinimalGenome(1, in, out, &conf)
+	if res, err := gnome.verify(); !res || err != nil {
+		t.Error("Genome verification failed", err)
+		return
+	}
+	var bias *network.NNode
+	for _, n := range gnome.Nodes {
+		if n.NeuronType == network.BiasNeuron {
+			bias = n
+		}
+	}
+	if bias == nil {
+		t.Error("bias node not found")
+		return
+	}
+	outputs := 0
+	for _, n := range gnome.Nodes {
+		if n.NeuronType != network.OutputNeuron {
+			continue
+		}
+		outputs++
+		connected := false
+		for _, gn := range gnome.Genes {
+			if gn.Link.InNode == bias && gn.Link.OutNode == n {
+				connected = true
+			}
+		}
+		if !connected {
+			t.Error("bias node is not connected to the output", n)
+		}
+	}
+	if outputs != out {
+		t.Error("outputs != out", outputs)
+	}
+}
+
+func TestGenome_mutateLinkWeights(t *testing.T) {
+	rand.Seed(42)
+	gnome1 := buildTestGenome(1)
+	// Configuration
+	conf := neat.NeatContext{
+		WeightMutPower:0.5,
+	}
+
+	res, err := gnome1.mutateLinkWeights(conf.WeightMutPower, 1.0, 0, 1.0, gaussianMutator)
+	if !res || err != nil {
+		t.Error("Failed to mutate link weights")
+	}
+	for i, gn := range gnome1.Genes {
+		if gn.Link.Weight == float64(i) + 1.5 {
+			t.Error("Found not mutrated gene:", gn)
+		}
+	}
+}
+
+func TestGenome_mutateLinkWeights_capped(t *testing.T) {
+	rand.Seed(42)
+	gnome1 := buildTestGenome(1)
+	for _, gn := range gnome1.Genes {
+		gn.Link.Weight = 0
+	}
+	weight_cap := 2.0
+
+	for i := 0; i < 100; i++ {
+		res, err := gnome1.mutateLinkWeights(10.0, 1.0, weight_cap, 1.0, gaussianMutator)
+		if !res || err != nil {
+			t.Error("Failed to mutate link weights")
+			return
+		}
+		for _, gn := range gnome1.Genes {
+			if math.Abs(gn.Link.Weight) > weight_cap {
+				t.Error("math.Abs(gn.Link.Weight) > weight_cap", gn.Link.Weight)
+				return
+			}
+		}
+	}
+}
+
+func TestGenome_mutateLinkWeights_outputBias(t *testing.T) {
+	rand.Seed(42)
+	nodes := []*network.NNode{
+		network.NewNNode(1, network.InputNeuron),
+		network.NewNNode(2, network.HiddenNeuron),
+		network.NewNNode(3, network.HiddenNeuron),
+		network.NewNNode(4, network.OutputNeuron),
+	}
+	genes := []*Gene{
+		NewGene(0, nodes[0], nodes[1], false, 1, 0),
+		NewGene(0, nodes[1], nodes[2], false, 2, 0),
+		NewGene(0, nodes[2], nodes[3], false, 3, 0),
+	}
+	gnome := NewGenome(1, []*neat.Trait{}, nodes, genes)
+
+	hidden_change, output_change := 0.0, 0.0
+	for i := 0; i < 500; i++ {
+		for _, gn := range gnome.Genes {
+			gn.Link.Weight = 0
+		}
+		if res, err := gnome.mutateLinkWeights(1.0, 1.0, 0, 5.0, gaussianMutator); !res || err != nil {
+			t.Error("Failed to mutate link weights", err)
+			return
+		}
+		hidden_change += math.Abs(gnome.Genes[1].Link.Weight)
+		output_change += math.Abs(gnome.Genes[2].Link.Weight)
+	}
+	if output_change <= hidden_change {
+		t.Error("output_change <= hidden_change", output_change, hidden_change)
+	}
+}
+
+func TestGenome_mutateLinkWeights_frozen(t *testing.T) {
+	rand.Seed(42)
+	gnome1 := buildTestGenome(1)
+	frozen := gnome1.Genes[0]
+	frozen.IsFrozen = true
+	frozen_weight := frozen.Link.Weight
+
+	for i := 0; i < 100; i++ {
+		res, err := gnome1.mutateLinkWeights(0.5, 1.0, 0, 1.0, gaussianMutator)
+		if !res || err != nil {
+			t.Error("Failed to mutate link weights")
+			return
+		}
+	}
+	if frozen.Link.Weight != frozen_weight {
+		t.Error("frozen.Link.Weight != frozen_weight", frozen.Link.Weight, frozen_weight)
+	}
+	for i, gn := range gnome1.Genes[1:] {
+		if gn.Link.Weight == float64(i + 1) + 1.5 {
+			t.Error("Found not mutated gene:", gn)
+		}
+	}
+}
+
+func TestGenome_mutateRandomTrait(t *testing.T) {
+	rand.Seed(42)
+	gnome1 := buildTestGenome(1)
+	// Configuration
+	conf := neat.NeatContext{
+		TraitMutationPower:0.3,
+		TraitParamMutProb:0.5,
+	}
+	res, err := gnome1.mutateRandomTrait(&conf)
+	if !res || err != nil {
+		t.Error("Failed to mutate random trait")
+	}
+	mutation_found := false
+	for _, tr := range gnome1.Traits {
+		for pi, p := range tr.Params {
+			if pi == 0 && p != float64(tr.Id) / 10.0 {
+				mutation_found = true
+				break
+			} else if pi > 0 && p != 0 {
+				mutation_found = true
+				break
+			}
+		}
+		if mutation_found {
+			break
+		}
+	}
+	if !mutation_found {
+		t.Error("No mutation found in genome traits")
+	}
+}
+
+func TestGenome_mutateLinkTrait(t *testing.T) {
+	rand.Seed(42)
+	gnome1 := buildTestGenome(1)
+
+	res, err := gnome1.mutateLinkTrait(10)
+	if !res || err != nil {
+		t.Error("Failed to mutate link trait")
+	}
+	mutation_found := false
+	for i, gn := range gnome1.Genes {
+		if gn.Link.Trait.Id != i + 1 {
+			mutation_found = true
+			break
+		}
+	}
+	if !mutation_found {
+		t.Error("No mutation found in gene links traits")
+	}
+}
+
+func TestGenome_mutateNodeBias(t *testing.T) {
+	rand.Seed(42)
+	gnome1 := buildTestGenome(1)
+
+	res, err := gnome1.mutateNodeBias(0.5)
+	if !res || err != nil {
+		t.Error("Failed to mutate node bias", err)
+	}
+	for _, nd := range gnome1.Nodes {
+		if nd.IsNeuron() {
+			if nd.Bias == 0 || math.Abs(nd.Bias) > 0.5 {
+				t.Error("Wrong neuron bias mutation", nd.Bias)
+			}
+		} else if nd.Bias != 0 {
+			t.Error("Sensor bias mutated", nd.Id, nd.Bias)
+		}
 	}
 }
 
@@ -638,6 +1613,130 @@ func TestGenome_mutateNodeTrait(t *testing.T) {
 	}
 }
 
+func TestGenome_mutateAddTrait(t *testing.T) {
+	rand.Seed(42)
+	gnome1 := buildTestGenome(1)
+	traits_count := len(gnome1.Traits)
+
+	res, err := gnome1.mutateAddTrait(&neat.NeatContext{})
+	if !res || err != nil {
+		t.Error("Failed to add trait", err)
+		return
+	}
+	if len(gnome1.Traits) != traits_count + 1 {
+		t.Error("len(gnome1.Traits) != traits_count + 1", len(gnome1.Traits))
+		return
+	}
+	new_trait := gnome1.Traits[traits_count]
+	if new_trait.Id != traits_count + 1 {
+		t.Error("new_trait.Id != traits_count + 1", new_trait.Id)
+	}
+	referenced := false
+	for _, nd := range gnome1.Nodes {
+		if nd.Trait == new_trait {
+			referenced = true
+		}
+	}
+	if !referenced {
+		t.Error("New trait is not referenced by any node")
+	}
+
+	// check that genome with more traits can mate and be written
+	child, err := gnome1.mateMultipoint(buildTestGenome(2), 3, 2.0, 1.0, neat.FitterDisjointInheritance)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(child.Traits) != traits_count + 1 {
+		t.Error("len(child.Traits) != traits_count + 1", len(child.Traits))
+	}
+	out_buf := bytes.NewBufferString("")
+	if err = child.Write(out_buf); err != nil {
+		t.Error(err)
+		return
+	}
+	r_gnome, err := ReadGenome(out_buf, 3)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(r_gnome.Traits) != traits_count + 1 {
+		t.Error("len(r_gnome.Traits) != traits_count + 1", len(r_gnome.Traits))
+	}
+
+	// merge traits
+	for _, nd := range gnome1.Nodes {
+		nd.Trait = new_trait
+	}
+	res, err = gnome1.mutateMergeTraits()
+	if !res || err != nil {
+		t.Error("Failed to merge traits", err)
+		return
+	}
+	if len(gnome1.Traits) != traits_count {
+		t.Error("len(gnome1.Traits) != traits_count", len(gnome1.Traits))
+	}
+	ids := make(map[int]bool)
+	for _, tr := range gnome1.Traits {
+		ids[tr.Id] = true
+	}
+	for id := 1; id <= traits_count; id++ {
+		if !ids[id] {
+			t.Error("Traits are not numbered contiguously, missing ID", id)
+		}
+	}
+	for _, nd := range gnome1.Nodes {
+		found := false
+		for _, tr := range gnome1.Traits {
+			if nd.Trait == tr {
+				found = true
+			}
+		}
+		if !found {
+			t.Error("Node references removed trait", nd)
+		}
+	}
+}
+
+func TestGenome_mutateMergeTraits_innovationReuse(t *testing.T) {
+	rand.Seed(42)
+	gnome1 := buildTestGenome(1)
+	if res, err := gnome1.mutateMergeTraits(); !res || err != nil {
+		t.Error("Failed to merge traits", err)
+		return
+	}
+	// add unconnected hidden node to have open links
+	node_5 := network.NewNNode(5, network.HiddenNeuron)
+	gnome1.Nodes = append(gnome1.Nodes, node_5)
+	gnome1.Genesis(1)
+
+	conf := neat.NewNeatContext()
+	conf.NewLinkTries = 100
+	conf.FeedForwardOnly = true
+
+	// the innovations which occurred in genome with more traits than merged one
+	pop := newPopulation()
+	pop.nextInnovNum = int64(10)
+	pop.nextNodeId = int32(6)
+	for i, pair := range [][2]int{{1, 5}, {2, 5}, {3, 5}, {4, 5}, {5, 4}} {
+		pop.Innovations = append(pop.Innovations, NewInnovationForLink(pair[0], pair[1], int64(4 + i), 1.0, 2))
+	}
+
+	res, err := gnome1.mutateAddLink(pop, conf)
+	if !res || err != nil {
+		t.Error("Failed to add link", err)
+		return
+	}
+	if pop.InnovationsReused != 1 {
+		t.Error("pop.InnovationsReused != 1", pop.InnovationsReused)
+	}
+	for _, gene := range gnome1.Genes {
+		if gene.Link.Trait != gnome1.Traits[0] && gene.Link.Trait != gnome1.Traits[1] {
+			t.Error("Gene references trait not in genome", gene)
+		}
+	}
+}
+
 func TestGenome_mutateToggleEnable(t *testing.T) {
 	rand.Seed(41)
 	gnome1 := buildTestGenome(1)
@@ -678,6 +1777,51 @@ func TestGenome_mutateGeneReenable(t *testing.T) {
 	}
 }
 
+func TestGenome_mutateRemoveLink(t *testing.T) {
+	rand.Seed(42)
+	gnome1 := buildTestGenome(1)
+	conf := neat.NeatContext{
+		MutateRemoveLinkProb:1.0,
+	}
+
+	res, err := gnome1.mutateAllNonstructural(1, &conf)
+	if !res || err != nil {
+		t.Error("Failed to mutate remove link", err)
+		return
+	}
+	if gnome1.Extrons() != len(gnome1.Genes) - 1 {
+		t.Error("gnome1.Extrons() != len(gnome1.Genes) - 1", gnome1.Extrons())
+	}
+
+	// check that network still activates
+	netw, err := gnome1.Genesis(1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if err = netw.LoadSensors([]float64{1.0, 1.0, 1.0}); err != nil {
+		t.Error(err)
+		return
+	}
+	if res, err = netw.Activate(); !res || err != nil {
+		t.Error("Failed to activate network", err)
+	}
+
+	// the last link connecting output with inputs should never be removed
+	for i := 0; i < 10; i++ {
+		if _, err = gnome1.mutateRemoveLink(); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+	if gnome1.Extrons() != 1 {
+		t.Error("gnome1.Extrons() != 1", gnome1.Extrons())
+	}
+	if connected := gnome1.connectedOutputs(); !connected[4] {
+		t.Error("The output disconnected")
+	}
+}
+
 func TestGenome_mateMultipoint(t *testing.T) {
 	rand.Seed(42)
 	gnome1 := buildTestGenome(1)
@@ -686,7 +1830,7 @@ func TestGenome_mateMultipoint(t *testing.T) {
 	genomeid := 3
 	fitness1, fitness2 := 1.0, 2.3
 
-	gnome_child, err := gnome1.mateMultipoint(gnome2, genomeid, fitness1, fitness2)
+	gnome_child, err := gnome1.mateMultipoint(gnome2, genomeid, fitness1, fitness2, neat.FitterDisjointInheritance)
 	if err != nil {
 		t.Error(err)
 	}
@@ -708,7 +1852,7 @@ func TestGenome_mateMultipoint(t *testing.T) {
 	gene := newGene(network.NewLinkWithTrait(gnome1.Traits[2], 5.5, gnome1.Nodes[2], gnome1.Nodes[3], false), 4, 0, true)
 	gnome1.Genes = append(gnome1.Genes, gene)
 	fitness1, fitness2 = 15.0, 2.3
-	gnome_child, err = gnome1.mateMultipoint(gnome2, genomeid, fitness1, fitness2)
+	gnome_child, err = gnome1.mateMultipoint(gnome2, genomeid, fitness1, fitness2, neat.FitterDisjointInheritance)
 	if err != nil {
 		t.Error(err)
 	}
@@ -726,6 +1870,38 @@ func TestGenome_mateMultipoint(t *testing.T) {
 	}
 }
 
+func TestGenome_SyncMutationNumbers(t *testing.T) {
+	rand.Seed(42)
+	gnome1 := buildTestGenome(1)
+	gnome2 := buildTestGenome(2)
+	for i, gene := range gnome2.Genes {
+		gene.Link.Weight += float64(i + 1)
+		gene.MutationNum = gene.Link.Weight
+	}
+
+	gnome_child, err := gnome1.mateMultipointAvg(gnome2, 3, 1.0, 2.3)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	diverged := false
+	for _, gene := range gnome_child.Genes {
+		if gene.MutationNum != gene.Link.Weight {
+			diverged = true
+		}
+	}
+	if !diverged {
+		t.Error("The mutation numbers expected to diverge from weights after mating")
+	}
+
+	gnome_child.SyncMutationNumbers()
+	for _, gene := range gnome_child.Genes {
+		if gene.MutationNum != gene.Link.Weight {
+			t.Error("gene.MutationNum != gene.Link.Weight", gene.MutationNum, gene.Link.Weight)
+		}
+	}
+}
+
 func TestGenome_mateMultipointModular(t *testing.T) {
 	rand.Seed(42)
 	gnome1 := buildTestGenome(1)
@@ -734,7 +1910,7 @@ func TestGenome_mateMultipointModular(t *testing.T) {
 	genomeid := 3
 	fitness1, fitness2 := 1.0, 2.3
 
-	gnome_child, err := gnome1.mateMultipoint(gnome2, genomeid, fitness1, fitness2)
+	gnome_child, err := gnome1.mateMultipoint(gnome2, genomeid, fitness1, fitness2, neat.FitterDisjointInheritance)
 	if err != nil {
 		t.Error(err)
 	}
@@ -805,6 +1981,83 @@ func TestGenome_mateMultipointAvg(t *testing.T) {
 	}
 }
 
+func TestGenome_mateMultipoint_disjointInheritance(t *testing.T) {
+	rand.Seed(42)
+	// the fitter parent
+	gnome1 := buildTestGenome(1)
+	// the less fit but more complex parent
+	gnome2 := buildTestGenome(2)
+	gene := newGene(network.NewLinkWithTrait(gnome2.Traits[2], 5.5, gnome2.Nodes[3], gnome2.Nodes[3], true), 4, 0, true)
+	gnome2.Genes = append(gnome2.Genes, gene)
+	fitness1, fitness2 := 2.0, 1.0
+
+	child, err := gnome1.mateMultipoint(gnome2, 3, fitness1, fitness2, neat.FitterDisjointInheritance)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(child.Genes) != len(gnome1.Genes) {
+		t.Error("The excess gene of less fit parent should not be inherited", len(child.Genes))
+	}
+
+	child, err = gnome1.mateMultipoint(gnome2, 3, fitness1, fitness2, neat.MoreComplexDisjointInheritance)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(child.Genes) != len(gnome2.Genes) {
+		t.Error("The excess gene of more complex parent expected", len(child.Genes))
+		return
+	}
+	if child.Genes[3].InnovationNum != gene.InnovationNum {
+		t.Error("child.Genes[3].InnovationNum != gene.InnovationNum", child.Genes[3].InnovationNum)
+	}
+}
+
+func TestGenome_mateTraits(t *testing.T) {
+	rand.Seed(42)
+	gnome1 := buildTestGenome(1)
+	gnome2 := buildTestGenome(2)
+	for i, tr := range gnome2.Traits {
+		tr.Params[0] = gnome1.Traits[i].Params[0] + 1.0
+	}
+
+	// check averaging
+	child, err := gnome1.mateMultipointAvg(gnome2, 3, 1.0, 2.3)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for i, tr := range child.Traits {
+		expected := (gnome1.Traits[i].Params[0] + gnome2.Traits[i].Params[0]) / 2.0
+		if tr.Params[0] != expected {
+			t.Error("tr.Params[0] != expected", tr.Params[0], expected)
+		}
+	}
+
+	// check random inheritance
+	from_first, from_second := 0, 0
+	for i := 0; i < 10; i++ {
+		child, err = gnome1.mateMultipoint(gnome2, 3, 1.0, 2.3, neat.FitterDisjointInheritance)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		for j, tr := range child.Traits {
+			if tr.Params[0] == gnome1.Traits[j].Params[0] {
+				from_first++
+			} else if tr.Params[0] == gnome2.Traits[j].Params[0] {
+				from_second++
+			} else {
+				t.Error("Trait params not inherited from parent", tr)
+			}
+		}
+	}
+	if from_first == 0 || from_second == 0 {
+		t.Error("Traits should be inherited from both parents", from_first, from_second)
+	}
+}
+
 func TestGenome_mateMultipointAvgModular(t *testing.T) {
 	rand.Seed(42)
 	gnome1 := buildTestGenome(1)
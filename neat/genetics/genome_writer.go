@@ -29,6 +29,22 @@ func NewGenomeWriter(w io.Writer, encoding GenomeEncoding) (GenomeWriter, error)
 	}
 }
 
+// Returns the format version of layout to write given genome with: GenomeFormatVersion2 if any of the extended layout
+// fields is set, otherwise GenomeFormatVersion1.
+func genomeFormatVersion(g *Genome) int {
+	for _, n := range g.Nodes {
+		if n.Bias != 0 || n.TimeConstant != 0 {
+			return GenomeFormatVersion2
+		}
+	}
+	for _, gn := range g.Genes {
+		if gn.IsFrozen {
+			return GenomeFormatVersion2
+		}
+	}
+	return GenomeFormatVersion1
+}
+
 // The plain text encoded genome writer
 type plainGenomeWriter struct {
 	w *bufio.Writer
@@ -36,7 +52,11 @@ type plainGenomeWriter struct {
 
 // Writes genome in Plain Text format
 func (wr *plainGenomeWriter) WriteGenome(g *Genome) error {
-	_, err := fmt.Fprintf(wr.w, "genomestart %d\n", g.Id)
+	_, err := fmt.Fprintf(wr.w, "genomeversion %d\n", genomeFormatVersion(g))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(wr.w, "genomestart %d\n", g.Id)
 	if err != nil {
 		return err
 	}
@@ -68,6 +88,9 @@ func (wr *plainGenomeWriter) WriteGenome(g *Genome) error {
 		fmt.Fprintln(wr.w, "")
 	}
 	_, err = fmt.Fprintf(wr.w, "genomeend %d\n", g.Id)
+	if err != nil {
+		return err
+	}
 
 	// flush buffer
 	err = wr.w.Flush()
@@ -104,6 +127,14 @@ func (wr *plainGenomeWriter) writeNetworkNode(n *network.NNode) error {
 		_, err = fmt.Fprintf(wr.w, "%d %d %d %d %s", n.Id, trait_id, n.NodeType(),
 			n.NeuronType, act_str)
 	}
+	if err == nil && (n.Bias != 0 || n.TimeConstant != 0) {
+		// the bias is optional and written only if set or followed by time constant
+		_, err = fmt.Fprintf(wr.w, " %g", n.Bias)
+	}
+	if err == nil && n.TimeConstant != 0 {
+		// the time constant is optional and written only if set
+		_, err = fmt.Fprintf(wr.w, " %g", n.TimeConstant)
+	}
 	return err
 }
 // Dump connection gene in plain text format
@@ -123,17 +154,24 @@ func (wr *plainGenomeWriter) writeConnectionGene(g *Gene) error {
 
 	_, err := fmt.Fprintf(wr.w, "%d %d %d %g %t %d %g %t",
 		traitId, inNodeId, outNodeId, weight, recurrent, innov_num, mut_num, enabled)
+	if err == nil && g.IsFrozen {
+		// the frozen flag is optional and written only if set
+		_, err = fmt.Fprintf(wr.w, " %t", g.IsFrozen)
+	}
 	return err
 }
 
 // The YAML encoded genome writer
 type yamlGenomeWriter struct {
-	w *bufio.Writer
+	w       *bufio.Writer
+	// The number of genomes written, subsequent genomes are written as separate documents of the YAML stream
+	written int
 }
 
 func (wr *yamlGenomeWriter) WriteGenome(g *Genome) (err error) {
 	g_map := make(map[string]interface{})
 	g_map["id"] = g.Id
+	g_map["version"] = genomeFormatVersion(g)
 
 	// encode traits
 	traits := make([]map[string]interface{}, len(g.Traits))
@@ -176,6 +214,13 @@ func (wr *yamlGenomeWriter) WriteGenome(g *Genome) (err error) {
 	r_map := make(map[string]interface{})
 	r_map["genome"] = g_map
 
+	// start new document if not first genome
+	if wr.written > 0 {
+		if _, err = fmt.Fprintln(wr.w, "---"); err != nil {
+			return err
+		}
+	}
+
 	// encode everything as YAML
 	enc := yaml.NewEncoder(wr.w)
 	err = enc.Encode(r_map)
@@ -183,6 +228,9 @@ func (wr *yamlGenomeWriter) WriteGenome(g *Genome) (err error) {
 		// flush stream
 		err = wr.w.Flush()
 	}
+	if err == nil {
+		wr.written++
+	}
 
 	return err
 }
@@ -240,6 +288,7 @@ func (wr *yamlGenomeWriter) encodeConnectionGene(gene *Gene) map[string]interfac
 	g_map["mut_num"] = gene.MutationNum
 	g_map["recurrent"] = cast.ToString(gene.Link.IsRecurrent)
 	g_map["enabled"] = cast.ToString(gene.IsEnabled)
+	g_map["frozen"] = cast.ToString(gene.IsFrozen)
 	return g_map
 }
 
@@ -252,6 +301,10 @@ func (wr *yamlGenomeWriter) encodeNetworkNode(node *network.NNode) (n_map map[st
 		n_map["trait_id"] = 0
 	}
 	n_map["type"] = network.NeuronTypeName(node.NeuronType)
+	n_map["bias"] = node.Bias
+	if node.TimeConstant != 0 {
+		n_map["time_constant"] = node.TimeConstant
+	}
 	n_map["activation"], err = utils.NodeActivators.ActivationNameFromType(node.ActivationType)
 	return n_map, err
 }
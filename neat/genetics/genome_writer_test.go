@@ -8,6 +8,7 @@ import (
 	"strings"
 	"bufio"
 	"github.com/yaricom/goNEAT/neat/network"
+	"github.com/yaricom/goNEAT/neat/utils"
 	"reflect"
 )
 
@@ -106,7 +107,7 @@ func TestPlainGenomeWriter_WriteGenome(t *testing.T) {
 		return
 	}
 
-	g_scanner := bufio.NewScanner(strings.NewReader(gnome_str))
+	g_scanner := bufio.NewScanner(strings.NewReader("genomeversion 1\n" + gnome_str))
 	g_scanner.Split(bufio.ScanLines)
 
 	o_scanner := bufio.NewScanner(out_buf)
@@ -124,8 +125,102 @@ func TestPlainGenomeWriter_WriteGenome(t *testing.T) {
 	}
 }
 
+// Tests that genome with extended layout fields is written as version 2 and read back
+func TestPlainGenomeWriter_WriteGenome_version2(t *testing.T) {
+	gnome := buildTestGenome(1)
+	gnome.Nodes[3].Bias = 0.5
+	gnome.Nodes[2].TimeConstant = 0.25
+	gnome.Genes[0].IsFrozen = true
+
+	out_buf := bytes.NewBufferString("")
+	wr, err := NewGenomeWriter(bufio.NewWriter(out_buf), PlainGenomeEncoding)
+	if err == nil {
+		err = wr.WriteGenome(gnome)
+	}
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !strings.HasPrefix(out_buf.String(), "genomeversion 2\n") {
+		t.Error("Genome format version 2 is not written")
+	}
+
+	r, err := NewGenomeReader(bytes.NewBuffer(out_buf.Bytes()), PlainGenomeEncoding)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	gnome_enc, err := r.Read()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(gnome.Nodes) != len(gnome_enc.Nodes) || len(gnome.Genes) != len(gnome_enc.Genes) {
+		t.Error("Wrong genome structure", len(gnome_enc.Nodes), len(gnome_enc.Genes))
+		return
+	}
+	for i, n := range gnome.Nodes {
+		if n.Bias != gnome_enc.Nodes[i].Bias {
+			t.Error("n.Bias != gnome_enc.Nodes[i].Bias", n.Bias, gnome_enc.Nodes[i].Bias, i)
+		}
+		if n.TimeConstant != gnome_enc.Nodes[i].TimeConstant {
+			t.Error("n.TimeConstant != gnome_enc.Nodes[i].TimeConstant", n.TimeConstant, i)
+		}
+	}
+	for i, gn := range gnome.Genes {
+		if gn.IsFrozen != gnome_enc.Genes[i].IsFrozen {
+			t.Error("gn.IsFrozen != gnome_enc.Genes[i].IsFrozen", gn.IsFrozen, i)
+		}
+	}
+}
+
+// Tests that nodes with CPPN activation functions survive genome write/read round trip in all encodings
+func TestGenomeWriter_WriteGenome_activations(t *testing.T) {
+	activations := []utils.NodeActivationType{utils.GaussianActivation, utils.SineActivation,
+		utils.LinearAbsActivation, utils.LinearActivation}
+	gnome := buildTestGenome(1)
+	for i, n := range gnome.Nodes {
+		n.ActivationType = activations[i % len(activations)]
+	}
+
+	for _, encoding := range []GenomeEncoding{PlainGenomeEncoding, YAMLGenomeEncoding} {
+		out_buf := bytes.NewBufferString("")
+		wr, err := NewGenomeWriter(bufio.NewWriter(out_buf), encoding)
+		if err == nil {
+			err = wr.WriteGenome(gnome)
+		}
+		if err != nil {
+			t.Error(err, encoding)
+			continue
+		}
+
+		r, err := NewGenomeReader(bytes.NewBuffer(out_buf.Bytes()), encoding)
+		if err != nil {
+			t.Error(err, encoding)
+			continue
+		}
+		gnome_enc, err := r.Read()
+		if err != nil {
+			t.Error(err, encoding)
+			continue
+		}
+		if len(gnome.Nodes) != len(gnome_enc.Nodes) {
+			t.Error("len(gnome.Nodes) != len(gnome_enc.Nodes)", len(gnome_enc.Nodes), encoding)
+			continue
+		}
+		for i, n := range gnome.Nodes {
+			if n.ActivationType != gnome_enc.Nodes[i].ActivationType {
+				t.Error("n.ActivationType != gnome_enc.Nodes[i].ActivationType", n.ActivationType,
+					gnome_enc.Nodes[i].ActivationType, encoding)
+			}
+		}
+	}
+}
+
 func TestYamlGenomeWriter_WriteGenome(t *testing.T) {
 	gnome := buildTestModularGenome(1)
+	gnome.Nodes[3].Bias = 0.5
+	gnome.Nodes[4].TimeConstant = 0.25
 
 	// encode genome
 	out_buf := bytes.NewBufferString("")
@@ -138,6 +233,9 @@ func TestYamlGenomeWriter_WriteGenome(t *testing.T) {
 		return
 	}
 	//t.Log(out_buf.String())
+	if !strings.Contains(out_buf.String(), "version: 2") {
+		t.Error("Genome format version is not written")
+	}
 
 	// decode genome and compare
 	enc := yamlGenomeReader{r:bufio.NewReader(bytes.NewBuffer(out_buf.Bytes()))}
@@ -183,6 +281,12 @@ func TestYamlGenomeWriter_WriteGenome(t *testing.T) {
 		if n.NeuronType != nd.NeuronType {
 			t.Error("n.NeuronType != nd.NeuronType at:", i)
 		}
+		if n.Bias != nd.Bias {
+			t.Error("n.Bias != nd.Bias at:", i)
+		}
+		if n.TimeConstant != nd.TimeConstant {
+			t.Error("n.TimeConstant != nd.TimeConstant at:", i)
+		}
 	}
 
 	if len(gnome.Traits) != len(gnome_enc.Traits) {
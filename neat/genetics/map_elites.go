@@ -0,0 +1,104 @@
+package genetics
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// The MAP-Elites archive which discretizes behavior space into the grid of cells and keeps the most fit organism found
+// so far per each cell. The cell of organism is determined by its BehaviorVector.
+type MapElitesArchive struct {
+	// The number of bins per each dimension of behavior descriptor
+	Bins     []int
+	// The lower bounds of behavior descriptor values per each dimension
+	MinBound []float64
+	// The upper bounds of behavior descriptor values per each dimension
+	MaxBound []float64
+
+	// The elite organisms by index of cell
+	cells    map[int]*Organism
+}
+
+// Creates new MAP-Elites archive with given number of bins and behavior bounds per each dimension of behavior descriptor
+func NewMapElitesArchive(bins []int, min_bound, max_bound []float64) (*MapElitesArchive, error) {
+	if len(bins) == 0 {
+		return nil, errors.New("MAP-Elites archive must have at least one dimension")
+	}
+	if len(bins) != len(min_bound) || len(bins) != len(max_bound) {
+		return nil, errors.New(fmt.Sprintf("The number of bins: %d does not match number of bounds: %d/%d",
+			len(bins), len(min_bound), len(max_bound)))
+	}
+	for i, b := range bins {
+		if b <= 0 {
+			return nil, errors.New(fmt.Sprintf("Wrong number of bins: %d at dimension: %d", b, i))
+		}
+		if max_bound[i] <= min_bound[i] {
+			return nil, errors.New(fmt.Sprintf("Wrong bounds [%f, %f] at dimension: %d", min_bound[i], max_bound[i], i))
+		}
+	}
+	archive := MapElitesArchive{
+		Bins:bins,
+		MinBound:min_bound,
+		MaxBound:max_bound,
+		cells:make(map[int]*Organism),
+	}
+	return &archive, nil
+}
+
+// Adds organism to the cell of its behavior descriptor if the cell is empty or the organism is more fit than current
+// occupant. Returns true if organism was inserted. The organism without behavior descriptor of archive dimensions
+// is never inserted.
+func (a *MapElitesArchive) Add(org *Organism) bool {
+	cell, ok := a.cellIndex(org.BehaviorVector)
+	if !ok {
+		return false
+	}
+	if occupant, found := a.cells[cell]; found && occupant.Fitness >= org.Fitness {
+		return false
+	}
+	a.cells[cell] = org
+	return true
+}
+
+// Returns the elite organism at the cell of provided behavior descriptor or nil if the cell is empty
+func (a *MapElitesArchive) Elite(behavior []float64) *Organism {
+	if cell, ok := a.cellIndex(behavior); ok {
+		return a.cells[cell]
+	}
+	return nil
+}
+
+// Returns all elite organisms stored in this archive
+func (a *MapElitesArchive) Elites() []*Organism {
+	elites := make([]*Organism, 0, len(a.cells))
+	for _, org := range a.cells {
+		elites = append(elites, org)
+	}
+	return elites
+}
+
+// Returns the number of occupied cells
+func (a *MapElitesArchive) Size() int {
+	return len(a.cells)
+}
+
+// Returns the flat index of cell for provided behavior descriptor. The values outside of bounds are clamped
+// to the edge cells. Returns false if the number of descriptor dimensions is wrong.
+func (a *MapElitesArchive) cellIndex(behavior []float64) (int, bool) {
+	if len(behavior) != len(a.Bins) {
+		return 0, false
+	}
+	index, stride := 0, 1
+	for i, v := range behavior {
+		bin := int(math.Floor((v - a.MinBound[i]) / (a.MaxBound[i] - a.MinBound[i]) * float64(a.Bins[i])))
+		if bin < 0 {
+			bin = 0
+		} else if bin >= a.Bins[i] {
+			bin = a.Bins[i] - 1
+		}
+		index += bin * stride
+		stride *= a.Bins[i]
+	}
+	return index, true
+}
@@ -0,0 +1,47 @@
+package genetics
+
+import (
+	"testing"
+)
+
+func TestMapElitesArchive_Add(t *testing.T) {
+	archive, err := NewMapElitesArchive([]int{4, 4}, []float64{0.0, 0.0}, []float64{1.0, 1.0})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	weak, err := NewOrganism(1.0, buildTestGenome(1), 1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	weak.BehaviorVector = []float64{0.1, 0.6}
+	fit, err := NewOrganism(2.0, buildTestGenome(2), 1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	fit.BehaviorVector = []float64{0.2, 0.7}
+
+	if !archive.Add(weak) {
+		t.Error("The organism expected to be inserted into empty cell")
+	}
+	if !archive.Add(fit) {
+		t.Error("The fitter organism expected to replace occupant")
+	}
+	if archive.Add(weak) {
+		t.Error("The weaker organism should not replace occupant")
+	}
+	if archive.Size() != 1 {
+		t.Error("archive.Size() != 1", archive.Size())
+	}
+	if archive.Elite(weak.BehaviorVector) != fit {
+		t.Error("Only the fitter organism expected to be retained")
+	}
+
+	// wrong dimensions
+	fit.BehaviorVector = []float64{0.5}
+	if archive.Add(fit) {
+		t.Error("The organism with wrong behavior dimensions should not be inserted")
+	}
+}
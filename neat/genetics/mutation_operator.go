@@ -0,0 +1,83 @@
+package genetics
+
+import (
+	"errors"
+	"fmt"
+	"github.com/yaricom/goNEAT/neat"
+	"sync"
+)
+
+// The mutation operator applied to the offspring genome during reproduction
+type MutationOperator interface {
+	// Mutates given genome and returns true if its structure was changed
+	Mutate(g *Genome, pop *Population, context *neat.NeatContext) (structural bool, err error)
+}
+
+// The adapter to use ordinary function as mutation operator
+type MutationOperatorFunc func(g *Genome, pop *Population, context *neat.NeatContext) (bool, error)
+
+// Mutate calls f(g, pop, context)
+func (f MutationOperatorFunc) Mutate(g *Genome, pop *Population, context *neat.NeatContext) (bool, error) {
+	return f(g, pop, context)
+}
+
+// The names of built-in mutation operators
+const (
+	// Adds new node by splitting existing link
+	AddNodeMutation = "AddNodeMutation"
+	// Adds new link between existing nodes
+	AddLinkMutation = "AddLinkMutation"
+	// Connects disconnected sensors to the outputs
+	ConnectSensorsMutation = "ConnectSensorsMutation"
+	// Applies all non structural mutations, i.e., link weights, traits, etc.
+	LinkWeightsMutation = "LinkWeightsMutation"
+)
+
+var (
+	// The registered mutation operators by name
+	mutationOperators = map[string]MutationOperator{
+		AddNodeMutation:MutationOperatorFunc(func(g *Genome, pop *Population, context *neat.NeatContext) (bool, error) {
+			return g.mutateAddNode(pop, context)
+		}),
+		AddLinkMutation:MutationOperatorFunc(func(g *Genome, pop *Population, context *neat.NeatContext) (bool, error) {
+			if _, err := g.Genesis(pop.Generation); err != nil {
+				return false, err
+			}
+			return g.mutateAddLink(pop, context)
+		}),
+		ConnectSensorsMutation:MutationOperatorFunc(func(g *Genome, pop *Population, context *neat.NeatContext) (bool, error) {
+			return g.mutateConnectSensors(pop, context)
+		}),
+		LinkWeightsMutation:MutationOperatorFunc(func(g *Genome, pop *Population, context *neat.NeatContext) (bool, error) {
+			_, err := g.mutateAllNonstructural(pop.Generation, context)
+			return false, err
+		}),
+	}
+	// The mutex to guard registered operators
+	mutationOperatorsMutex sync.RWMutex
+)
+
+// Registers mutation operator with given name, so it can be referenced by context.MutationOperators.
+// The operator previously registered with the same name will be replaced.
+func RegisterMutationOperator(name string, op MutationOperator) {
+	mutationOperatorsMutex.Lock()
+	defer mutationOperatorsMutex.Unlock()
+	mutationOperators[name] = op
+}
+
+// Removes mutation operator registered with given name. It does nothing if operator with such name is not registered.
+func UnregisterMutationOperator(name string) {
+	mutationOperatorsMutex.Lock()
+	defer mutationOperatorsMutex.Unlock()
+	delete(mutationOperators, name)
+}
+
+// Returns mutation operator registered with given name or error if not found
+func MutationOperatorByName(name string) (MutationOperator, error) {
+	mutationOperatorsMutex.RLock()
+	defer mutationOperatorsMutex.RUnlock()
+	if op, ok := mutationOperators[name]; ok {
+		return op, nil
+	}
+	return nil, errors.New(fmt.Sprintf("Unknown mutation operator: %s", name))
+}
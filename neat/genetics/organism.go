@@ -4,6 +4,9 @@ import (
 	"github.com/yaricom/goNEAT/neat/network"
 	"fmt"
 	"bytes"
+	"errors"
+	"encoding/json"
+	"github.com/yaricom/goNEAT/neat"
 )
 
 // The object to associate implementation specific data with particular organism for various algorithm implementations
@@ -21,6 +24,13 @@ type Organism struct {
 	Error                     float64
 	// Win marker (if needed for a particular task)
 	IsWinner                  bool
+	// The marker that fitness was inherited from identical parent genome, thus evaluation can be skipped
+	// for deterministic fitness functions, see context.PreserveCloneFitness
+	FitnessInherited          bool
+	// The raw values of objectives for multi-objective tasks, see Population.AggregateObjectives
+	Objectives                []float64
+	// The behavior characterization of organism for novelty search and quality-diversity tasks
+	BehaviorVector            []float64
 
 	// The Organism's phenotype
 	Phenotype                 *network.Network
@@ -37,6 +47,9 @@ type Organism struct {
 	// The utility data transfer object to be used by different GA implementations to hold additional data.
 	// Implemented as ANY to allow implementation specific objects.
 	Data                      *OrganismData
+	// The arbitrary key/value metadata attached to organism, e.g., the sub-experiment which produced it. The metadata
+	// is inherited by offspring according to context.MetaInheritance
+	Meta                      map[string]string
 
 	// A fitness measure that won't change during fitness adjustments of population's epoch evaluation
 	originalFitness           float64
@@ -59,11 +72,35 @@ type Organism struct {
 	// Track its origin - for debugging or analysis - we can tell how the organism was born
 	mutationStructBaby        bool
 	mateBaby                  bool
+	// The reproduction operator which produced this Organism, empty for organisms of initial population
+	origin                    string
+	// The Species which produced this Organism during reproduction
+	parentSpecies             *Species
+	// The representative of Species this Organism was last tested for compatibility against
+	representative            *Organism
 
 	// The flag to be used as utility value
 	Flag                      int
 }
 
+// The reproduction operators which produce organisms
+const (
+	// The organisms of initial population
+	initialOrigin = "initial"
+	// The weights mutated copy of the population champion
+	superChampOrigin = "super_champ"
+	// The exact clone of the species champion
+	cloneOrigin = "clone"
+	// The mutated copy of organism
+	mutateOrigin = "mutate"
+	// The offspring of mating, optionally mutated
+	mateOrigin = "mate"
+	// The exact clone of elite organism of population
+	eliteOrigin = "elite"
+	// The fresh variant of seed genome in restarted stagnant species
+	restartOrigin = "restart"
+)
+
 // Creates new organism with specified genome, fitness and given generation number
 func NewOrganism(fit float64, g *Genome, generation int) (org *Organism, err error) {
 	phenotype := g.Phenotype
@@ -84,14 +121,70 @@ func NewOrganism(fit float64, g *Genome, generation int) (org *Organism, err err
 
 // Regenerate the network based on a change in the genotype
 func (o *Organism) UpdatePhenotype() (err error) {
-	// First, delete the old phenotype (net)
+	// First, delete the old phenotype (net) and make sure it will not be returned from the genome cache
 	o.Phenotype = nil
+	o.Genotype.Invalidate()
 
 	// Now, recreate the phenotype off the new genotype
 	o.Phenotype, err = o.Genotype.Genesis(o.Genotype.Id)
 	return err
 }
 
+// Copies current connection weights of the phenotype network into the corresponding genes of the genotype, so the
+// weights adjusted during evaluation (e.g., by local search) will be inherited by offspring (Lamarckian evolution).
+func (o *Organism) WriteBackWeights() error {
+	if o.Phenotype == nil {
+		return errors.New("Phenotype was not built for organism")
+	}
+	for _, gn := range o.Genotype.Genes {
+		if !gn.IsEnabled {
+			continue
+		}
+		in_node, out_node := gn.Link.InNode.PhenotypeAnalogue, gn.Link.OutNode.PhenotypeAnalogue
+		if in_node == nil || out_node == nil {
+			return errors.New(fmt.Sprintf("Phenotype nodes not found for gene: %s", gn))
+		}
+		found := false
+		for _, l := range out_node.Incoming {
+			if l.InNode == in_node && l.IsRecurrent == gn.Link.IsRecurrent {
+				gn.Link.Weight = l.Weight
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errors.New(fmt.Sprintf("Phenotype link not found for gene: %s", gn))
+		}
+	}
+	return nil
+}
+
+// Returns metadata to be inherited by offspring of provided parents according to policy. The dad is nil if offspring
+// produced by mutation only.
+func inheritMeta(mom, dad *Organism, policy neat.MetaInheritanceType) map[string]string {
+	sources := []*Organism{mom}
+	if dad != nil && policy == neat.MergeMetaInheritance {
+		sources = append(sources, dad)
+	}
+	var meta map[string]string
+	for _, org := range sources {
+		for k, v := range org.Meta {
+			if meta == nil {
+				meta = make(map[string]string)
+			}
+			if _, ok := meta[k]; !ok {
+				meta[k] = v
+			}
+		}
+	}
+	return meta
+}
+
+// Returns the network built as phenotype of this organism or nil if it was not built yet
+func (o *Organism) Network() *network.Network {
+	return o.Phenotype
+}
+
 // Method to check if this algorithm is champion child and if so than if it's damaged
 func (o *Organism) CheckChampionChildDamaged() bool {
 	if o.isPopulationChampionChild && o.highestFitness > o.Fitness {
@@ -103,7 +196,30 @@ func (o *Organism) CheckChampionChildDamaged() bool {
 // Encodes this organism for wired transmission during parallel reproduction cycle
 func (o *Organism) MarshalBinary() ([]byte, error) {
 	var buf bytes.Buffer
-	_, err := fmt.Fprintln(&buf, o.Fitness, o.Generation, o.highestFitness, o.isPopulationChampionChild, o.Genotype.Id)
+	origin := o.origin
+	if len(origin) == 0 {
+		origin = initialOrigin
+	}
+	_, err := fmt.Fprintln(&buf, o.Fitness, o.Generation, o.highestFitness, o.isPopulationChampionChild, o.Genotype.Id, origin,
+		o.originalFitness, o.Error, o.FitnessInherited)
+	if err != nil {
+		return nil, err
+	}
+	// the metadata encoded as JSON in one line
+	meta, err := json.Marshal(o.Meta)
+	if err != nil {
+		return nil, err
+	}
+	_, err = fmt.Fprintln(&buf, string(meta))
+	if err != nil {
+		return nil, err
+	}
+	// the behavior vector encoded as JSON in one line
+	behavior, err := json.Marshal(o.BehaviorVector)
+	if err != nil {
+		return nil, err
+	}
+	_, err = fmt.Fprintln(&buf, string(behavior))
 	o.Genotype.Write(&buf)
 	if err != nil {
 		return nil, err
@@ -116,7 +232,28 @@ func (o *Organism) UnmarshalBinary(data []byte) error {
 	// A simple encoding: plain text.
 	b := bytes.NewBuffer(data)
 	var genotype_id int
-	_, err := fmt.Fscanln(b, &o.Fitness, &o.Generation, &o.highestFitness, &o.isPopulationChampionChild, &genotype_id)
+	_, err := fmt.Fscanln(b, &o.Fitness, &o.Generation, &o.highestFitness, &o.isPopulationChampionChild, &genotype_id, &o.origin,
+		&o.originalFitness, &o.Error, &o.FitnessInherited)
+	if err != nil {
+		return err
+	}
+	if o.origin == initialOrigin {
+		o.origin = ""
+	}
+	meta, err := b.ReadBytes('\n')
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(meta, &o.Meta); err != nil {
+		return err
+	}
+	behavior, err := b.ReadBytes('\n')
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(behavior, &o.BehaviorVector); err != nil {
+		return err
+	}
 	o.Genotype, err = ReadGenome(b, genotype_id)
 	if err == nil {
 		o.Phenotype, err = o.Genotype.Genesis(genotype_id)
@@ -144,6 +281,7 @@ func (o *Organism) Dump() string {
 	fmt.Fprintln(b, "Fitness: ", o.Fitness)
 	fmt.Fprintln(b, "Error: ", o.Error)
 	fmt.Fprintln(b, "IsWinner: ", o.IsWinner)
+	fmt.Fprintln(b, "FitnessInherited: ", o.FitnessInherited)
 	fmt.Fprintln(b, "Phenotype: ", o.Phenotype)
 	fmt.Fprintln(b, "Genotype: ", o.Genotype)
 	fmt.Fprintln(b, "Species: ", o.Species)
@@ -159,6 +297,7 @@ func (o *Organism) Dump() string {
 	fmt.Fprintln(b, "highestFitness: ", o.highestFitness)
 	fmt.Fprintln(b, "mutationStructBaby: ", o.mutationStructBaby)
 	fmt.Fprintln(b, "mateBaby: ", o.mateBaby)
+	fmt.Fprintln(b, "origin: ", o.origin)
 	fmt.Fprintln(b, "Flag: ", o.Flag)
 
 	return b.String()
@@ -7,6 +7,7 @@ import (
 	"math"
 	"bytes"
 	"encoding/gob"
+	"github.com/yaricom/goNEAT/neat/network"
 )
 
 // tests organisms sorting
@@ -51,6 +52,97 @@ func TestOrganisms(t *testing.T) {
 	}
 }
 
+func TestOrganism_Network(t *testing.T) {
+	org := Organism{}
+	if org.Network() != nil {
+		t.Error("org.Network() != nil")
+	}
+
+	gnome := buildTestGenome(1)
+	new_org, err := NewOrganism(0.0, gnome, 1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	netw := new_org.Network()
+	if netw == nil {
+		t.Error("new_org.Network() == nil")
+		return
+	}
+	sensors := 0
+	for _, n := range netw.AllNodes() {
+		if n.IsSensor() {
+			sensors++
+		}
+	}
+	if sensors != 3 {
+		t.Error("sensors != 3", sensors)
+	}
+	if len(netw.Outputs) != 1 {
+		t.Error("len(netw.Outputs) != 1", len(netw.Outputs))
+	}
+	if netw.LinkCount() != len(gnome.Genes) {
+		t.Error("netw.LinkCount() != len(gnome.Genes)", netw.LinkCount(), len(gnome.Genes))
+	}
+}
+
+func TestOrganism_UpdatePhenotype(t *testing.T) {
+	gnome := buildTestGenome(1)
+	org, err := NewOrganism(0.0, gnome, 1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	netw := org.Phenotype
+	if err = org.UpdatePhenotype(); err != nil {
+		t.Error(err)
+		return
+	}
+	if org.Phenotype == netw {
+		t.Error("Phenotype was not rebuilt")
+	}
+}
+
+func TestOrganism_WriteBackWeights(t *testing.T) {
+	gnome := buildTestGenome(1)
+	org, err := NewOrganism(0.0, gnome, 1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	// modify phenotype weight of the link corresponding to the second gene
+	gene := gnome.Genes[1]
+	var link *network.Link
+	for _, l := range org.Phenotype.Outputs[0].Incoming {
+		if l.InNode == gene.Link.InNode.PhenotypeAnalogue {
+			link = l
+		}
+	}
+	if link == nil {
+		t.Error("Phenotype link not found for gene", gene)
+		return
+	}
+	link.Weight = -7.25
+
+	if err = org.WriteBackWeights(); err != nil {
+		t.Error(err)
+		return
+	}
+	if gene.Link.Weight != -7.25 {
+		t.Error("gene.Link.Weight != -7.25", gene.Link.Weight)
+	}
+	if gnome.Genes[0].Link.Weight != 1.5 {
+		t.Error("gnome.Genes[0].Link.Weight != 1.5", gnome.Genes[0].Link.Weight)
+	}
+
+	// organism without phenotype
+	org.Phenotype = nil
+	if err = org.WriteBackWeights(); err == nil {
+		t.Error("Error expected for organism without phenotype")
+	}
+}
+
 func TestOrganism_MarshalBinary(t *testing.T) {
 	gnome := buildTestGenome(1)
 	org, err := NewOrganism(rand.Float64(), gnome, 1)
@@ -58,6 +150,8 @@ func TestOrganism_MarshalBinary(t *testing.T) {
 		t.Error(err)
 		return
 	}
+	org.Meta = map[string]string{"experiment":"sub experiment A"}
+	org.BehaviorVector = []float64{0.5, -1.25}
 
 	// Marshal to binary
 	var buf bytes.Buffer
@@ -81,6 +175,12 @@ func TestOrganism_MarshalBinary(t *testing.T) {
 	if org.Fitness != dec_org.Fitness {
 		t.Error("org.Fitness != dec_org.Fitness")
 	}
+	if dec_org.Meta["experiment"] != "sub experiment A" {
+		t.Error("Organism metadata not decoded", dec_org.Meta)
+	}
+	if len(dec_org.BehaviorVector) != 2 || dec_org.BehaviorVector[0] != 0.5 || dec_org.BehaviorVector[1] != -1.25 {
+		t.Error("Organism behavior vector not decoded", dec_org.BehaviorVector)
+	}
 
 	dec_gnome := dec_org.Genotype
 	if gnome.Id != dec_gnome.Id {
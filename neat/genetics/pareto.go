@@ -0,0 +1,118 @@
+package genetics
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Returns true if organism a dominates organism b by objectives, i.e. it is not worse in all objectives and better in
+// at least one of them. All objectives are maximized. The organisms with different number of objectives never dominate.
+func dominates(a, b *Organism) bool {
+	if len(a.Objectives) != len(b.Objectives) {
+		return false
+	}
+	better := false
+	for i, obj := range a.Objectives {
+		if obj < b.Objectives[i] {
+			return false
+		} else if obj > b.Objectives[i] {
+			better = true
+		}
+	}
+	return better
+}
+
+// NonDominatedSort sorts organisms of this population into Pareto fronts by their objectives as in NSGA-II. The first
+// front holds organisms not dominated by any other, the second one holds organisms dominated only by the first front
+// and so on. All objectives are maximized.
+func (p *Population) NonDominatedSort() [][]*Organism {
+	// the number of organisms dominating each organism and the organisms dominated by it
+	dominated_count := make([]int, len(p.Organisms))
+	dominated_set := make([][]int, len(p.Organisms))
+	front := make([]int, 0)
+	for i, org_i := range p.Organisms {
+		for j, org_j := range p.Organisms {
+			if i == j {
+				continue
+			}
+			if dominates(org_i, org_j) {
+				dominated_set[i] = append(dominated_set[i], j)
+			} else if dominates(org_j, org_i) {
+				dominated_count[i]++
+			}
+		}
+		if dominated_count[i] == 0 {
+			front = append(front, i)
+		}
+	}
+
+	fronts := make([][]*Organism, 0)
+	for len(front) > 0 {
+		orgs := make([]*Organism, len(front))
+		next_front := make([]int, 0)
+		for k, i := range front {
+			orgs[k] = p.Organisms[i]
+			for _, j := range dominated_set[i] {
+				dominated_count[j]--
+				if dominated_count[j] == 0 {
+					next_front = append(next_front, j)
+				}
+			}
+		}
+		fronts = append(fronts, orgs)
+		front = next_front
+	}
+	return fronts
+}
+
+// Returns crowding distance of each organism in the front as in NSGA-II. The boundary organisms of each objective get
+// infinite distance.
+func crowdingDistances(front []*Organism) map[*Organism]float64 {
+	distances := make(map[*Organism]float64, len(front))
+	if len(front) == 0 {
+		return distances
+	}
+	sorted := make([]*Organism, len(front))
+	copy(sorted, front)
+	for obj := range front[0].Objectives {
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return sorted[i].Objectives[obj] < sorted[j].Objectives[obj]
+		})
+		first, last := sorted[0], sorted[len(sorted) - 1]
+		distances[first], distances[last] = math.Inf(1), math.Inf(1)
+		obj_range := last.Objectives[obj] - first.Objectives[obj]
+		if obj_range == 0 {
+			continue
+		}
+		for i := 1; i < len(sorted) - 1; i++ {
+			distances[sorted[i]] += (sorted[i + 1].Objectives[obj] - sorted[i - 1].Objectives[obj]) / obj_range
+		}
+	}
+	return distances
+}
+
+// Assigns fitness of organisms according to the rank of their Pareto front and crowding distance within front, so
+// the organisms of better front are always more fit and within the same front the less crowded are preferred.
+// Returns error if organisms have no objectives or the number of objectives differs between organisms.
+func (p *Population) assignParetoFitness() error {
+	for _, org := range p.Organisms {
+		if len(org.Objectives) == 0 || len(org.Objectives) != len(p.Organisms[0].Objectives) {
+			return errors.New(fmt.Sprintf("POPULATION: Organism [%d] has %d objectives, expected: %d",
+				org.Genotype.Id, len(org.Objectives), len(p.Organisms[0].Objectives)))
+		}
+	}
+	fronts := p.NonDominatedSort()
+	for rank, front := range fronts {
+		distances := crowdingDistances(front)
+		for _, org := range front {
+			crowding := 1.0
+			if d := distances[org]; !math.IsInf(d, 1) {
+				crowding = d / (1.0 + d)
+			}
+			org.Fitness = float64(len(fronts) - rank) + 0.5 * crowding
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package genetics
+
+import (
+	"testing"
+)
+
+func TestPopulation_NonDominatedSort(t *testing.T) {
+	pop := newPopulation()
+	sp, err := buildSpeciesWithOrganisms(1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	pop.Organisms = append(pop.Organisms, sp.Organisms...)
+	pop.Organisms[0].Objectives = []float64{1.0, 2.0}
+	pop.Organisms[1].Objectives = []float64{2.0, 1.0}
+	// dominated by both others
+	dominated := pop.Organisms[2]
+	dominated.Objectives = []float64{0.5, 0.5}
+
+	fronts := pop.NonDominatedSort()
+	if len(fronts) != 2 {
+		t.Error("len(fronts) != 2", len(fronts))
+		return
+	}
+	if len(fronts[0]) != 2 {
+		t.Error("len(fronts[0]) != 2", len(fronts[0]))
+	}
+	if len(fronts[1]) != 1 || fronts[1][0] != dominated {
+		t.Error("The dominated organism is not in the second front", fronts[1])
+	}
+
+	// the fitness assigned by front rank
+	if err = pop.assignParetoFitness(); err != nil {
+		t.Error(err)
+		return
+	}
+	for _, org := range fronts[0] {
+		if org.Fitness <= dominated.Fitness {
+			t.Error("org.Fitness <= dominated.Fitness", org.Fitness, dominated.Fitness)
+		}
+	}
+
+	// the number of objectives differs
+	dominated.Objectives = []float64{0.5}
+	if err = pop.assignParetoFitness(); err == nil {
+		t.Error("Error expected for wrong number of objectives")
+	}
+}
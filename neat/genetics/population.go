@@ -4,6 +4,7 @@ import (
 	"math/rand"
 	"errors"
 	"github.com/yaricom/goNEAT/neat"
+	"github.com/yaricom/goNEAT/neat/network"
 
 	"io"
 	"fmt"
@@ -14,6 +15,8 @@ import (
 	"math"
 	"sync/atomic"
 	"sync"
+	"sort"
+	"time"
 )
 
 // A Population is a group of Organisms including their species
@@ -37,15 +40,34 @@ type Population struct {
 	// than delta coding will be applied to avoid population's fitness stagnation
 	EpochsHighestLastChanged int
 
+	// The number of generations this population was advanced
+	Generation               int
+
+	// The current phase of phased search
+	Phase                    SearchPhase
+
+	// The best organisms ever found in this population across all generations, the most fit goes first
+	HallOfFame               []*Organism
+
+	// The number of structural mutations of the last generation which reused already existing innovation
+	InnovationsReused        int
+	// The number of structural mutations of the last generation which created new innovation
+	InnovationsCreated       int
+
 	/* Fitness Statistics */
 	MeanFitness              float64
 	Variance                 float64
 	StandardDev              float64
 
+	// The genome this population was spawned from, it is used to re-seed stagnant species
+	seedGenome               *Genome
+
 	// The next innovation number for population
 	nextInnovNum             int64
 	// The next ID for new node in population
 	nextNodeId               int32
+	// The number of genomes compatibility tests performed during speciation
+	compatChecks             int
 
 	// The mutex to guard against concurrent modifications
 	mutex                    *sync.Mutex
@@ -65,6 +87,11 @@ func NewPopulation(g *Genome, context *neat.NeatContext) (*Population, error) {
 		return nil, errors.New(
 			fmt.Sprintf("Wrong population size in the context: %d", context.PopSize))
 	}
+	if len(context.CompatibilityMetric) > 0 {
+		if _, err := CompatibilityMetricByName(context.CompatibilityMetric); err != nil {
+			return nil, err
+		}
+	}
 
 	pop := newPopulation()
 	err := pop.spawn(g, context)
@@ -74,6 +101,99 @@ func NewPopulation(g *Genome, context *neat.NeatContext) (*Population, error) {
 	return pop, nil
 }
 
+// Construct off of the list of template genomes, e.g., pre-trained in previous runs. All provided genomes will be added
+// to the population as is and the remaining slots will be filled by genomes produced from random templates with
+// perturbed link weights. The innovation history is initialized from all templates, thus it is assumed that templates
+// share the same innovation numbering.
+func NewPopulationFromGenomes(genomes []*Genome, context *neat.NeatContext) (*Population, error) {
+	if len(genomes) == 0 {
+		return nil, errors.New("No template genomes provided")
+	}
+	if context.PopSize < len(genomes) {
+		return nil, errors.New(
+			fmt.Sprintf("Wrong population size in the context: %d, less than number of templates: %d",
+				context.PopSize, len(genomes)))
+	}
+
+	pop := newPopulation()
+	for count := 0; count < context.PopSize; count++ {
+		var new_genome *Genome
+		var err error
+		if count < len(genomes) {
+			// keep template as is
+			new_genome, err = genomes[count].duplicate(count)
+		} else if new_genome, err = genomes[rand.Intn(len(genomes))].duplicate(count); err == nil {
+			// perturb random template
+			_, err = new_genome.mutateLinkWeights(1.0, 1.0, context.WeightCap, 1.0, gaussianMutator)
+		}
+		if err != nil {
+			return nil, err
+		}
+		org, err := NewOrganism(0.0, new_genome, 1)
+		if err != nil {
+			return nil, err
+		}
+		pop.Organisms = append(pop.Organisms, org)
+	}
+
+	// Keep a record of the innovation and node number we are on
+	for _, g := range genomes {
+		last_node_id, err := g.getLastNodeId()
+		if err != nil {
+			return nil, err
+		}
+		if int32(last_node_id + 1) > pop.nextNodeId {
+			pop.nextNodeId = int32(last_node_id + 1)
+		}
+		next_innov_num, err := g.getNextGeneInnovNum()
+		if err != nil {
+			return nil, err
+		}
+		if next_innov_num > pop.nextInnovNum {
+			pop.nextInnovNum = next_innov_num
+		}
+	}
+
+	// Separate the new Population into species
+	if err := pop.speciate(pop.Organisms, context); err != nil {
+		return nil, err
+	}
+	return pop, nil
+}
+
+// Construct off of the minimal genomes with in inputs and out outputs created by NewMinimalGenome, thus the bias node
+// connected to all outputs is added if context.WithBiasNode is set. Each organism gets its own minimal genome, so that
+// with sparse initial connectivity the population starts with a variety of connection patterns.
+func NewPopulationMinimal(in, out int, context *neat.NeatContext) (*Population, error) {
+	if context.PopSize <= 0 {
+		return nil, errors.New(
+			fmt.Sprintf("Wrong population size in the context: %d", context.PopSize))
+	}
+
+	pop := newPopulation()
+	for count := 0; count < context.PopSize; count++ {
+		gen := NewMinimalGenome(count, in, out, context)
+		org, err := NewOrganism(0.0, gen, 1)
+		if err != nil {
+			return nil, err
+		}
+		pop.Organisms = append(pop.Organisms, org)
+	}
+	// Keep a record of the innovation and node number we are on, the innovation numbers are reserved for all
+	// possible links between sensors and outputs
+	sensors := in
+	if context.WithBiasNode {
+		sensors++
+	}
+	pop.nextNodeId = int32(sensors + out + 1)
+	pop.nextInnovNum = int64(sensors * out + 1)
+
+	if err := pop.speciate(pop.Organisms, context); err != nil {
+		return nil, err
+	}
+	return pop, nil
+}
+
 // Special constructor to create a population of random topologies uses
 // NewGenomeRand(new_id, in, out, n, nmax int, recurrent bool, link_prob float64)
 // See the Genome constructor above for the argument specifications
@@ -112,6 +232,7 @@ func ReadPopulation(ir io.Reader, context *neat.NeatContext) (pop *Population, e
 	scanner.Split(bufio.ScanLines)
 	var out_buff *bytes.Buffer
 	var id_check int
+	version_line := ""
 	for scanner.Scan() {
 		line := scanner.Text()
 		parts := strings.SplitN(line, " ", 2)
@@ -119,8 +240,16 @@ func ReadPopulation(ir io.Reader, context *neat.NeatContext) (pop *Population, e
 			return nil, errors.New(fmt.Sprintf("Line: [%s] can not be split when reading Population", line))
 		}
 		switch parts[0] {
+		case "genomeversion":
+			// the format version header leading the next genome
+			version_line = line
 		case "genomestart":
-			out_buff = bytes.NewBufferString(fmt.Sprintf("genomestart %s", parts[1]))
+			out_buff = bytes.NewBufferString("")
+			if len(version_line) > 0 {
+				fmt.Fprintln(out_buff, version_line)
+				version_line = ""
+			}
+			fmt.Fprintf(out_buff, "genomestart %s\n", parts[1])
 			id_check, err = strconv.Atoi(parts[1])
 			if err != nil {
 				return nil, err
@@ -190,6 +319,687 @@ func (p *Population) WriteBySpecies(w io.Writer) {
 	}
 }
 
+// Writes genomes of this population by species to the provided writer using given encoding. Each genome is encoded and
+// flushed to the underlying writer one at a time, thus the whole population never accumulated in memory and output can
+// be piped directly into compressed file. Each species preceded by comment line. The written genomes can be read back
+// with ReadGenomes.
+func (p *Population) WriteStream(w io.Writer, encoding GenomeEncoding) error {
+	g_writer, err := NewGenomeWriter(w, encoding)
+	if err != nil {
+		return err
+	}
+	for _, sp := range p.Species {
+		switch encoding {
+		case PlainGenomeEncoding:
+			_, err = fmt.Fprintf(w, "/* Species #%d : (Size %d) (Age %d) */\n", sp.Id, len(sp.Organisms), sp.Age)
+		case YAMLGenomeEncoding:
+			_, err = fmt.Fprintf(w, "# Species #%d : (Size %d) (Age %d)\n", sp.Id, len(sp.Organisms), sp.Age)
+		}
+		if err != nil {
+			return err
+		}
+		for _, org := range sp.Organisms {
+			if err = g_writer.WriteGenome(org.Genotype); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Writes metrics of this population in Prometheus text exposition format labeled with provided generation
+func (p *Population) WriteMetrics(w io.Writer, generation int) error {
+	best_fitness := 0.0
+	for i, org := range p.Organisms {
+		if i == 0 || org.Fitness > best_fitness {
+			best_fitness = org.Fitness
+		}
+	}
+	metrics := []struct {
+		name  string
+		help  string
+		value float64
+	}{
+		{"neat_species_count", "The number of species in population", float64(len(p.Species))},
+		{"neat_best_fitness", "The best fitness of organism in population", best_fitness},
+		{"neat_mean_complexity", "The mean complexity of genomes in population", p.MeanComplexity()},
+		{"neat_organisms_total", "The number of organisms in population", float64(len(p.Organisms))},
+	}
+	for _, m := range metrics {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s{generation=\"%d\"} %s\n",
+			m.name, m.help, m.name, m.name, generation, strconv.FormatFloat(m.value, 'g', -1, 64)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Speciate separates all organisms of this population into species by checking compatibilities against a threshold,
+// creating new species as needed. The existing species structure, if any, will be discarded. This can be used
+// to (re)build species after organisms was loaded or modified externally.
+//
+// If context.IncrementalSpeciation is set and population already has species, than only organisms which representative
+// of their species changed since last compatibility test will be tested against it. The organisms that are no longer
+// compatible with their species will be speciated again.
+func (p *Population) Speciate(context *neat.NeatContext) error {
+	if context.IncrementalSpeciation && len(p.Species) > 0 {
+		return p.speciateIncremental(context)
+	}
+
+	// clear existing species structure
+	for _, org := range p.Organisms {
+		org.Species = nil
+	}
+	p.Species = make([]*Species, 0)
+
+	return p.speciate(p.Organisms, context)
+}
+
+// Merges organisms of other population into this one and speciates the combined set of organisms. The innovation
+// numbers of genes and IDs of hidden nodes of other population's genomes will be shifted above maximal values of this
+// population to avoid collisions during future structural mutations. The other population should not be used after merge.
+func (p *Population) Merge(other *Population, context *neat.NeatContext) error {
+	if len(other.Organisms) == 0 {
+		return nil
+	}
+	innov_offset := p.nextInnovNum
+	node_offset := int(p.nextNodeId)
+
+	// re-map innovation numbers and hidden node IDs of other population
+	nodes_seen := make(map[*network.NNode]bool)
+	for _, org := range other.Organisms {
+		for _, gene := range org.Genotype.Genes {
+			gene.InnovationNum += innov_offset
+		}
+		for _, c_gene := range org.Genotype.ControlGenes {
+			c_gene.InnovationNum += innov_offset
+			if !nodes_seen[c_gene.ControlNode] {
+				c_gene.ControlNode.Id += node_offset
+				nodes_seen[c_gene.ControlNode] = true
+			}
+		}
+		for _, node := range org.Genotype.Nodes {
+			if node.NeuronType == network.HiddenNeuron && !nodes_seen[node] {
+				node.Id += node_offset
+				nodes_seen[node] = true
+			}
+		}
+		// keep nodes ordered by ID
+		nodes := org.Genotype.Nodes
+		sort.Slice(nodes, func(i, j int) bool {
+			return nodes[i].Id < nodes[j].Id
+		})
+		// rebuild phenotype to reflect changed node IDs
+		if err := org.UpdatePhenotype(); err != nil {
+			return err
+		}
+		org.Species = nil
+		org.representative = nil
+		p.Organisms = append(p.Organisms, org)
+	}
+	p.nextInnovNum = innov_offset + other.nextInnovNum
+	p.nextNodeId = int32(node_offset) + other.nextNodeId
+
+	// speciate the combined set of organisms
+	return p.Speciate(context)
+}
+
+// Selects up to n organisms to migrate into another population according to given strategy. With BestPerSpeciesMigration
+// strategy the champions of the n best species will be selected. If n is zero or negative than champions of all species
+// will be returned. The returned organisms hold deep copies of genomes and can be safely modified by destination.
+func (p *Population) SelectMigrants(n int, strategy MigrationStrategy) ([]*Organism, error) {
+	var selected []*Organism
+	switch strategy {
+	case BestNMigration:
+		sorted_organisms := make(Organisms, len(p.Organisms))
+		copy(sorted_organisms, p.Organisms)
+		sort.Sort(sort.Reverse(sorted_organisms))
+		selected = sorted_organisms
+	case RandomNMigration:
+		selected = make([]*Organism, len(p.Organisms))
+		for i, j := range rand.Perm(len(p.Organisms)) {
+			selected[i] = p.Organisms[j]
+		}
+	case BestPerSpeciesMigration:
+		selected = make([]*Organism, 0)
+		for _, sp := range p.Species {
+			if champ := sp.FindChampion(); champ != nil {
+				selected = append(selected, champ)
+			}
+		}
+		sort.Sort(sort.Reverse(Organisms(selected)))
+		if n <= 0 {
+			n = len(selected)
+		}
+	default:
+		return nil, ErrUnsupportedMigrationStrategy
+	}
+	if n > len(selected) {
+		n = len(selected)
+	}
+
+	// create migrants with copies of genomes
+	migrants := make([]*Organism, n)
+	for i, org := range selected[:n] {
+		new_genome, err := org.Genotype.duplicate(org.Genotype.Id)
+		if err != nil {
+			return nil, err
+		}
+		if migrants[i], err = NewOrganism(org.Fitness, new_genome, org.Generation); err != nil {
+			return nil, err
+		}
+		migrants[i].Meta = inheritMeta(org, nil, neat.MomMetaInheritance)
+	}
+	return migrants, nil
+}
+
+// The differences found between two populations
+type PopulationDiff struct {
+	// The IDs of species present only in the second population
+	SpeciesAdded     []int
+	// The IDs of species present only in the first population
+	SpeciesRemoved   []int
+	// The IDs of matched genomes which full hash changed
+	ChangedOrganisms []int
+	// The fitness changes of matched organisms keyed by genome ID
+	FitnessDeltas    map[int]float64
+}
+
+// DiffPopulations compares two populations. Species are matched by ID and organisms are matched by their genome ID.
+func DiffPopulations(a, b *Population) PopulationDiff {
+	diff := PopulationDiff{
+		SpeciesAdded:make([]int, 0),
+		SpeciesRemoved:make([]int, 0),
+		ChangedOrganisms:make([]int, 0),
+		FitnessDeltas:make(map[int]float64),
+	}
+
+	a_species, b_species := make(map[int]bool), make(map[int]bool)
+	for _, sp := range a.Species {
+		a_species[sp.Id] = true
+	}
+	for _, sp := range b.Species {
+		b_species[sp.Id] = true
+		if !a_species[sp.Id] {
+			diff.SpeciesAdded = append(diff.SpeciesAdded, sp.Id)
+		}
+	}
+	for _, sp := range a.Species {
+		if !b_species[sp.Id] {
+			diff.SpeciesRemoved = append(diff.SpeciesRemoved, sp.Id)
+		}
+	}
+
+	a_orgs := make(map[int]*Organism)
+	for _, org := range a.Organisms {
+		a_orgs[org.Genotype.Id] = org
+	}
+	for _, org := range b.Organisms {
+		a_org, ok := a_orgs[org.Genotype.Id]
+		if !ok {
+			continue
+		}
+		if a_org.Genotype.FullHash() != org.Genotype.FullHash() {
+			diff.ChangedOrganisms = append(diff.ChangedOrganisms, org.Genotype.Id)
+		}
+		diff.FitnessDeltas[org.Genotype.Id] = org.Fitness - a_org.Fitness
+	}
+	sort.Ints(diff.SpeciesAdded)
+	sort.Ints(diff.SpeciesRemoved)
+	sort.Ints(diff.ChangedOrganisms)
+
+	return diff
+}
+
+// AdvanceGeneration increments the generation counter of this population and the age of each its species. The novel
+// species created during the last reproduction are not aged, but lose their novelty. It is used by the epoch
+// executors and can be used by custom epoch loops to age population consistently.
+func (p *Population) AdvanceGeneration() {
+	p.Generation++
+	for _, sp := range p.Species {
+		if sp.IsNovel {
+			sp.IsNovel = false
+		} else {
+			sp.Age++
+		}
+	}
+}
+
+// ApplyMinimalCriteria zeroes the fitness of organisms failing provided minimal criteria predicate and marks them to be
+// eliminated. With novelty search, where the novelty score is assigned as organism's fitness, it prevents behaviorally
+// novel but useless organisms from reproduction. If all organisms of species failed, its champion is not eliminated to
+// keep species able to produce the offspring allocated to it.
+func (p *Population) ApplyMinimalCriteria(pred func(*Organism) bool) {
+	for _, sp := range p.Species {
+		var champion *Organism
+		failed := make([]*Organism, 0)
+		for _, org := range sp.Organisms {
+			if !pred(org) {
+				failed = append(failed, org)
+				if champion == nil || org.Fitness > champion.Fitness {
+					champion = org
+				}
+			}
+		}
+		for _, org := range failed {
+			org.Fitness = 0.0
+			org.toEliminate = len(failed) < len(sp.Organisms) || org != champion
+		}
+	}
+}
+
+// RestartStagnantSpecies re-seeds species which were not improved longer than context.DropOffAge. All organisms of such
+// species except the champion are replaced with fresh variants of the seed genome with perturbed link weights, and
+// the age of last improvement is reset. The champion's genome is used as seed if population was not spawned from one.
+// It should be invoked after fitness of organisms is adjusted and before reproduction, thus the evaluated champion is
+// kept and the staleness is judged on the evaluated generation.
+func (p *Population) RestartStagnantSpecies(context *neat.NeatContext) error {
+	replaced := make(map[*Organism]*Organism)
+	for _, sp := range p.Species {
+		if sp.Age - sp.AgeOfLastImprovement < context.DropOffAge || len(sp.Organisms) == 0 {
+			continue
+		}
+		champion := sp.FindChampion()
+		seed := p.seedGenome
+		if seed == nil {
+			seed = champion.Genotype
+		}
+		for i, org := range sp.Organisms {
+			if org == champion {
+				continue
+			}
+			new_genome, err := seed.duplicate(org.Genotype.Id)
+			if err != nil {
+				return err
+			}
+			if _, err = new_genome.mutateLinkWeights(1.0, 1.0, context.WeightCap, 1.0, gaussianMutator); err != nil {
+				return err
+			}
+			new_org, err := NewOrganism(0.0, new_genome, org.Generation)
+			if err != nil {
+				return err
+			}
+			new_org.Species = sp
+			new_org.origin = restartOrigin
+			new_org.toEliminate = org.toEliminate
+			sp.Organisms[i] = new_org
+			replaced[org] = new_org
+		}
+		sp.AgeOfLastImprovement = sp.Age
+
+		neat.DebugLog(fmt.Sprintf("POPULATION: Restarted stagnant species [%d] of age: %d", sp.Id, sp.Age))
+	}
+	// update master list of organisms
+	for i, org := range p.Organisms {
+		if new_org, ok := replaced[org]; ok {
+			p.Organisms[i] = new_org
+		}
+	}
+	return nil
+}
+
+// AggregateObjectives computes fitness of each organism as weighted sum of its objectives. The raw objectives are kept
+// in Organism.Objectives for later analysis. Returns error if the number of objectives of any organism differs from
+// the number of weights.
+func (p *Population) AggregateObjectives(weights []float64) error {
+	for _, org := range p.Organisms {
+		if len(org.Objectives) != len(weights) {
+			return errors.New(fmt.Sprintf("POPULATION: Organism [%d] has %d objectives, expected: %d",
+				org.Genotype.Id, len(org.Objectives), len(weights)))
+		}
+	}
+	for _, org := range p.Organisms {
+		org.Fitness = 0.0
+		for i, w := range weights {
+			org.Fitness += w * org.Objectives[i]
+		}
+	}
+	return nil
+}
+
+// EachOrganism calls fn for every organism of each species of this population in order of species. The iteration stops
+// early if fn returns false.
+func (p *Population) EachOrganism(fn func(*Organism) bool) {
+	for _, sp := range p.Species {
+		for _, org := range sp.Organisms {
+			if !fn(org) {
+				return
+			}
+		}
+	}
+}
+
+// AllOrganisms returns organisms of all species of this population according to the current species membership
+func (p *Population) AllOrganisms() []*Organism {
+	organisms := make([]*Organism, 0, len(p.Organisms))
+	p.EachOrganism(func(org *Organism) bool {
+		organisms = append(organisms, org)
+		return true
+	})
+	return organisms
+}
+
+// ReproductionBreakdown returns the number of organisms of this population grouped by reproduction operator which
+// produced them in the most recent generation: super_champ, clone, mutate, mate, elite, restart, or initial for
+// the organisms of initial population.
+func (p *Population) ReproductionBreakdown() map[string]int {
+	breakdown := make(map[string]int)
+	for _, org := range p.Organisms {
+		if len(org.origin) == 0 {
+			breakdown[initialOrigin]++
+		} else {
+			breakdown[org.origin]++
+		}
+	}
+	return breakdown
+}
+
+// UpdateHallOfFame inserts a copy of the current population champion into the HallOfFame keeping at most size the most
+// fit organisms seen across all generations. It should be called after organisms of the current generation evaluated.
+// The champion is not inserted if the same genome with the same fitness is already in the hall of fame.
+func (p *Population) UpdateHallOfFame(size int) error {
+	if size <= 0 {
+		return errors.New(fmt.Sprintf("POPULATION: Wrong hall of fame size: %d", size))
+	}
+	var champion *Organism
+	for _, org := range p.Organisms {
+		if champion == nil || org.Fitness > champion.Fitness {
+			champion = org
+		}
+	}
+	if champion == nil {
+		return nil
+	}
+	for _, org := range p.HallOfFame {
+		if org.Fitness == champion.Fitness {
+			if eq, _ := org.Genotype.IsEqual(champion.Genotype); eq {
+				return nil
+			}
+		}
+	}
+
+	// store the deep copy of champion to keep it when original one eliminated
+	new_genome, err := champion.Genotype.duplicate(champion.Genotype.Id)
+	if err != nil {
+		return err
+	}
+	org, err := NewOrganism(champion.Fitness, new_genome, champion.Generation)
+	if err != nil {
+		return err
+	}
+	org.Error = champion.Error
+	org.IsWinner = champion.IsWinner
+	org.Meta = inheritMeta(champion, nil, neat.MomMetaInheritance)
+	if champion.BehaviorVector != nil {
+		org.BehaviorVector = append([]float64(nil), champion.BehaviorVector...)
+	}
+
+	p.HallOfFame = append(p.HallOfFame, org)
+	sort.SliceStable(p.HallOfFame, func(i, j int) bool {
+		return p.HallOfFame[i].Fitness > p.HallOfFame[j].Fitness
+	})
+	if len(p.HallOfFame) > size {
+		p.HallOfFame = p.HallOfFame[:size]
+	}
+	return nil
+}
+
+// SplitLargeSpecies splits each species having more than maxSize organisms into two sub-species by clustering its
+// organisms around two the most distant members. The cluster without species champion moves into the new species.
+// Species which organisms are all identical are not split.
+func (p *Population) SplitLargeSpecies(maxSize int, context *neat.NeatContext) error {
+	if maxSize <= 0 {
+		return errors.New(fmt.Sprintf("POPULATION: Wrong maximal species size: %d", maxSize))
+	}
+	new_species := make([]*Species, 0)
+	for _, sp := range p.Species {
+		if len(sp.Organisms) <= maxSize {
+			continue
+		}
+		// find the organism most distant from the species champion to seed the clusters
+		seed_1 := sp.FindChampion()
+		var seed_2 *Organism
+		max_compat := 0.0
+		for _, org := range sp.Organisms {
+			if org == seed_1 {
+				continue
+			}
+			if compat := seed_1.Genotype.compatibility(org.Genotype, context); compat > max_compat {
+				seed_2, max_compat = org, compat
+			}
+		}
+		if seed_2 == nil {
+			// all organisms are identical
+			continue
+		}
+
+		// assign organisms to the closest seed
+		cluster_1, cluster_2 := make([]*Organism, 0), make([]*Organism, 0)
+		for _, org := range sp.Organisms {
+			if org == seed_2 || seed_2.Genotype.compatibility(org.Genotype, context) <
+				seed_1.Genotype.compatibility(org.Genotype, context) {
+				cluster_2 = append(cluster_2, org)
+			} else {
+				cluster_1 = append(cluster_1, org)
+			}
+		}
+
+		// move the second cluster into the new species
+		p.LastSpecies++
+		split_species := NewSpeciesNovel(p.LastSpecies, true)
+		for _, org := range cluster_2 {
+			split_species.addOrganism(org)
+			org.Species = split_species
+		}
+		sp.Organisms = cluster_1
+		new_species = append(new_species, split_species)
+
+		neat.DebugLog(fmt.Sprintf("POPULATION: Species [%d] split with %d organisms moved to new species [%d]",
+			sp.Id, len(cluster_2), split_species.Id))
+	}
+	p.Species = append(p.Species, new_species...)
+	return nil
+}
+
+// The function to evaluate given organism and return its fitness
+type OrganismEvaluator func(org *Organism) (float64, error)
+
+// Evaluates all organisms of this population in parallel using provided evaluator function and stores returned fitness
+// values. If timeout is positive and evaluation of organism exceeds it, the organism's fitness is set to zero and it
+// marked for elimination. The evaluator is invoked with a copy of organism, which is published back only if evaluation
+// finished before timeout. Note that timed out evaluation can not be interrupted and keeps running in background on
+// its copy of organism, its results will be discarded. The organisms with inherited fitness are not evaluated. Returns
+// the first error returned by evaluator if any.
+func (p *Population) EvaluateParallel(eval OrganismEvaluator, timeout time.Duration) error {
+	type evalResult struct {
+		fitness float64
+		err     error
+	}
+	errs := make([]error, len(p.Organisms))
+	var wg sync.WaitGroup
+	for i, org := range p.Organisms {
+		if org.FitnessInherited {
+			// no need to evaluate the same genome again
+			continue
+		}
+		wg.Add(1)
+		go func(i int, org *Organism) {
+			defer wg.Done()
+
+			// the copy of organism to be evaluated, so that timed out evaluation can not modify the original
+			org_copy := *org
+			org_copy.Objectives = append([]float64(nil), org.Objectives...)
+			org_copy.BehaviorVector = append([]float64(nil), org.BehaviorVector...)
+			if org.Meta != nil {
+				org_copy.Meta = make(map[string]string, len(org.Meta))
+				for k, v := range org.Meta {
+					org_copy.Meta[k] = v
+				}
+			}
+
+			// the buffered channel to let timed out evaluation to finish without blocking
+			res_chan := make(chan evalResult, 1)
+			go func() {
+				fitness, err := eval(&org_copy)
+				res_chan <- evalResult{fitness:fitness, err:err}
+			}()
+
+			var timeout_chan <-chan time.Time
+			if timeout > 0 {
+				timer := time.NewTimer(timeout)
+				defer timer.Stop()
+				timeout_chan = timer.C
+			}
+			select {
+			case res := <-res_chan:
+				// the evaluation is finished, publish its results
+				*org = org_copy
+				org.Fitness = res.fitness
+				errs[i] = res.err
+			case <-timeout_chan:
+				neat.WarnLog(fmt.Sprintf("POPULATION: Evaluation of organism [%d] timed out after %s",
+					org.Genotype.Id, timeout))
+				org.Fitness = 0.0
+				org.toEliminate = true
+			}
+		}(i, org)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Clones the top context.PopulationElitism organisms of the whole population (ranked by original fitness) into the
+// next generation and decrements offspring budgets of the species they belong to. It should be invoked after the
+// expected offspring of species was determined and before species reproduction. The returned clones hold exact
+// duplicates of elite genomes and should be added to the progeny of the next generation.
+func (p *Population) PreserveGlobalElites(generation int, context *neat.NeatContext) ([]*Organism, error) {
+	if context.PopulationElitism <= 0 {
+		return nil, nil
+	}
+	sorted_organisms := make([]*Organism, len(p.Organisms))
+	copy(sorted_organisms, p.Organisms)
+	sort.SliceStable(sorted_organisms, func(i, j int) bool {
+		return sorted_organisms[i].originalFitness > sorted_organisms[j].originalFitness
+	})
+
+	elites := make([]*Organism, 0)
+	for _, org := range sorted_organisms {
+		if len(elites) >= context.PopulationElitism {
+			break
+		}
+		// skip organisms of species having no offspring budget left to keep population size the same
+		if org.Species == nil || org.Species.ExpectedOffspring <= 0 {
+			continue
+		}
+		new_genome, err := org.Genotype.duplicate(org.Genotype.Id)
+		if err != nil {
+			return nil, err
+		}
+		baby, err := NewOrganism(0.0, new_genome, generation)
+		if err != nil {
+			return nil, err
+		}
+		baby.Meta = inheritMeta(org, nil, context.MetaInheritance)
+		baby.origin = eliteOrigin
+		baby.parentSpecies = org.Species
+		org.Species.ExpectedOffspring--
+		org.Species.OffspringProduced++
+		// the super champion of species may not produce more offspring than species has left
+		if champ := org.Species.Organisms[0]; champ.superChampOffspring > org.Species.ExpectedOffspring {
+			champ.superChampOffspring = org.Species.ExpectedOffspring
+		}
+		elites = append(elites, baby)
+
+		neat.DebugLog(fmt.Sprintf("POPULATION: Preserved global elite organism with original fitness: %f of species: %d",
+			org.originalFitness, org.Species.Id))
+	}
+	return elites, nil
+}
+
+// Switches the phase of phased search depending on the mean complexity of population genomes. The simplifying phase
+// starts when mean complexity exceeds context.SimplifyThreshold and ends when it drops below context.ComplexifyThreshold.
+func (p *Population) updateSearchPhase(context *neat.NeatContext) {
+	if !context.PhasedSearch || len(p.Organisms) == 0 {
+		return
+	}
+	mean_complexity := p.MeanComplexity()
+	if p.Phase == ComplexifyingPhase && mean_complexity > context.SimplifyThreshold {
+		p.Phase = SimplifyingPhase
+		neat.DebugLog(fmt.Sprintf("POPULATION: Start simplifying phase, mean complexity: %f", mean_complexity))
+	} else if p.Phase == SimplifyingPhase && mean_complexity < context.ComplexifyThreshold {
+		p.Phase = ComplexifyingPhase
+		neat.DebugLog(fmt.Sprintf("POPULATION: Start complexifying phase, mean complexity: %f", mean_complexity))
+	}
+}
+
+// Returns the mean complexity of population genomes measured as the number of nodes plus the number of enabled genes
+func (p *Population) MeanComplexity() float64 {
+	if len(p.Organisms) == 0 {
+		return 0.0
+	}
+	total := 0
+	for _, org := range p.Organisms {
+		c := org.Genotype.Complexity()
+		total += c.Nodes + c.EnabledGenes
+	}
+	return float64(total) / float64(len(p.Organisms))
+}
+
+// Speciates only organisms that have no species or drifted out of their species since last compatibility test
+func (p *Population) speciateIncremental(context *neat.NeatContext) error {
+	drifted := make([]*Organism, 0)
+	for _, org := range p.Organisms {
+		if org.Species == nil {
+			drifted = append(drifted, org)
+			continue
+		}
+		rep_org := org.Species.firstOrganism()
+		if rep_org == org || rep_org == org.representative {
+			// the representative of species is the same since last check - no need to test again
+			continue
+		}
+		// the representative changed - check that organism is still compatible with species
+		p.compatChecks++
+		if org.Genotype.compatibility(rep_org.Genotype, context) < context.EffectiveCompatThreshold() {
+			org.representative = rep_org
+			continue
+		}
+		neat.DebugLog(fmt.Sprintf("POPULATION: Organism [%d] drifted out of species [%d]",
+			org.Genotype.Id, org.Species.Id))
+		if _, err := org.Species.removeOrganism(org); err != nil {
+			return err
+		}
+		org.Species = nil
+		org.representative = nil
+		drifted = append(drifted, org)
+	}
+	if len(drifted) == 0 {
+		return nil
+	}
+
+	// speciate drifted organisms
+	if err := p.speciate(drifted, context); err != nil {
+		return err
+	}
+
+	// remove species that become empty
+	species := make([]*Species, 0)
+	for _, sp := range p.Species {
+		if len(sp.Organisms) > 0 {
+			species = append(species, sp)
+		}
+	}
+	p.Species = species
+
+	return nil
+}
+
 // Run verify on all Genomes in this Population (Debugging)
 func (p *Population) Verify() (bool, error) {
 	res := true
@@ -203,11 +1013,75 @@ func (p *Population) Verify() (bool, error) {
 	return res, nil
 }
 
+// Validate checks the integrity of this Population, i.e., that every organism belongs to one of the population species,
+// every species organism is in the population, the genome IDs are unique, and innovation counters are above any
+// node ID and innovation number found in the genomes. Returns error describing the first inconsistency found.
+func (p *Population) Validate() error {
+	species := make(map[*Species]bool)
+	for _, sp := range p.Species {
+		species[sp] = true
+	}
+	organisms := make(map[*Organism]bool)
+	genome_ids := make(map[int]bool)
+	max_node_id, max_innov_num := int32(-1), int64(-1)
+	for _, org := range p.Organisms {
+		if org.Species == nil {
+			return errors.New(fmt.Sprintf("POPULATION: The organism with genome [%d] has no species", org.Genotype.Id))
+		}
+		if !species[org.Species] {
+			return errors.New(fmt.Sprintf("POPULATION: The species [%d] of organism with genome [%d] is not in population",
+				org.Species.Id, org.Genotype.Id))
+		}
+		if genome_ids[org.Genotype.Id] {
+			return errors.New(fmt.Sprintf("POPULATION: Duplicate genome ID found: %d", org.Genotype.Id))
+		}
+		genome_ids[org.Genotype.Id] = true
+		organisms[org] = true
+
+		for _, n := range org.Genotype.Nodes {
+			if int32(n.Id) > max_node_id {
+				max_node_id = int32(n.Id)
+			}
+		}
+		for _, gn := range org.Genotype.Genes {
+			if gn.InnovationNum > max_innov_num {
+				max_innov_num = gn.InnovationNum
+			}
+		}
+		for _, cg := range org.Genotype.ControlGenes {
+			if int32(cg.ControlNode.Id) > max_node_id {
+				max_node_id = int32(cg.ControlNode.Id)
+			}
+			if cg.InnovationNum > max_innov_num {
+				max_innov_num = cg.InnovationNum
+			}
+		}
+	}
+	for _, sp := range p.Species {
+		for _, org := range sp.Organisms {
+			if !organisms[org] {
+				return errors.New(fmt.Sprintf("POPULATION: The organism with genome [%d] of species [%d] is not in population",
+					org.Genotype.Id, sp.Id))
+			}
+		}
+	}
+	if p.nextNodeId <= max_node_id {
+		return errors.New(fmt.Sprintf("POPULATION: The next node ID: %d is not above maximal node ID found: %d",
+			p.nextNodeId, max_node_id))
+	}
+	if p.nextInnovNum <= max_innov_num {
+		return errors.New(fmt.Sprintf("POPULATION: The next innovation number: %d is not above maximal innovation number found: %d",
+			p.nextInnovNum, max_innov_num))
+	}
+	return nil
+}
+
 // Default private constructor
 func newPopulation() *Population {
 	return &Population{
 		WinnerGen:0,
-		HighestFitness:0.0,
+		// the fitness may be negative when context.AllowNegativeFitness is set
+		HighestFitness:-math.MaxFloat64,
 		EpochsHighestLastChanged:0,
 		Species:make([]*Species, 0),
 		Organisms:make([]*Organism, 0),
@@ -229,12 +1103,21 @@ func (p *Population) getNextNodeIdAndIncrement() int32 {
 func (p *Population) addInnovationSynced(i *Innovation) {
 	p.mutex.Lock()
 	p.Innovations = append(p.Innovations, i)
+	p.InnovationsCreated++
+	p.mutex.Unlock()
+}
+
+// Records that structural mutation reused already existing innovation in thread safe manner
+func (p *Population) innovationReusedSynced() {
+	p.mutex.Lock()
+	p.InnovationsReused++
 	p.mutex.Unlock()
 }
 
 // Create a population of size size off of Genome g. The new Population will have the same topology as g
 // with link weights slightly perturbed from g's
 func (p *Population) spawn(g *Genome, context *neat.NeatContext) (err error) {
+	p.seedGenome = g
 	for count := 0; count < context.PopSize; count++ {
 		// make genome duplicate for new organism
 		new_genome, err := g.duplicate(count)
@@ -242,7 +1125,7 @@ func (p *Population) spawn(g *Genome, context *neat.NeatContext) (err error) {
 			return err
 		}
 		// introduce initial mutations
-		if _, err = new_genome.mutateLinkWeights(1.0, 1.0, gaussianMutator); err != nil {
+		if _, err = new_genome.mutateLinkWeights(1.0, 1.0, context.WeightCap, 1.0, gaussianMutator); err != nil {
 			return err
 		}
 		// create organism for new genome
@@ -292,6 +1175,29 @@ func (p *Population) checkBestSpeciesAlive(best_species_id int, best_species_rep
 	return nil
 }
 
+// Sorts species of this population by original fitness of their champions, the most fit species goes first.
+// The organisms of each species are sorted by fitness beforehand to keep the champion at Organisms[0].
+func (p *Population) SortSpecies() {
+	for _, sp := range p.Species {
+		sp.SortByFitness()
+	}
+	sort.Sort(sort.Reverse(byOrganismOrigFitness(p.Species)))
+}
+
+// Adjusts compatibility threshold of context by given step to keep the number of species close to the target:
+// the threshold is increased when there are too many species and decreased when there are too few of them. The
+// adjusted threshold is kept within context.CompatThresholdMin and context.CompatThresholdMax bounds.
+func (p *Population) AdjustCompatThreshold(target_species int, step float64, context *neat.NeatContext) {
+	if len(p.Species) > target_species {
+		context.CompatThreshold += step
+	} else if len(p.Species) < target_species {
+		context.CompatThreshold -= step
+	}
+	context.CompatThreshold = context.EffectiveCompatThreshold()
+	neat.DebugLog(fmt.Sprintf("POPULATION: Compatibility threshold adjusted to: %f, species: %d, target: %d",
+		context.CompatThreshold, len(p.Species), target_species))
+}
+
 // Speciate separates given organisms into species of this population by checking compatibilities against a threshold.
 // Any organism that does is not compatible with the first organism in any existing species becomes a new species.
 func (p *Population) speciate(organisms []*Organism, context *neat.NeatContext) error {
@@ -299,32 +1205,47 @@ func (p *Population) speciate(organisms []*Organism, context *neat.NeatContext)
 		return errors.New("There is no organisms to speciate from")
 	}
 
+	compat_threshold := context.EffectiveCompatThreshold()
 	// Step through all given organisms and speciate them within the population
 	for _, curr_org := range organisms {
 		if len(p.Species) == 0 {
 			// Create the first species
 			createFirstSpecies(p, curr_org)
 		} else {
-			if context.CompatThreshold == 0 {
-				return errors.New("POPULATION: compatibility thershold is set to ZERO. " +
-					"Will not find any compatible species.")
+			if compat_threshold <= 0 {
+				return newReproductionError(ZeroCompatThresholdReproductionError,
+					errors.New("POPULATION: compatibility thershold is set to ZERO. " +
+						"Will not find any compatible species."))
 			}
 			// For each organism, search for a species it is compatible to
 			done := false
 			var best_compatible *Species // the best compatible species
 			best_compat_value := math.MaxFloat64
+			var nearest *Species // the least incompatible species
+			nearest_value := math.MaxFloat64
 			for _, curr_species := range p.Species {
 				comp_org := curr_species.firstOrganism()
 				// compare current organism with first organism in current specie
 				if comp_org != nil {
+					p.compatChecks++
 					curr_compat := curr_org.Genotype.compatibility(comp_org.Genotype, context)
-					if curr_compat < context.CompatThreshold && curr_compat < best_compat_value {
+					if curr_compat < compat_threshold && curr_compat < best_compat_value {
 						best_compatible = curr_species
 						best_compat_value = curr_compat
 						done = true
 					}
+					if curr_compat < nearest_value {
+						nearest = curr_species
+						nearest_value = curr_compat
+					}
 				}
 			}
+			if !done && nearest != nil && context.MaxSpecies > 0 && len(p.Species) >= context.MaxSpecies {
+				neat.DebugLog(fmt.Sprintf("POPULATION: Maximal number of species reached, baby organism [%d] joins the nearest species [%d]",
+					curr_org.Genotype.Id, nearest.Id))
+				best_compatible = nearest
+				done = true
+			}
 			if done {
 				neat.DebugLog(fmt.Sprintf("POPULATION: Compatible species [%d] found for baby organism [%d]",
 					best_compatible.Id, curr_org.Genotype.Id))
@@ -332,6 +1253,11 @@ func (p *Population) speciate(organisms []*Organism, context *neat.NeatContext)
 				best_compatible.addOrganism(curr_org);
 				// Point organism to its species
 				curr_org.Species = best_compatible
+				curr_org.representative = best_compatible.firstOrganism()
+				if curr_org.parentSpecies == best_compatible {
+					// The baby was speciated back into its parent species
+					best_compatible.OffspringRetained++
+				}
 			} else {
 				// If we didn't find a match, create a new species
 				createFirstSpecies(p, curr_org)
@@ -342,22 +1268,40 @@ func (p *Population) speciate(organisms []*Organism, context *neat.NeatContext)
 	return nil
 }
 
-// Removes zero offspring species from this population, i.e. species which will not have any offspring organism belonging to it
-// after reproduction cycle due to its fitness stagnation
-func (p *Population) purgeZeroOffspringSpecies(generation int) {
+// Returns the shift to be added to the fitness of all organisms to make it positive, if context.AllowNegativeFitness
+// is set and the minimal fitness in population is negative. Otherwise, returns zero.
+func (p *Population) negativeFitnessShift(context *neat.NeatContext) float64 {
+	if !context.AllowNegativeFitness || len(p.Organisms) == 0 {
+		return 0
+	}
+	min_fitness := math.MaxFloat64
+	for _, org := range p.Organisms {
+		min_fitness = math.Min(min_fitness, org.Fitness)
+	}
+	if min_fitness >= 0 {
+		return 0
+	}
+	// the least fit organism gets the same fitness as clamped negative ones
+	return math.Max(context.MinAdjustedFitness, 0.0) - min_fitness
+}
+
+// Computes the number of offspring expected for each organism as its adjusted fitness divided by the average adjusted
+// fitness of all organisms in population. After that the expected offspring of each species are aggregated from its organisms
+// with fractional parts skimmed across species. Returns the total number of expected offspring in population which
+// can be less than population size due to skim rounding.
+func (p *Population) ComputeOffspringAllocation(context *neat.NeatContext) int {
 	// Used to compute average fitness over all Organisms
 	total := 0.0
-	total_organisms := len(p.Organisms)
 
 	// Go through the organisms and add up their fitnesses to compute the overall average
 	for _, o := range p.Organisms {
 		total += o.Fitness
 	}
 	// The average modified fitness among ALL organisms
-	overall_average := total / float64(total_organisms)
+	overall_average := total / float64(len(p.Organisms))
 	neat.DebugLog(fmt.Sprintf(
-		"POPULATION: Generation %d: overall average fitness = %.3f, # of organisms: %d, # of species: %d\n",
-		generation, overall_average, len(p.Organisms), len(p.Species)))
+		"POPULATION: Overall average fitness = %.3f, # of organisms: %d, # of species: %d\n",
+		overall_average, len(p.Organisms), len(p.Species)))
 
 	// Now compute expected number of offspring for each individual organism
 	if overall_average != 0 {
@@ -377,6 +1321,16 @@ func (p *Population) purgeZeroOffspringSpecies(generation int) {
 		sp.ExpectedOffspring, skim = sp.countOffspring(skim)
 		total_expected += sp.ExpectedOffspring
 	}
+	return total_expected
+}
+
+// Removes zero offspring species from this population, i.e. species which will not have any offspring organism belonging to it
+// after reproduction cycle due to its fitness stagnation
+func (p *Population) purgeZeroOffspringSpecies(generation int, context *neat.NeatContext) {
+	total_organisms := len(p.Organisms)
+	neat.DebugLog(fmt.Sprintf("POPULATION: Generation %d: computing offspring allocation", generation))
+
+	total_expected := p.ComputeOffspringAllocation(context)
 	neat.DebugLog(fmt.Sprintf("POPULATION: Total expected offspring count: %d", total_expected))
 
 	// Need to make up for lost floating point precision in offspring assignment.
@@ -422,6 +1376,109 @@ func (p *Population) purgeZeroOffspringSpecies(generation int) {
 	p.Species = species_to_keep
 }
 
+// OffspringTotal returns the number of offspring expected from all species of this population
+func (p *Population) OffspringTotal() int {
+	total := 0
+	for _, sp := range p.Species {
+		total += sp.ExpectedOffspring
+	}
+	return total
+}
+
+// ReconcilePopSize adds or removes expected offspring of the species expecting the most until the total number
+// of offspring is exactly context.PopSize. It compensates population size drift due to fractional rounding
+// of offspring allocation.
+func (p *Population) ReconcilePopSize(context *neat.NeatContext) {
+	if len(p.Species) == 0 || context.PopSize <= 0 {
+		return
+	}
+	for diff := context.PopSize - p.OffspringTotal(); diff != 0; {
+		// Find the Species expecting the most
+		best_species := p.Species[0]
+		for _, sp := range p.Species {
+			if sp.ExpectedOffspring > best_species.ExpectedOffspring {
+				best_species = sp
+			}
+		}
+		if diff > 0 {
+			best_species.ExpectedOffspring++
+			diff--
+		} else {
+			best_species.ExpectedOffspring--
+			diff++
+		}
+	}
+	neat.DebugLog(fmt.Sprintf("POPULATION: Total expected offspring reconciled to: %d", context.PopSize))
+}
+
+// Limits the expected offspring of each species to context.MaxSpeciesOffspringFraction of population size. The clipped
+// offspring are given to other species below the limit starting from the best ones. The offspring which can not be
+// given to anyone are returned to the species they were clipped from, so the population size is preserved.
+func (p *Population) capSpeciesOffspring(sorted_species []*Species, context *neat.NeatContext) {
+	if context.MaxSpeciesOffspringFraction <= 0 || context.MaxSpeciesOffspringFraction >= 1.0 {
+		return
+	}
+	max_offspring := int(math.Floor(context.MaxSpeciesOffspringFraction * float64(context.PopSize)))
+	if max_offspring < 1 {
+		max_offspring = 1
+	}
+
+	clipped := make(map[*Species]int)
+	total_clipped := 0
+	for _, sp := range sorted_species {
+		if sp.ExpectedOffspring > max_offspring {
+			clipped[sp] = sp.ExpectedOffspring - max_offspring
+			total_clipped += clipped[sp]
+			sp.ExpectedOffspring = max_offspring
+			neat.DebugLog(fmt.Sprintf("POPULATION: Expected offspring of species [%d] capped at %d",
+				sp.Id, max_offspring))
+		}
+	}
+
+	// give clipped offspring to other species
+	for given := true; total_clipped > 0 && given; {
+		given = false
+		for _, sp := range sorted_species {
+			if total_clipped > 0 && sp.ExpectedOffspring < max_offspring && len(sp.Organisms) > 0 {
+				sp.ExpectedOffspring++
+				total_clipped--
+				given = true
+			}
+		}
+	}
+
+	// return the rest back
+	for _, sp := range sorted_species {
+		if total_clipped == 0 {
+			break
+		}
+		if back, ok := clipped[sp]; ok {
+			if back > total_clipped {
+				back = total_clipped
+			}
+			sp.ExpectedOffspring += back
+			total_clipped -= back
+		}
+	}
+}
+
+// Reserves context.SuperChampOffspringCount of the best species offspring to be specially treated offspring of the
+// population champion, unless they were already reserved by delta coding or babies stealing.
+func (p *Population) assignSuperChampOffspring(sorted_species []*Species, context *neat.NeatContext) {
+	if context.SuperChampOffspringCount <= 0 || len(sorted_species) == 0 || len(sorted_species[0].Organisms) == 0 {
+		return
+	}
+	best_species := sorted_species[0]
+	champ := best_species.Organisms[0]
+	if champ.superChampOffspring > 0 {
+		return
+	}
+	champ.superChampOffspring = context.SuperChampOffspringCount
+	if champ.superChampOffspring > best_species.ExpectedOffspring {
+		champ.superChampOffspring = best_species.ExpectedOffspring
+	}
+}
+
 // When population stagnation detected the delta coding will be performed in attempt to fix this
 func (p *Population) deltaCoding(sorted_species []*Species, context *neat.NeatContext) {
 	neat.DebugLog("POPULATION: PERFORMING DELTA CODING TO FIX STAGNATION")
@@ -574,12 +1631,6 @@ func (p *Population) purgeOrAgeSpecies() {
 	species_to_keep := make([]*Species, 0)
 	for _, curr_species := range p.Species {
 		if len(curr_species.Organisms) > 0 {
-			// Age surviving Species
-			if curr_species.IsNovel {
-				curr_species.IsNovel = false
-			} else {
-				curr_species.Age += 1
-			}
 			// Rebuild master Organism list of population: NUMBER THEM as they are added to the list
 			for _, curr_org := range curr_species.Organisms {
 				curr_org.Genotype.Id = org_count
@@ -593,8 +1644,9 @@ func (p *Population) purgeOrAgeSpecies() {
 				curr_species.Id))
 		}
 	}
-	// Keep only survived species
+	// Keep only survived species and age them
 	p.Species = species_to_keep
+	p.AdvanceGeneration()
 
 	neat.DebugLog(fmt.Sprintf("POPULATION: # of species survived: %d, # of organisms survived: %d\n",
 		len(p.Species), len(p.Organisms)))
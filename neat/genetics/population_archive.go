@@ -0,0 +1,265 @@
+package genetics
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"gopkg.in/yaml.v2"
+	"github.com/yaricom/goNEAT/neat"
+)
+
+// The names of population archive entries
+const (
+	// The NEAT context configuration
+	archiveContextEntry = "context.yml"
+	// The population state, innovations and species structure
+	archivePopulationEntry = "population.yml"
+	// The genomes of all organisms
+	archiveGenomesEntry = "genomes.yml"
+)
+
+// The archived population state
+type archivedPopulation struct {
+	NextInnovNum             int64 `yaml:"next_innov_num"`
+	NextNodeId               int32 `yaml:"next_node_id"`
+	LastSpecies              int `yaml:"last_species"`
+	Generation               int `yaml:"generation"`
+	WinnerGen                int `yaml:"winner_gen"`
+	FinalGen                 int `yaml:"final_gen"`
+	HighestFitness           float64 `yaml:"highest_fitness"`
+	EpochsHighestLastChanged int `yaml:"epochs_highest_last_changed"`
+	Phase                    int `yaml:"phase"`
+	Innovations              []archivedInnovation `yaml:"innovations"`
+	Species                  []archivedSpecies `yaml:"species"`
+}
+
+// The archived innovation record
+type archivedInnovation struct {
+	Type           int `yaml:"type"`
+	InNodeId       int `yaml:"in_node_id"`
+	OutNodeId      int `yaml:"out_node_id"`
+	InnovationNum  int64 `yaml:"innov_num"`
+	InnovationNum2 int64 `yaml:"innov_num2"`
+	NewWeight      float64 `yaml:"new_weight"`
+	NewTraitNum    int `yaml:"new_trait_num"`
+	NewNodeId      int `yaml:"new_node_id"`
+	OldInnovNum    int64 `yaml:"old_innov_num"`
+	IsRecurrent    bool `yaml:"recurrent"`
+}
+
+// The archived species with references to genomes of its organisms
+type archivedSpecies struct {
+	Id                   int `yaml:"id"`
+	Age                  int `yaml:"age"`
+	AgeOfLastImprovement int `yaml:"age_of_last_improvement"`
+	MaxFitnessEver       float64 `yaml:"max_fitness_ever"`
+	IsNovel              bool `yaml:"novel"`
+	MutateRateMultiplier float64 `yaml:"mutate_rate_multiplier,omitempty"`
+	Organisms            []archivedOrganism `yaml:"organisms"`
+}
+
+// The archived organism referencing its genome by ID
+type archivedOrganism struct {
+	GenomeId   int `yaml:"genome_id"`
+	Fitness    float64 `yaml:"fitness"`
+	Error      float64 `yaml:"error"`
+	IsWinner   bool `yaml:"winner"`
+	Generation int `yaml:"generation"`
+	Meta       map[string]string `yaml:"meta,omitempty"`
+	Behavior   []float64 `yaml:"behavior,omitempty"`
+}
+
+// Writes this population along with provided context into the single TAR archive. The archive holds context
+// configuration, innovation records and all genomes grouped by species, so it can be restored with ReadArchive.
+func (p *Population) WriteArchive(w io.Writer, context *neat.NeatContext) error {
+	// encode context
+	ctx_buf := bytes.NewBufferString("")
+	if err := context.WriteYAML(ctx_buf); err != nil {
+		return err
+	}
+
+	// encode population state and genomes
+	a_pop := archivedPopulation{
+		NextInnovNum:p.nextInnovNum,
+		NextNodeId:p.nextNodeId,
+		LastSpecies:p.LastSpecies,
+		Generation:p.Generation,
+		WinnerGen:p.WinnerGen,
+		FinalGen:p.FinalGen,
+		HighestFitness:p.HighestFitness,
+		EpochsHighestLastChanged:p.EpochsHighestLastChanged,
+		Phase:int(p.Phase),
+	}
+	for _, inn := range p.Innovations {
+		a_pop.Innovations = append(a_pop.Innovations, archivedInnovation{
+			Type:int(inn.innovationType),
+			InNodeId:inn.InNodeId,
+			OutNodeId:inn.OutNodeId,
+			InnovationNum:inn.InnovationNum,
+			InnovationNum2:inn.InnovationNum2,
+			NewWeight:inn.NewWeight,
+			NewTraitNum:inn.NewTraitNum,
+			NewNodeId:inn.NewNodeId,
+			OldInnovNum:inn.OldInnovNum,
+			IsRecurrent:inn.IsRecurrent,
+		})
+	}
+	gen_buf := bytes.NewBufferString("")
+	gen_writer, err := NewGenomeWriter(gen_buf, YAMLGenomeEncoding)
+	if err != nil {
+		return err
+	}
+	for _, sp := range p.Species {
+		a_sp := archivedSpecies{
+			Id:sp.Id,
+			Age:sp.Age,
+			AgeOfLastImprovement:sp.AgeOfLastImprovement,
+			MaxFitnessEver:sp.MaxFitnessEver,
+			IsNovel:sp.IsNovel,
+			MutateRateMultiplier:sp.MutateRateMultiplier,
+		}
+		for _, org := range sp.Organisms {
+			a_sp.Organisms = append(a_sp.Organisms, archivedOrganism{
+				GenomeId:org.Genotype.Id,
+				Fitness:org.Fitness,
+				Error:org.Error,
+				IsWinner:org.IsWinner,
+				Generation:org.Generation,
+				Meta:org.Meta,
+				Behavior:org.BehaviorVector,
+			})
+			if err = gen_writer.WriteGenome(org.Genotype); err != nil {
+				return err
+			}
+		}
+		a_pop.Species = append(a_pop.Species, a_sp)
+	}
+	pop_data, err := yaml.Marshal(a_pop)
+	if err != nil {
+		return err
+	}
+
+	// write archive entries
+	tw := tar.NewWriter(w)
+	entries := []struct {
+		name string
+		data []byte
+	}{
+		{archiveContextEntry, ctx_buf.Bytes()},
+		{archivePopulationEntry, pop_data},
+		{archiveGenomesEntry, gen_buf.Bytes()},
+	}
+	for _, e := range entries {
+		hdr := &tar.Header{Name:e.name, Mode:0600, Size:int64(len(e.data))}
+		if err = tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err = tw.Write(e.data); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+// Reads population and its context from the archive created by Population.WriteArchive
+func ReadArchive(r io.Reader) (*Population, *neat.NeatContext, error) {
+	entries := make(map[string][]byte)
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, nil, err
+		}
+		if entries[hdr.Name], err = ioutil.ReadAll(tr); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, name := range []string{archiveContextEntry, archivePopulationEntry, archiveGenomesEntry} {
+		if _, ok := entries[name]; !ok {
+			return nil, nil, errors.New(fmt.Sprintf("Population archive entry not found: %s", name))
+		}
+	}
+
+	// read context
+	context := neat.NewNeatContext()
+	if err := context.LoadContext(bytes.NewReader(entries[archiveContextEntry])); err != nil {
+		return nil, nil, err
+	}
+
+	// read genomes
+	genomes, err := ReadGenomes(bytes.NewReader(entries[archiveGenomesEntry]), YAMLGenomeEncoding)
+	if err != nil {
+		return nil, nil, err
+	}
+	genomes_by_id := make(map[int]*Genome, len(genomes))
+	for _, g := range genomes {
+		if _, ok := genomes_by_id[g.Id]; ok {
+			return nil, nil, errors.New(fmt.Sprintf("Duplicate genome ID in population archive: %d", g.Id))
+		}
+		genomes_by_id[g.Id] = g
+	}
+
+	// read population state
+	a_pop := archivedPopulation{}
+	if err = yaml.Unmarshal(entries[archivePopulationEntry], &a_pop); err != nil {
+		return nil, nil, err
+	}
+	pop := newPopulation()
+	pop.nextInnovNum = a_pop.NextInnovNum
+	pop.nextNodeId = a_pop.NextNodeId
+	pop.LastSpecies = a_pop.LastSpecies
+	pop.Generation = a_pop.Generation
+	pop.WinnerGen = a_pop.WinnerGen
+	pop.FinalGen = a_pop.FinalGen
+	pop.HighestFitness = a_pop.HighestFitness
+	pop.EpochsHighestLastChanged = a_pop.EpochsHighestLastChanged
+	pop.Phase = SearchPhase(a_pop.Phase)
+	for _, a_inn := range a_pop.Innovations {
+		pop.Innovations = append(pop.Innovations, &Innovation{
+			innovationType:innovationType(a_inn.Type),
+			InNodeId:a_inn.InNodeId,
+			OutNodeId:a_inn.OutNodeId,
+			InnovationNum:a_inn.InnovationNum,
+			InnovationNum2:a_inn.InnovationNum2,
+			NewWeight:a_inn.NewWeight,
+			NewTraitNum:a_inn.NewTraitNum,
+			NewNodeId:a_inn.NewNodeId,
+			OldInnovNum:a_inn.OldInnovNum,
+			IsRecurrent:a_inn.IsRecurrent,
+		})
+	}
+	for _, a_sp := range a_pop.Species {
+		sp := NewSpeciesNovel(a_sp.Id, a_sp.IsNovel)
+		sp.Age = a_sp.Age
+		sp.AgeOfLastImprovement = a_sp.AgeOfLastImprovement
+		sp.MaxFitnessEver = a_sp.MaxFitnessEver
+		if a_sp.MutateRateMultiplier > 0 {
+			sp.MutateRateMultiplier = a_sp.MutateRateMultiplier
+		}
+		for _, a_org := range a_sp.Organisms {
+			g, ok := genomes_by_id[a_org.GenomeId]
+			if !ok {
+				return nil, nil, errors.New(
+					fmt.Sprintf("Genome [%d] of species [%d] not found in population archive", a_org.GenomeId, a_sp.Id))
+			}
+			org, err := NewOrganism(a_org.Fitness, g, a_org.Generation)
+			if err != nil {
+				return nil, nil, err
+			}
+			org.Error = a_org.Error
+			org.IsWinner = a_org.IsWinner
+			org.Meta = a_org.Meta
+			org.BehaviorVector = a_org.Behavior
+			org.Species = sp
+			sp.addOrganism(org)
+			pop.Organisms = append(pop.Organisms, org)
+		}
+		pop.Species = append(pop.Species, sp)
+	}
+	return pop, context, nil
+}
@@ -0,0 +1,131 @@
+package genetics
+
+import (
+	"testing"
+	"bytes"
+	"math/rand"
+	"reflect"
+	"github.com/yaricom/goNEAT/neat"
+)
+
+func TestPopulation_WriteArchive(t *testing.T) {
+	rand.Seed(42)
+	in, out, nmax, n := 3, 2, 15, 3
+	conf := neat.NewNeatContext()
+	conf.CompatThreshold = 0.5
+	conf.PopSize = 20
+	conf.DropOffAge = 15
+	conf.MutateAddLinkProb = 0.1
+	conf.WeightMutPower = 2.5
+	gen := newGenomeRand(1, in, out, n, nmax, false, 0.8)
+	pop, err := NewPopulation(gen, conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for _, org := range pop.Organisms {
+		org.Fitness = rand.Float64()
+	}
+	pop.Organisms[0].Meta = map[string]string{"experiment":"A"}
+	pop.Species[0].MutateRateMultiplier = 1.21
+	pop.Generation = 7
+	pop.Innovations = append(pop.Innovations, NewInnovationForNode(1, 4, 10, 11, 20, 2))
+
+	buf := bytes.NewBufferString("")
+	if err = pop.WriteArchive(buf, conf); err != nil {
+		t.Error(err)
+		return
+	}
+
+	r_pop, r_conf, err := ReadArchive(buf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !reflect.DeepEqual(conf, r_conf) {
+		t.Errorf("Contexts are not equal after archive round-trip\n%v\n%v", conf, r_conf)
+	}
+	if r_pop.Generation != pop.Generation || r_pop.nextInnovNum != pop.nextInnovNum || r_pop.nextNodeId != pop.nextNodeId {
+		t.Error("Population state not restored", r_pop.Generation, r_pop.nextInnovNum, r_pop.nextNodeId)
+	}
+	if len(r_pop.Innovations) != 1 || *r_pop.Innovations[0] != *pop.Innovations[0] {
+		t.Error("Innovations not restored", r_pop.Innovations)
+	}
+	if len(r_pop.Organisms) != len(pop.Organisms) {
+		t.Error("len(r_pop.Organisms) != len(pop.Organisms)", len(r_pop.Organisms), len(pop.Organisms))
+	}
+	if len(r_pop.Species) != len(pop.Species) {
+		t.Error("len(r_pop.Species) != len(pop.Species)", len(r_pop.Species), len(pop.Species))
+		return
+	}
+	for i, sp := range pop.Species {
+		r_sp := r_pop.Species[i]
+		if r_sp.Id != sp.Id || len(r_sp.Organisms) != len(sp.Organisms) {
+			t.Error("Species not restored", sp.Id, r_sp.Id, len(sp.Organisms), len(r_sp.Organisms))
+			continue
+		}
+		if r_sp.MutateRateMultiplier != sp.MutateRateMultiplier {
+			t.Error("r_sp.MutateRateMultiplier != sp.MutateRateMultiplier", r_sp.MutateRateMultiplier, sp.MutateRateMultiplier)
+		}
+		for j, org := range sp.Organisms {
+			r_org := r_sp.Organisms[j]
+			if r_org.Fitness != org.Fitness || r_org.Species != r_sp {
+				t.Error("Organism not restored", org.Fitness, r_org.Fitness)
+			}
+			if !reflect.DeepEqual(r_org.Meta, org.Meta) {
+				t.Error("Organism metadata not restored", org.Meta, r_org.Meta)
+			}
+			if eq, err := org.Genotype.IsEqual(r_org.Genotype); !eq {
+				t.Error("Genome not restored", err)
+			}
+		}
+	}
+}
+
+func TestReadArchive_behaviorVector(t *testing.T) {
+	rand.Seed(42)
+	conf := neat.NewNeatContext()
+	conf.CompatThreshold = 0.5
+	conf.PopSize = 5
+	gen := newGenomeRand(1, 3, 2, 3, 15, false, 0.8)
+	pop, err := NewPopulation(gen, conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	behavior := []float64{0.1, 2.5, -3.75}
+	org := pop.Species[0].Organisms[0]
+	org.Fitness = 1.5
+	org.BehaviorVector = behavior
+
+	buf := bytes.NewBufferString("")
+	if err = pop.WriteArchive(buf, conf); err != nil {
+		t.Error(err)
+		return
+	}
+	r_pop, _, err := ReadArchive(buf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	r_org := r_pop.Species[0].Organisms[0]
+	if r_org.Fitness != org.Fitness {
+		t.Error("r_org.Fitness != org.Fitness", r_org.Fitness, org.Fitness)
+	}
+	if len(r_org.BehaviorVector) != len(behavior) {
+		t.Error("len(r_org.BehaviorVector) != len(behavior)", len(r_org.BehaviorVector), len(behavior))
+		return
+	}
+	for i, v := range behavior {
+		if r_org.BehaviorVector[i] != v {
+			t.Error("Behavior vector element mismatch", i, r_org.BehaviorVector[i], v)
+		}
+	}
+	for _, sp := range r_pop.Species {
+		for _, o := range sp.Organisms {
+			if o != r_org && o.BehaviorVector != nil {
+				t.Error("Unexpected behavior vector", o.BehaviorVector)
+			}
+		}
+	}
+}
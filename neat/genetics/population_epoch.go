@@ -30,6 +30,8 @@ type SequentialPopulationEpochExecutor struct {
 	sorted_species          []*Species
 	best_species_reproduced bool
 	best_species_id         int
+	// the global elites cloned into the next generation
+	elites                  []*Organism
 }
 
 func (ex *SequentialPopulationEpochExecutor) NextEpoch(generation int, population *Population, context *neat.NeatContext) error {
@@ -51,17 +53,36 @@ func (ex *SequentialPopulationEpochExecutor) NextEpoch(generation int, populatio
 func (ex *SequentialPopulationEpochExecutor) prepare(generation int, p *Population, context *neat.NeatContext) error {
 	// clear executor state from previous run
 	ex.sorted_species = nil
+	ex.elites = nil
+	// reset innovation statistics of previous generation
+	p.InnovationsReused, p.InnovationsCreated = 0, 0
+
+	// Check whether the phase of phased search should be changed
+	p.updateSearchPhase(context)
+
+	// Assign fitness by Pareto fronts of multiple objectives
+	if context.MultiObjective {
+		if err := p.assignParetoFitness(); err != nil {
+			return err
+		}
+	}
 
 	// Use Species' ages to modify the objective fitness of organisms in other words, make it more fair for younger
 	// species so they have a chance to take hold and also penalize stagnant species. Then adjust the fitness using
 	// the species size to "share" fitness within a species. Then, within each Species, mark for death those below
 	// survival_thresh * average
+	fitness_shift := p.negativeFitnessShift(context)
 	for _, sp := range p.Species {
-		sp.adjustFitness(context)
+		sp.adjustFitnessShifted(context, fitness_shift)
 	}
 
 	// find and remove species unable to produce offspring due to fitness stagnation
-	p.purgeZeroOffspringSpecies(generation)
+	p.purgeZeroOffspringSpecies(generation, context)
+
+	// Make total number of offspring exactly the population size
+	if context.ReconcilePopSize {
+		p.ReconcilePopSize(context)
+	}
 
 	// Stick the Species pointers into a new Species list for sorting
 	ex.sorted_species = make([]*Species, len(p.Species))
@@ -108,8 +129,28 @@ func (ex *SequentialPopulationEpochExecutor) prepare(generation int, p *Populati
 		p.giveBabiesToTheBest(ex.sorted_species, context)
 	}
 
+	// Limit the offspring of dominating species
+	p.capSpeciesOffspring(ex.sorted_species, context)
+
+	// Reserve the specially treated offspring for the population champion
+	p.assignSuperChampOffspring(ex.sorted_species, context)
+
+	// Clone the global elites into the next generation taking their places from the species offspring budgets
+	var err error
+	if ex.elites, err = p.PreserveGlobalElites(generation, context); err != nil {
+		return err
+	}
+
+	// Re-seed species stagnant for too long, their evaluated champions are kept and the fresh variants of the seed
+	// genome reproduce within offspring budgets already allocated
+	if context.RestartStagnant {
+		if err = p.RestartStagnantSpecies(context); err != nil {
+			return err
+		}
+	}
+
 	// Kill off all Organisms marked for death. The remainder will be allowed to reproduce.
-	err := p.purgeOrganisms()
+	err = p.purgeOrganisms()
 	return err
 }
 
@@ -119,6 +160,7 @@ func (ex *SequentialPopulationEpochExecutor) reproduce(generation int, p *Popula
 
 	// Perform reproduction. Reproduction is done on a per-Species basis
 	babies := make([]*Organism, 0)
+	babies = append(babies, ex.elites...)
 
 	for _, sp := range p.Species {
 		rep_babies, err := sp.reproduce(generation, p, ex.sorted_species, context)
@@ -257,10 +299,19 @@ func (ex *ParallelPopulationEpochExecutor) reproduce(generation int, p *Populati
 
 	// read reproduction results, instantiate progeny and speciate over population
 	babies := make([]*Organism, 0)
+	babies = append(babies, ex.sequential.elites...)
 	for result := range res_chan {
 		if result.err != nil {
 			return result.err
 		}
+		// find parent species of the babies
+		var parent_species *Species
+		for _, sp := range p.Species {
+			if sp.Id == result.species_id {
+				parent_species = sp
+				break
+			}
+		}
 		// read baby genome
 		dec := gob.NewDecoder(bytes.NewBuffer(result.babies))
 		for i := 0; i < result.babies_stored; i++ {
@@ -270,6 +321,7 @@ func (ex *ParallelPopulationEpochExecutor) reproduce(generation int, p *Populati
 				return errors.New(
 					fmt.Sprintf("POPULATION: Failed to decode baby organism, reason: %s", err))
 			}
+			org.parentSpecies = parent_species
 			babies = append(babies, &org)
 		}
 		if result.species_id == ex.sequential.best_species_id {
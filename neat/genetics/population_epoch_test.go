@@ -4,6 +4,7 @@ import (
 	"testing"
 	"github.com/yaricom/goNEAT/neat"
 	"math/rand"
+	"bytes"
 )
 
 func runSequentialPopulationEpochExecutor_NextEpoch(pop *Population, conf *neat.NeatContext) error {
@@ -65,3 +66,257 @@ func TestPopulationEpochExecutor_NextEpoch(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestSequentialPopulationEpochExecutor_NextEpoch_elitism(t *testing.T) {
+	rand.Seed(42)
+	in, out, nmax, n := 3, 2, 15, 3
+	conf := neat.NewNeatContext()
+	conf.CompatThreshold = 0.5
+	conf.DropOffAge = 15
+	conf.PopSize = 30
+	conf.PopulationElitism = 1
+	conf.SurvivalThresh = 0.2
+	conf.MutateAddLinkProb = 0.5
+	conf.MutateAddNodeProb = 0.5
+	conf.MutateLinkWeightsProb = 0.9
+	conf.WeightMutPower = 2.5
+	conf.RecurOnlyProb = 0.2
+	neat.LogLevel = neat.LogLevelInfo
+	gen := newGenomeRand(1, in, out, n, nmax, false, 0.8)
+	pop, err := NewPopulation(gen, conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	// evaluate organisms and find the global champion
+	var champ *Organism
+	for _, org := range pop.Organisms {
+		org.Fitness = rand.Float64()
+		if champ == nil || org.Fitness > champ.Fitness {
+			champ = org
+		}
+	}
+	champ_genome, err := champ.Genotype.duplicate(champ.Genotype.Id)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	ex := SequentialPopulationEpochExecutor{}
+	if err = ex.NextEpoch(1, pop, conf); err != nil {
+		t.Error(err)
+		return
+	}
+	if len(pop.Organisms) != conf.PopSize {
+		t.Error("len(pop.Organisms) != conf.PopSize", len(pop.Organisms), conf.PopSize)
+	}
+
+	found := false
+	for _, org := range pop.Organisms {
+		// genomes are renumbered with new generation
+		champ_genome.Id = org.Genotype.Id
+		var champ_buf, buf bytes.Buffer
+		if err = champ_genome.Write(&champ_buf); err != nil {
+			t.Error(err)
+			return
+		}
+		if err = org.Genotype.Write(&buf); err != nil {
+			t.Error(err)
+			return
+		}
+		if champ_buf.String() == buf.String() {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Error("The global champion genome not found in the next generation")
+	}
+}
+
+func TestSequentialPopulationEpochExecutor_NextEpoch_weightsOnly(t *testing.T) {
+	rand.Seed(42)
+	in, out, nmax, n := 3, 2, 15, 3
+	conf := neat.NewNeatContext()
+	conf.CompatThreshold = 0.5
+	conf.DropOffAge = 15
+	conf.PopSize = 30
+	conf.BabiesStolen = 10
+	conf.MutateAddLinkProb = 0.5
+	conf.MutateAddNodeProb = 0.5
+	conf.MutateConnectSensors = 0.5
+	conf.MutateLinkWeightsProb = 0.9
+	conf.WeightMutPower = 2.5
+	conf.RecurOnlyProb = 0.2
+	conf.MutateRemoveLinkProb = 0.5
+	conf.WeightsOnly = true
+	neat.LogLevel = neat.LogLevelInfo
+	gen := newGenomeRand(1, in, out, n, nmax, false, 0.8)
+	pop, err := NewPopulation(gen, conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	ex := SequentialPopulationEpochExecutor{}
+	for i := 0; i < 10; i++ {
+		for _, org := range pop.Organisms {
+			org.Fitness = rand.Float64()
+		}
+		if err = ex.NextEpoch(i + 1, pop, conf); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+
+	for _, org := range pop.Organisms {
+		if len(org.Genotype.Nodes) != len(gen.Nodes) {
+			t.Error("len(org.Genotype.Nodes) != len(gen.Nodes)", len(org.Genotype.Nodes), len(gen.Nodes))
+		}
+		if len(org.Genotype.Genes) != len(gen.Genes) {
+			t.Error("len(org.Genotype.Genes) != len(gen.Genes)", len(org.Genotype.Genes), len(gen.Genes))
+		}
+		if org.Genotype.Extrons() != gen.Extrons() {
+			t.Error("org.Genotype.Extrons() != gen.Extrons()", org.Genotype.Extrons(), gen.Extrons())
+		}
+	}
+}
+
+func TestPopulation_ReproductionBreakdown(t *testing.T) {
+	rand.Seed(42)
+	in, out, nmax, n := 3, 2, 15, 3
+	conf := neat.NeatContext{
+		CompatThreshold:0.5,
+		DropOffAge:1,
+		PopSize: 30,
+		BabiesStolen:10,
+		RecurOnlyProb:0.2,
+		MutateOnlyProb:0.25,
+		MateMultipointProb:1.0,
+	}
+	gen := newGenomeRand(1, in, out, n, nmax, false, 0.8)
+	pop, err := NewPopulation(gen, &conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if breakdown := pop.ReproductionBreakdown(); breakdown[initialOrigin] != conf.PopSize {
+		t.Error("breakdown[initialOrigin] != conf.PopSize", breakdown)
+	}
+
+	for _, ex := range []PopulationEpochExecutor{&SequentialPopulationEpochExecutor{}, &ParallelPopulationEpochExecutor{}} {
+		for _, org := range pop.Organisms {
+			org.Fitness = rand.Float64()
+		}
+		if err = ex.NextEpoch(pop.Generation + 1, pop, &conf); err != nil {
+			t.Error(err)
+			return
+		}
+		breakdown := pop.ReproductionBreakdown()
+		total := 0
+		for origin, count := range breakdown {
+			switch origin {
+			case superChampOrigin, cloneOrigin, mutateOrigin, mateOrigin, eliteOrigin:
+			default:
+				t.Error("Unexpected offspring origin", origin)
+			}
+			total += count
+		}
+		if total != len(pop.Organisms) {
+			t.Error("total != len(pop.Organisms)", total, len(pop.Organisms))
+		}
+		if breakdown[initialOrigin] != 0 {
+			t.Error("Organisms of initial population found in the next generation", breakdown)
+		}
+	}
+}
+
+func TestSequentialPopulationEpochExecutor_NextEpoch_negativeFitness(t *testing.T) {
+	rand.Seed(42)
+	in, out, nmax, n := 3, 2, 15, 3
+	conf := neat.NewNeatContext()
+	conf.CompatThreshold = 100.0
+	conf.DropOffAge = 15
+	conf.PopSize = 20
+	conf.AgeSignificance = 1.0
+	conf.SurvivalThresh = 0.2
+	conf.AllowNegativeFitness = true
+	gen := newGenomeRand(1, in, out, n, nmax, false, 0.8)
+	pop, err := NewPopulation(gen, conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	ex := SequentialPopulationEpochExecutor{}
+	for generation := 1; generation <= 5; generation++ {
+		// the rising negative fitness
+		for i, org := range pop.Organisms {
+			org.Fitness = -100.0 + float64(generation * 10) + float64(i) * 0.1
+		}
+		if err = ex.NextEpoch(generation, pop, conf); err != nil {
+			t.Error(err)
+			return
+		}
+		if pop.HighestFitness >= 0 || pop.HighestFitness < -100.0 + float64(generation * 10) {
+			t.Error("The population fitness record is not updated", generation, pop.HighestFitness)
+		}
+		if pop.EpochsHighestLastChanged != 0 {
+			t.Error("pop.EpochsHighestLastChanged != 0", generation, pop.EpochsHighestLastChanged)
+		}
+	}
+}
+
+func TestSequentialPopulationEpochExecutor_NextEpoch_restartStagnant(t *testing.T) {
+	rand.Seed(42)
+	in, out, nmax, n := 3, 2, 15, 3
+	conf := neat.NewNeatContext()
+	conf.CompatThreshold = 100.0
+	conf.DropOffAge = 5
+	conf.PopSize = 20
+	conf.AgeSignificance = 1.0
+	conf.SurvivalThresh = 0.2
+	conf.RestartStagnant = true
+	gen := newGenomeRand(1, in, out, n, nmax, false, 0.8)
+	pop, err := NewPopulation(gen, conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for i, org := range pop.Organisms {
+		org.Fitness = float64(i + 1)
+	}
+	// make species stagnant
+	sp := pop.Species[0]
+	sp.Age = 10
+	sp.AgeOfLastImprovement = 1
+	sp.MaxFitnessEver = 100.0
+	champion := sp.FindChampion()
+
+	ex := SequentialPopulationEpochExecutor{}
+	if err = ex.NextEpoch(1, pop, conf); err != nil {
+		t.Error(err)
+		return
+	}
+	if sp.AgeOfLastImprovement != 10 {
+		t.Error("The stagnant species is not restarted", sp.AgeOfLastImprovement)
+	}
+	// the next generation is reproduced from the restarted species rather than replaced after reproduction
+	for _, org := range pop.Organisms {
+		if org.origin == restartOrigin {
+			t.Error("The organism of next generation is replaced by restart", org.Genotype.Id)
+		}
+	}
+	// the evaluated champion is cloned into the next generation
+	found := false
+	for _, org := range pop.Organisms {
+		if org.Genotype.FullHash() == champion.Genotype.FullHash() {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Error("The evaluated champion of restarted species is not kept")
+	}
+}
@@ -3,12 +3,108 @@ package genetics
 import (
 	"testing"
 	"github.com/yaricom/goNEAT/neat"
+	"github.com/yaricom/goNEAT/neat/network"
 	"math/rand"
 	"strings"
 	"bytes"
 	"bufio"
+	"time"
+	"errors"
+	"regexp"
+	"fmt"
+	"strconv"
 )
 
+func TestNewPopulationFromGenomes(t *testing.T) {
+	rand.Seed(42)
+	conf := neat.NeatContext{
+		CompatThreshold:0.5,
+		DisjointCoeff:0.5,
+		ExcessCoeff:0.5,
+		MutdiffCoeff:0.5,
+		PopSize:10,
+	}
+	gen1 := newGenomeRand(1, 3, 2, 3, 5, false, 0.5)
+	gen2 := newGenomeRand(2, 3, 2, 5, 5, false, 0.8)
+
+	pop, err := NewPopulationFromGenomes([]*Genome{gen1, gen2}, &conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(pop.Organisms) != conf.PopSize {
+		t.Error("len(pop.Organisms) != conf.PopSize", len(pop.Organisms))
+	}
+	if len(pop.Species) < 2 {
+		t.Error("len(pop.Species) < 2", len(pop.Species))
+	}
+	last_node_id, _ := gen2.getLastNodeId()
+	if pop.nextNodeId != int32(last_node_id + 1) {
+		t.Error("pop.nextNodeId != last_node_id + 1", pop.nextNodeId)
+	}
+	next_innov_num, _ := gen2.getNextGeneInnovNum()
+	if pop.nextInnovNum != next_innov_num {
+		t.Error("pop.nextInnovNum != next_innov_num", pop.nextInnovNum)
+	}
+	if ok, err := pop.Verify(); !ok {
+		t.Error(err)
+	}
+
+	// check wrong arguments
+	if _, err = NewPopulationFromGenomes([]*Genome{}, &conf); err == nil {
+		t.Error("err == nil for empty templates")
+	}
+	conf.PopSize = 1
+	if _, err = NewPopulationFromGenomes([]*Genome{gen1, gen2}, &conf); err == nil {
+		t.Error("err == nil for too small population size")
+	}
+}
+
+func TestPopulation_WriteMetrics(t *testing.T) {
+	rand.Seed(42)
+	conf := neat.NeatContext{
+		CompatThreshold:0.5,
+		PopSize:10,
+	}
+	pop, err := NewPopulationRandom(3, 2, 5, false, 0.5, &conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	pop.Organisms[2].Fitness = 12.5
+
+	out_buf := bytes.NewBufferString("")
+	if err = pop.WriteMetrics(out_buf, 7); err != nil {
+		t.Error(err)
+		return
+	}
+
+	line_re := regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*\{generation="7"\} [-+]?[0-9.eE+-]+$`)
+	metrics := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(out_buf.String()), "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !line_re.MatchString(line) {
+			t.Error("Wrong metric line:", line)
+			continue
+		}
+		fields := strings.Fields(line)
+		metrics[fields[0][:strings.Index(fields[0], "{")]] = fields[1]
+	}
+	expected := map[string]string{
+		"neat_species_count":fmt.Sprintf("%d", len(pop.Species)),
+		"neat_best_fitness":"12.5",
+		"neat_mean_complexity":strconv.FormatFloat(pop.MeanComplexity(), 'g', -1, 64),
+		"neat_organisms_total":"10",
+	}
+	for name, value := range expected {
+		if metrics[name] != value {
+			t.Error("Wrong metric value", name, value, metrics[name])
+		}
+	}
+}
+
 func TestNewPopulationRandom(t *testing.T) {
 	rand.Seed(42)
 	in, out, nmax := 3, 2, 5
@@ -55,6 +151,93 @@ func TestNewPopulationRandom(t *testing.T) {
 
 }
 
+func TestNewPopulationMinimal_biasNode(t *testing.T) {
+	rand.Seed(42)
+	in, out := 3, 2
+	conf := neat.NeatContext{
+		CompatThreshold:0.5,
+		PopSize:10,
+		WithBiasNode:true,
+	}
+	pop, err := NewPopulationMinimal(in, out, &conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(pop.Organisms) != conf.PopSize {
+		t.Error("len(pop.Organisms) != conf.PopSize", len(pop.Organisms))
+	}
+	if pop.nextNodeId != int32(in + out + 2) {
+		t.Error("pop.nextNodeId != in + out + 2", pop.nextNodeId)
+	}
+
+	for _, org := range pop.Organisms {
+		var bias *network.NNode
+		for _, n := range org.Genotype.Nodes {
+			if n.NeuronType == network.BiasNeuron {
+				bias = n
+			}
+		}
+		if bias == nil {
+			t.Error("Bias node not found in genome", org.Genotype.Id)
+			continue
+		}
+		connected := 0
+		for _, gn := range org.Genotype.Genes {
+			if gn.Link.InNode.Id == bias.Id && gn.Link.OutNode.NeuronType == network.OutputNeuron {
+				connected++
+			}
+		}
+		if connected != out {
+			t.Error("Bias node is not connected to every output", connected)
+		}
+	}
+}
+
+func TestNewPopulationMinimal_unconnected(t *testing.T) {
+	rand.Seed(42)
+	in, out := 3, 2
+	conf := neat.NewNeatContext()
+	conf.CompatThreshold = 3.0
+	conf.PopSize = 20
+	conf.DropOffAge = 15
+	conf.SurvivalThresh = 0.4
+	conf.MutateConnectSensors = 0.5
+	conf.MutateLinkWeightsProb = 0.9
+	conf.InitialConnectivity = neat.Unconnected
+	pop, err := NewPopulationMinimal(in, out, conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for _, org := range pop.Organisms {
+		if len(org.Genotype.Genes) != 0 {
+			t.Error("len(org.Genotype.Genes) != 0", len(org.Genotype.Genes))
+		}
+	}
+
+	// the connections should grow by structural mutations
+	ex := SequentialPopulationEpochExecutor{}
+	for generation := 1; generation <= 5; generation++ {
+		for _, org := range pop.Organisms {
+			org.Fitness = rand.Float64()
+		}
+		if err = ex.NextEpoch(generation, pop, conf); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+	connected := 0
+	for _, org := range pop.Organisms {
+		if len(org.Genotype.Genes) > 0 {
+			connected++
+		}
+	}
+	if connected == 0 {
+		t.Error("No connections grown in unconnected population")
+	}
+}
+
 func TestNewPopulation(t *testing.T) {
 	rand.Seed(42)
 	in, out, nmax, n := 3, 2, 5, 3
@@ -194,57 +377,1364 @@ func TestPopulation_verify(t *testing.T) {
 	}
 }
 
-func TestPopulation_Write(t *testing.T) {
-	// first create population
-	pop_str := "genomestart 1\n" +
-		"trait 1 0.1 0 0 0 0 0 0 0\n" +
-		"trait 2 0.2 0 0 0 0 0 0 0\n" +
-		"trait 3 0.3 0 0 0 0 0 0 0\n" +
-		"node 1 0 1 1\n" +
-		"node 2 0 1 1\n" +
-		"node 3 0 1 3\n" +
-		"node 4 0 0 2\n" +
-		"gene 1 1 4 1.5 false 1 0 true\n" +
-		"gene 2 2 4 2.5 false 2 0 true\n" +
-		"gene 3 3 4 3.5 false 3 0 true\n" +
-		"genomeend 1\n" +
-		"genomestart 2\n" +
-		"trait 1 0.1 0 0 0 0 0 0 0\n" +
-		"trait 2 0.2 0 0 0 0 0 0 0\n" +
-		"trait 3 0.3 0 0 0 0 0 0 0\n" +
-		"node 1 0 1 1\n" +
-		"node 2 0 1 1\n" +
-		"node 3 0 1 3\n" +
-		"node 4 0 0 2\n" +
-		"gene 1 1 4 1.5 false 1 0 true\n" +
-		"gene 2 2 4 2.5 false 2 0 true\n" +
-		"gene 3 3 4 3.5 false 3 0 true\n" +
-		"genomeend 2\n"
+func TestPopulation_Validate(t *testing.T) {
+	rand.Seed(42)
 	conf := neat.NeatContext{
 		CompatThreshold:0.5,
+		PopSize:10,
 	}
-	pop, err := ReadPopulation(strings.NewReader(pop_str), &conf)
+	gen := newGenomeRand(1, 3, 2, 3, 5, false, 0.5)
+	pop, err := NewPopulation(gen, &conf)
 	if err != nil {
 		t.Error(err)
 		return
 	}
-	if pop == nil {
-		t.Error("pop == nil")
+	if err = pop.Validate(); err != nil {
+		t.Error(err)
 		return
 	}
 
-	// write it again and test
-	out_buf := bytes.NewBufferString("")
-	pop.Write(out_buf)
+	// corrupt species pointer
+	org := pop.Organisms[2]
+	species := org.Species
+	org.Species = NewSpecies(100)
+	if err = pop.Validate(); err == nil {
+		t.Error("Corrupted species pointer not detected")
+	} else if !strings.Contains(err.Error(), "species [100]") {
+		t.Error("Unexpected error", err)
+	}
+	org.Species = species
 
-	_, p_str_r, err_p := bufio.ScanLines([]byte(pop_str), true)
-	_, o_str_r, err_o := bufio.ScanLines(out_buf.Bytes(), true)
-	if err_p != nil || err_o != nil {
-		t.Error("Failed to parse strings", err_o, err_p)
+	// stale innovation counter
+	pop.nextInnovNum = 0
+	if err = pop.Validate(); err == nil {
+		t.Error("Stale innovation counter not detected")
 	}
-	for i, gsr := range p_str_r {
-		if gsr != o_str_r[i] {
-			t.Error("Lines mismatch", gsr, o_str_r[i])
+}
+
+func TestPopulation_AdvanceGeneration(t *testing.T) {
+	rand.Seed(42)
+	conf := neat.NeatContext{
+		CompatThreshold:0.5,
+		PopSize:10,
+	}
+	gen := newGenomeRand(1, 3, 2, 3, 5, false, 0.5)
+	pop, err := NewPopulation(gen, &conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	ages := make([]int, len(pop.Species))
+	for i, sp := range pop.Species {
+		ages[i] = sp.Age
+		if sp.IsNovel {
+			// the novel species is not aged the first time
+			ages[i]--
+		}
+	}
+
+	pop.AdvanceGeneration()
+	pop.AdvanceGeneration()
+
+	if pop.Generation != 2 {
+		t.Error("pop.Generation != 2", pop.Generation)
+	}
+	for i, sp := range pop.Species {
+		if sp.Age != ages[i] + 2 {
+			t.Error("sp.Age != ages[i] + 2", sp.Age, ages[i])
+		}
+	}
+
+	// the novel species lose novelty instead of aging the first time
+	sp := pop.Species[0]
+	sp.IsNovel = true
+	age := sp.Age
+	pop.AdvanceGeneration()
+	if sp.IsNovel || sp.Age != age {
+		t.Error("Novel species aged", sp.IsNovel, sp.Age, age)
+	}
+	pop.AdvanceGeneration()
+	if sp.Age != age + 1 {
+		t.Error("sp.Age != age + 1", sp.Age, age)
+	}
+}
+
+func TestPopulation_EachOrganism(t *testing.T) {
+	pop := newPopulation()
+	for i := 0; i < 3; i++ {
+		sp, err := buildSpeciesWithOrganisms(i + 1)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		pop.Species = append(pop.Species, sp)
+		pop.Organisms = append(pop.Organisms, sp.Organisms...)
+	}
+
+	visits := make(map[*Organism]int)
+	pop.EachOrganism(func(org *Organism) bool {
+		visits[org]++
+		return true
+	})
+	if len(visits) != len(pop.Organisms) {
+		t.Error("len(visits) != len(pop.Organisms)", len(visits), len(pop.Organisms))
+	}
+	for _, org := range pop.Organisms {
+		if visits[org] != 1 {
+			t.Error("Organism must be visited exactly once", visits[org])
+		}
+	}
+
+	// stop early
+	count := 0
+	pop.EachOrganism(func(org *Organism) bool {
+		count++
+		return count < 4
+	})
+	if count != 4 {
+		t.Error("count != 4", count)
+	}
+
+	// reflects species membership
+	pop.Species = pop.Species[1:]
+	if all := pop.AllOrganisms(); len(all) != 6 {
+		t.Error("len(all) != 6", len(all))
+	}
+}
+
+func TestPopulation_ApplyMinimalCriteria(t *testing.T) {
+	pop := newPopulation()
+	for i := 0; i < 3; i++ {
+		sp, err := buildSpeciesWithOrganisms(i + 1)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		pop.Species = append(pop.Species, sp)
+		pop.Organisms = append(pop.Organisms, sp.Organisms...)
+	}
+	// the novelty scores assigned as fitness
+	novelty := make(map[*Organism]float64)
+	for _, org := range pop.Organisms {
+		novelty[org] = org.Fitness
+	}
+	// reject half of population
+	passed := make(map[*Organism]bool)
+	for i, org := range pop.Organisms {
+		passed[org] = i % 2 == 0
+	}
+
+	pop.ApplyMinimalCriteria(func(org *Organism) bool {
+		return passed[org]
+	})
+	for _, org := range pop.Organisms {
+		if passed[org] {
+			if org.toEliminate || org.Fitness != novelty[org] {
+				t.Error("Organism passed minimal criteria is changed", org.toEliminate, org.Fitness, novelty[org])
+			}
+		} else if !org.toEliminate || org.Fitness != 0.0 {
+			t.Error("Organism failed minimal criteria is not flagged", org.toEliminate, org.Fitness)
+		}
+	}
+}
+
+func TestPopulation_ApplyMinimalCriteria_allFailed(t *testing.T) {
+	rand.Seed(42)
+	in, out, nmax, n := 3, 2, 15, 3
+	conf := neat.NewNeatContext()
+	conf.CompatThreshold = 0.5
+	conf.DropOffAge = 15
+	conf.PopSize = 30
+	conf.SurvivalThresh = 0.2
+	gen := newGenomeRand(1, in, out, n, nmax, false, 0.8)
+	pop, err := NewPopulation(gen, conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for i, org := range pop.Organisms {
+		org.Fitness = float64(i + 1)
+	}
+
+	pop.ApplyMinimalCriteria(func(org *Organism) bool {
+		return false
+	})
+	for _, sp := range pop.Species {
+		kept := 0
+		for _, org := range sp.Organisms {
+			if org.Fitness != 0.0 {
+				t.Error("Failed organism fitness is not zeroed", org.Fitness)
+			}
+			if !org.toEliminate {
+				kept++
+			}
+		}
+		if kept != 1 {
+			t.Error("The champion of species is not kept", sp.Id, kept)
+		}
+	}
+
+	ex := SequentialPopulationEpochExecutor{}
+	if err = ex.NextEpoch(1, pop, conf); err != nil {
+		t.Error(err)
+		return
+	}
+	if len(pop.Organisms) != conf.PopSize {
+		t.Error("len(pop.Organisms) != conf.PopSize", len(pop.Organisms))
+	}
+}
+
+func TestPopulation_PreserveGlobalElites_superChampOffspring(t *testing.T) {
+	rand.Seed(42)
+	in, out, nmax, n := 3, 2, 15, 3
+	conf := neat.NewNeatContext()
+	conf.CompatThreshold = 100.0
+	conf.PopSize = 10
+	conf.PopulationElitism = 1
+	gen := newGenomeRand(1, in, out, n, nmax, false, 0.8)
+	pop, err := NewPopulation(gen, conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(pop.Species) != 1 {
+		t.Error("len(pop.Species) != 1", len(pop.Species))
+		return
+	}
+	sp := pop.Species[0]
+	for i, org := range sp.Organisms {
+		org.originalFitness = float64(len(sp.Organisms) - i)
+	}
+	sp.ExpectedOffspring = 3
+	sp.Organisms[0].superChampOffspring = 3
+
+	elites, err := pop.PreserveGlobalElites(1, conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(elites) != 1 {
+		t.Error("len(elites) != 1", len(elites))
+	}
+	if sp.ExpectedOffspring != 2 {
+		t.Error("sp.ExpectedOffspring != 2", sp.ExpectedOffspring)
+	}
+	if sp.Organisms[0].superChampOffspring != sp.ExpectedOffspring {
+		t.Error("superChampOffspring != ExpectedOffspring", sp.Organisms[0].superChampOffspring, sp.ExpectedOffspring)
+	}
+}
+
+func TestPopulation_RestartStagnantSpecies(t *testing.T) {
+	rand.Seed(42)
+	in, out, nmax, n := 3, 2, 15, 3
+	conf := neat.NewNeatContext()
+	conf.CompatThreshold = 100.0
+	conf.PopSize = 10
+	conf.DropOffAge = 5
+	gen := newGenomeRand(1, in, out, n, nmax, false, 0.8)
+	pop, err := NewPopulation(gen, conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(pop.Species) != 1 {
+		t.Error("len(pop.Species) != 1", len(pop.Species))
+		return
+	}
+	for i, org := range pop.Organisms {
+		org.Fitness = float64(i)
+	}
+	// make species stagnant
+	sp := pop.Species[0]
+	sp.Age = 10
+	sp.AgeOfLastImprovement = 1
+	champion := sp.FindChampion()
+	old_genomes := make(map[*Genome]bool)
+	for _, org := range sp.Organisms {
+		old_genomes[org.Genotype] = true
+	}
+
+	if err = pop.RestartStagnantSpecies(conf); err != nil {
+		t.Error(err)
+		return
+	}
+	if len(sp.Organisms) != conf.PopSize {
+		t.Error("len(sp.Organisms) != conf.PopSize", len(sp.Organisms))
+	}
+	for i, org := range sp.Organisms {
+		if org == champion {
+			continue
+		}
+		if old_genomes[org.Genotype] {
+			t.Error("Organism of stagnant species is not replaced", i)
+		}
+		if org.Species != sp {
+			t.Error("org.Species != sp", i)
+		}
+		if pop.Organisms[i] != org {
+			t.Error("Organism is not replaced in population", i)
+		}
+	}
+	if !old_genomes[champion.Genotype] || sp.Organisms[len(sp.Organisms) - 1] != champion {
+		t.Error("Champion of stagnant species is not kept")
+	}
+	if sp.AgeOfLastImprovement != sp.Age {
+		t.Error("sp.AgeOfLastImprovement != sp.Age", sp.AgeOfLastImprovement)
+	}
+}
+
+func TestPopulation_AggregateObjectives(t *testing.T) {
+	pop := newPopulation()
+	sp, err := buildSpeciesWithOrganisms(1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	pop.Organisms = append(pop.Organisms, sp.Organisms...)
+	for i, org := range pop.Organisms {
+		org.Objectives = []float64{float64(i), 2.0, -1.0}
+	}
+
+	weights := []float64{0.5, 1.5, 2.0}
+	if err = pop.AggregateObjectives(weights); err != nil {
+		t.Error(err)
+		return
+	}
+	for i, org := range pop.Organisms {
+		expected := 0.5 * float64(i) + 3.0 - 2.0
+		if org.Fitness != expected {
+			t.Error("org.Fitness != expected", org.Fitness, expected)
+		}
+		if len(org.Objectives) != 3 || org.Objectives[1] != 2.0 {
+			t.Error("Raw objectives are not kept", org.Objectives)
 		}
 	}
+
+	// length mismatch
+	pop.Organisms[1].Objectives = []float64{1.0}
+	if err = pop.AggregateObjectives(weights); err == nil {
+		t.Error("Error expected for objectives length mismatch")
+	}
+}
+
+func TestPopulation_UpdateHallOfFame(t *testing.T) {
+	rand.Seed(42)
+	in, out, nmax, n := 3, 2, 15, 3
+	conf := neat.NeatContext{
+		CompatThreshold:0.5,
+		DropOffAge:1,
+		PopSize: 30,
+		RecurOnlyProb:0.2,
+	}
+	gen := newGenomeRand(1, in, out, n, nmax, false, 0.8)
+	pop, err := NewPopulation(gen, &conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	// the first generation with the best organism ever
+	for i, org := range pop.Organisms {
+		org.Fitness = float64(i)
+	}
+	best := pop.Organisms[len(pop.Organisms) - 1]
+	if err = pop.UpdateHallOfFame(2); err != nil {
+		t.Error(err)
+		return
+	}
+
+	// the second generation is less fit
+	ex := SequentialPopulationEpochExecutor{}
+	if err = ex.NextEpoch(1, pop, &conf); err != nil {
+		t.Error(err)
+		return
+	}
+	for _, org := range pop.Organisms {
+		org.Fitness = rand.Float64()
+	}
+	if err = pop.UpdateHallOfFame(2); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if len(pop.HallOfFame) != 2 {
+		t.Error("len(pop.HallOfFame) != 2", len(pop.HallOfFame))
+		return
+	}
+	if pop.HallOfFame[0].Fitness != best.originalFitness {
+		t.Error("pop.HallOfFame[0].Fitness != best.originalFitness", pop.HallOfFame[0].Fitness, best.originalFitness)
+	}
+	if pop.HallOfFame[0].Genotype == best.Genotype {
+		t.Error("The genome of hall of fame organism is not a copy")
+	}
+	if eq, err := pop.HallOfFame[0].Genotype.IsEqual(best.Genotype); !eq {
+		t.Error("The genome of hall of fame organism is not equal to the best", err)
+	}
+	if pop.HallOfFame[1].Fitness > pop.HallOfFame[0].Fitness {
+		t.Error("Hall of fame is not sorted", pop.HallOfFame[0].Fitness, pop.HallOfFame[1].Fitness)
+	}
+
+	if err = pop.UpdateHallOfFame(0); err == nil {
+		t.Error("Error expected for zero hall of fame size")
+	}
+}
+
+func TestPopulation_SplitLargeSpecies(t *testing.T) {
+	conf := neat.NeatContext{
+		CompatThreshold:1000.0,
+		DisjointCoeff:1.0,
+		ExcessCoeff:1.0,
+		MutdiffCoeff:1.0,
+	}
+	// build two clusters of genomes with the same structure but distinct weights
+	pop := newPopulation()
+	for i := 0; i < 6; i++ {
+		gnome := buildTestGenome(i)
+		for _, gn := range gnome.Genes {
+			gn.MutationNum = float64(i % 2) * 100.0 + float64(i) * 0.1
+		}
+		org, err := NewOrganism(0.0, gnome, 1)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		pop.Organisms = append(pop.Organisms, org)
+	}
+	if err := pop.speciate(pop.Organisms, &conf); err != nil {
+		t.Error(err)
+		return
+	}
+	if len(pop.Species) != 1 {
+		t.Error("len(pop.Species) != 1", len(pop.Species))
+		return
+	}
+	// the champion is not the first organism of species
+	champion := pop.Organisms[3]
+	champion.Fitness = 10.0
+
+	if err := pop.SplitLargeSpecies(4, &conf); err != nil {
+		t.Error(err)
+		return
+	}
+	if len(pop.Species) != 2 {
+		t.Error("len(pop.Species) != 2", len(pop.Species))
+		return
+	}
+	if pop.Species[1].Id != pop.LastSpecies {
+		t.Error("pop.Species[1].Id != pop.LastSpecies", pop.Species[1].Id, pop.LastSpecies)
+	}
+	if champion.Species != pop.Species[0] {
+		t.Error("The species champion moved into the new species", champion.Species.Id)
+	}
+	for _, sp := range pop.Species {
+		if len(sp.Organisms) != 3 {
+			t.Error("len(sp.Organisms) != 3", len(sp.Organisms))
+		}
+		for _, org := range sp.Organisms {
+			if org.Species != sp {
+				t.Error("org.Species != sp", org.Species.Id, sp.Id)
+			}
+			if org.Genotype.Id % 2 != sp.Organisms[0].Genotype.Id % 2 {
+				t.Error("Organism from another cluster found", org.Genotype.Id, sp.Id)
+			}
+		}
+	}
+}
+
+func TestPopulation_capSpeciesOffspring(t *testing.T) {
+	conf := neat.NeatContext{
+		PopSize:12,
+		MaxSpeciesOffspringFraction:0.5,
+	}
+	pop := newPopulation()
+	for i := 0; i < 3; i++ {
+		sp, err := buildSpeciesWithOrganisms(i + 1)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		pop.Species = append(pop.Species, sp)
+	}
+	// the first species claims whole population
+	pop.Species[0].ExpectedOffspring = conf.PopSize
+
+	pop.capSpeciesOffspring(pop.Species, &conf)
+
+	total := 0
+	for _, sp := range pop.Species {
+		if sp.ExpectedOffspring > 6 {
+			t.Error("sp.ExpectedOffspring > 6", sp.Id, sp.ExpectedOffspring)
+		}
+		total += sp.ExpectedOffspring
+	}
+	if pop.Species[0].ExpectedOffspring != 6 {
+		t.Error("pop.Species[0].ExpectedOffspring != 6", pop.Species[0].ExpectedOffspring)
+	}
+	if total != conf.PopSize {
+		t.Error("total != conf.PopSize", total)
+	}
+
+	// the single species keeps whole population
+	pop.Species = pop.Species[:1]
+	pop.Species[0].ExpectedOffspring = conf.PopSize
+	pop.capSpeciesOffspring(pop.Species, &conf)
+	if pop.Species[0].ExpectedOffspring != conf.PopSize {
+		t.Error("pop.Species[0].ExpectedOffspring != conf.PopSize", pop.Species[0].ExpectedOffspring)
+	}
+}
+
+func TestPopulation_ComputeOffspringAllocation(t *testing.T) {
+	conf := neat.NeatContext{
+		PopSize:9,
+	}
+	pop := newPopulation()
+	for i := 0; i < 3; i++ {
+		sp, err := buildSpeciesWithOrganisms(i + 1)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		pop.Species = append(pop.Species, sp)
+		pop.Organisms = append(pop.Organisms, sp.Organisms...)
+	}
+
+	total := pop.ComputeOffspringAllocation(&conf)
+	if total > conf.PopSize || total < conf.PopSize - 1 {
+		t.Error("total != conf.PopSize", total)
+	}
+	// the average fitness is 20
+	if pop.Organisms[0].ExpectedOffspring != 0.25 {
+		t.Error("pop.Organisms[0].ExpectedOffspring != 0.25", pop.Organisms[0].ExpectedOffspring)
+	}
+	species_total := 0
+	for _, sp := range pop.Species {
+		species_total += sp.ExpectedOffspring
+	}
+	if species_total != total {
+		t.Error("species_total != total", species_total, total)
+	}
+}
+
+func TestPopulation_ReconcilePopSize(t *testing.T) {
+	conf := neat.NeatContext{
+		PopSize:9,
+	}
+	pop := newPopulation()
+	for i := 0; i < 3; i++ {
+		sp, err := buildSpeciesWithOrganisms(i + 1)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		pop.Species = append(pop.Species, sp)
+		pop.Organisms = append(pop.Organisms, sp.Organisms...)
+	}
+	// the rounding lost one offspring
+	pop.Species[0].ExpectedOffspring = 2
+	pop.Species[1].ExpectedOffspring = 2
+	pop.Species[2].ExpectedOffspring = 4
+	if total := pop.OffspringTotal(); total != conf.PopSize - 1 {
+		t.Error("total != conf.PopSize - 1", total)
+		return
+	}
+
+	pop.ReconcilePopSize(&conf)
+	if total := pop.OffspringTotal(); total != conf.PopSize {
+		t.Error("total != conf.PopSize", total)
+	}
+	if pop.Species[2].ExpectedOffspring != 5 {
+		t.Error("The extra offspring expected to be given to the best species", pop.Species[2].ExpectedOffspring)
+	}
+
+	// too many offspring
+	pop.Species[0].ExpectedOffspring = 3
+	pop.ReconcilePopSize(&conf)
+	if total := pop.OffspringTotal(); total != conf.PopSize {
+		t.Error("total != conf.PopSize", total)
+	}
+	if pop.Species[2].ExpectedOffspring != 4 {
+		t.Error("The offspring expected to be taken from the best species", pop.Species[2].ExpectedOffspring)
+	}
+}
+
+func TestPopulation_negativeFitnessShift(t *testing.T) {
+	conf := neat.NeatContext{
+		PopSize:9,
+		DropOffAge:15,
+		AgeSignificance:1.0,
+		AllowNegativeFitness:true,
+		MinAdjustedFitness:neat.DefaultMinAdjustedFitness,
+	}
+	pop := newPopulation()
+	for i := 0; i < 3; i++ {
+		sp, err := buildSpeciesWithOrganisms(i + 1)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		// make fitness signed
+		for _, org := range sp.Organisms {
+			org.Fitness -= 30.0
+		}
+		pop.Species = append(pop.Species, sp)
+		pop.Organisms = append(pop.Organisms, sp.Organisms...)
+	}
+
+	fitness_shift := pop.negativeFitnessShift(&conf)
+	if fitness_shift != 25.0001 {
+		t.Error("fitness_shift != 25.0001", fitness_shift)
+	}
+	for _, sp := range pop.Species {
+		sp.adjustFitnessShifted(&conf, fitness_shift)
+		for i := 1; i < len(sp.Organisms); i++ {
+			prev, org := sp.Organisms[i - 1], sp.Organisms[i]
+			if prev.originalFitness <= org.originalFitness || prev.Fitness <= org.Fitness {
+				t.Error("Fitness ordering is not preserved", prev.originalFitness, org.originalFitness)
+			}
+			if org.Fitness <= 0 {
+				t.Error("org.Fitness <= 0", org.Fitness)
+			}
+		}
+		if sp.MaxFitnessEver != sp.Organisms[0].originalFitness {
+			t.Error("The negative fitness improvement is not recorded", sp.MaxFitnessEver, sp.Organisms[0].originalFitness)
+		}
+	}
+
+	pop.ComputeOffspringAllocation(&conf)
+	for _, sp := range pop.Species {
+		if sp.ExpectedOffspring < 0 {
+			t.Error("sp.ExpectedOffspring < 0", sp.Id, sp.ExpectedOffspring)
+		}
+	}
+	for _, org := range pop.Organisms {
+		if org.ExpectedOffspring < 0 {
+			t.Error("org.ExpectedOffspring < 0", org.ExpectedOffspring)
+		}
+	}
+
+	// no shift if not allowed
+	conf.AllowNegativeFitness = false
+	if fitness_shift = pop.negativeFitnessShift(&conf); fitness_shift != 0 {
+		t.Error("fitness_shift != 0", fitness_shift)
+	}
+}
+
+func TestPopulation_MinAdjustedFitness(t *testing.T) {
+	conf := neat.NeatContext{
+		PopSize:9,
+		DropOffAge:5,
+		AgeSignificance:1.0,
+		SurvivalThresh:1.0,
+		MinAdjustedFitness:2.0,
+	}
+	pop := newPopulation()
+	for i := 0; i < 3; i++ {
+		sp, err := buildSpeciesWithOrganisms(i + 1)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		pop.Species = append(pop.Species, sp)
+		pop.Organisms = append(pop.Organisms, sp.Organisms...)
+	}
+	// the worst organisms has zero fitness
+	pop.Species[0].Organisms[0].Fitness = 0.0
+	pop.Species[1].Organisms[0].Fitness = 0.0
+	pop.Species[1].Organisms[1].Fitness = 0.0
+
+	for _, sp := range pop.Species {
+		sp.adjustFitness(&conf)
+	}
+	for _, org := range pop.Organisms {
+		if org.Fitness < conf.MinAdjustedFitness {
+			t.Error("org.Fitness < conf.MinAdjustedFitness", org.Fitness)
+		}
+	}
+	pop.ComputeOffspringAllocation(&conf)
+	for _, org := range pop.Organisms {
+		if !org.toEliminate && org.ExpectedOffspring <= 0 {
+			t.Error("Surviving organism has no expected offspring", org)
+		}
+	}
+}
+
+func TestPopulation_assignSuperChampOffspring(t *testing.T) {
+	rand.Seed(42)
+	in, out, nmax, n := 3, 2, 15, 3
+
+	conf := neat.NewNeatContext()
+	conf.PopSize = 30
+	conf.CompatThreshold = 0.6
+	conf.MutateOnlyProb = 1.0
+	conf.SuperChampOffspringCount = 3
+
+	// register operator which tags genomes produced by ordinary mutation
+	tagged := registerTagMutation(t, "SuperChampTagMutation", conf)
+
+	gen := newGenomeRand(1, in, out, n, nmax, false, 0.8)
+	pop, err := NewPopulation(gen, conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	sorted_species := make([]*Species, len(pop.Species))
+	copy(sorted_species, pop.Species)
+	pop.Species[0].ExpectedOffspring = 5
+
+	pop.assignSuperChampOffspring(sorted_species, conf)
+	if pop.Species[0].Organisms[0].superChampOffspring != 3 {
+		t.Error("superChampOffspring != 3", pop.Species[0].Organisms[0].superChampOffspring)
+	}
+
+	babies, err := pop.Species[0].reproduce(1, pop, sorted_species, conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(babies) != 5 {
+		t.Error("len(babies) != 5", len(babies))
+	}
+	super_champ_babies := 0
+	for _, baby := range babies {
+		if !tagged[baby.Genotype] {
+			super_champ_babies++
+		}
+	}
+	if super_champ_babies != 3 {
+		t.Error("super_champ_babies != 3", super_champ_babies)
+	}
+
+	// disabled by default
+	conf.SuperChampOffspringCount = 0
+	pop.assignSuperChampOffspring(sorted_species, conf)
+	if pop.Species[0].Organisms[0].superChampOffspring != 0 {
+		t.Error("superChampOffspring != 0", pop.Species[0].Organisms[0].superChampOffspring)
+	}
+}
+
+func TestPopulation_AdjustCompatThreshold(t *testing.T) {
+	conf := neat.NeatContext{
+		CompatThreshold:2.0,
+		CompatThresholdMin:1.0,
+		CompatThresholdMax:3.0,
+	}
+	pop := newPopulation()
+	for i := 0; i < 3; i++ {
+		pop.Species = append(pop.Species, NewSpecies(i + 1))
+	}
+
+	// too few species
+	for i := 0; i < 5; i++ {
+		pop.AdjustCompatThreshold(10, 5.0, &conf)
+		if conf.CompatThreshold < conf.CompatThresholdMin || conf.CompatThreshold > conf.CompatThresholdMax {
+			t.Error("CompatThreshold out of bounds", conf.CompatThreshold)
+		}
+	}
+	if conf.CompatThreshold != 1.0 {
+		t.Error("conf.CompatThreshold != 1.0", conf.CompatThreshold)
+	}
+
+	// too many species
+	for i := 0; i < 5; i++ {
+		pop.AdjustCompatThreshold(1, 5.0, &conf)
+		if conf.CompatThreshold < conf.CompatThresholdMin || conf.CompatThreshold > conf.CompatThresholdMax {
+			t.Error("CompatThreshold out of bounds", conf.CompatThreshold)
+		}
+	}
+	if conf.CompatThreshold != 3.0 {
+		t.Error("conf.CompatThreshold != 3.0", conf.CompatThreshold)
+	}
+
+	// the floor replaces zero threshold
+	conf.CompatThreshold = 0
+	if threshold := conf.EffectiveCompatThreshold(); threshold != 1.0 {
+		t.Error("threshold != 1.0", threshold)
+	}
+}
+
+func TestPopulation_Write(t *testing.T) {
+	// first create population
+	pop_str := "genomeversion 1\n" +
+		"genomestart 1\n" +
+		"trait 1 0.1 0 0 0 0 0 0 0\n" +
+		"trait 2 0.2 0 0 0 0 0 0 0\n" +
+		"trait 3 0.3 0 0 0 0 0 0 0\n" +
+		"node 1 0 1 1\n" +
+		"node 2 0 1 1\n" +
+		"node 3 0 1 3\n" +
+		"node 4 0 0 2\n" +
+		"gene 1 1 4 1.5 false 1 0 true\n" +
+		"gene 2 2 4 2.5 false 2 0 true\n" +
+		"gene 3 3 4 3.5 false 3 0 true\n" +
+		"genomeend 1\n" +
+		"genomeversion 1\n" +
+		"genomestart 2\n" +
+		"trait 1 0.1 0 0 0 0 0 0 0\n" +
+		"trait 2 0.2 0 0 0 0 0 0 0\n" +
+		"trait 3 0.3 0 0 0 0 0 0 0\n" +
+		"node 1 0 1 1\n" +
+		"node 2 0 1 1\n" +
+		"node 3 0 1 3\n" +
+		"node 4 0 0 2\n" +
+		"gene 1 1 4 1.5 false 1 0 true\n" +
+		"gene 2 2 4 2.5 false 2 0 true\n" +
+		"gene 3 3 4 3.5 false 3 0 true\n" +
+		"genomeend 2\n"
+	conf := neat.NeatContext{
+		CompatThreshold:0.5,
+	}
+	pop, err := ReadPopulation(strings.NewReader(pop_str), &conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if pop == nil {
+		t.Error("pop == nil")
+		return
+	}
+
+	// write it again and test
+	out_buf := bytes.NewBufferString("")
+	pop.Write(out_buf)
+
+	_, p_str_r, err_p := bufio.ScanLines([]byte(pop_str), true)
+	_, o_str_r, err_o := bufio.ScanLines(out_buf.Bytes(), true)
+	if err_p != nil || err_o != nil {
+		t.Error("Failed to parse strings", err_o, err_p)
+	}
+	for i, gsr := range p_str_r {
+		if gsr != o_str_r[i] {
+			t.Error("Lines mismatch", gsr, o_str_r[i])
+		}
+	}
+}
+
+// The writer counting write calls and the largest chunk written
+type countingWriter struct {
+	buf      bytes.Buffer
+	writes   int
+	maxChunk int
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	cw.writes++
+	if len(p) > cw.maxChunk {
+		cw.maxChunk = len(p)
+	}
+	return cw.buf.Write(p)
+}
+
+func TestPopulation_WriteStream(t *testing.T) {
+	rand.Seed(42)
+	in, out, nmax, n := 3, 2, 15, 3
+	conf := neat.NeatContext{
+		CompatThreshold:0.5,
+		PopSize:20,
+	}
+	gen := newGenomeRand(1, in, out, n, nmax, false, 0.8)
+	pop, err := NewPopulation(gen, &conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	for _, encoding := range []GenomeEncoding{PlainGenomeEncoding, YAMLGenomeEncoding} {
+		cw := &countingWriter{}
+		if err = pop.WriteStream(cw, encoding); err != nil {
+			t.Error(err)
+			return
+		}
+		if cw.writes < len(pop.Organisms) {
+			t.Error("cw.writes < len(pop.Organisms)", cw.writes, len(pop.Organisms))
+		}
+		if cw.maxChunk >= cw.buf.Len() / 2 {
+			t.Error("The output was not written incrementally", cw.maxChunk, cw.buf.Len())
+		}
+
+		genomes, err := ReadGenomes(&cw.buf, encoding)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if len(genomes) != len(pop.Organisms) {
+			t.Error("len(genomes) != len(pop.Organisms)", len(genomes), len(pop.Organisms))
+		}
+	}
+}
+
+func TestPopulation_speciate_offspringRetained(t *testing.T) {
+	conf := neat.NeatContext{
+		CompatThreshold:0.5,
+		DisjointCoeff:1.0,
+		ExcessCoeff:1.0,
+		MutdiffCoeff:0.4,
+	}
+	pop := newPopulation()
+	near, err := NewOrganism(1.0, buildTestGenome(1), 1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	distant, err := NewOrganism(1.0, buildTestModularGenome(2), 1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if err = pop.speciate([]*Organism{near, distant}, &conf); err != nil {
+		t.Error(err)
+		return
+	}
+	if len(pop.Species) != 2 {
+		t.Error("len(pop.Species) != 2", len(pop.Species))
+		return
+	}
+	parent_species := near.Species
+
+	// the baby of the first species which is compatible only with the second species
+	baby, err := NewOrganism(0.0, buildTestModularGenome(3), 2)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	baby.parentSpecies = parent_species
+	parent_species.OffspringProduced++
+
+	if err = pop.speciate([]*Organism{baby}, &conf); err != nil {
+		t.Error(err)
+		return
+	}
+	if baby.Species != distant.Species {
+		t.Error("baby.Species != distant.Species", baby.Species.Id)
+	}
+	if parent_species.OffspringProduced != 1 {
+		t.Error("parent_species.OffspringProduced != 1", parent_species.OffspringProduced)
+	}
+	if parent_species.OffspringRetained != 0 {
+		t.Error("parent_species.OffspringRetained != 0", parent_species.OffspringRetained)
+	}
+}
+
+func TestPopulation_Speciate(t *testing.T) {
+	conf := neat.NeatContext{
+		CompatThreshold:0.5,
+		DisjointCoeff:1.0,
+		ExcessCoeff:1.0,
+		MutdiffCoeff:0.4,
+	}
+	pop := newPopulation()
+	genomes := []*Genome{buildTestGenome(1), buildTestGenome(2), buildTestModularGenome(3)}
+	for _, gnome := range genomes {
+		org, err := NewOrganism(0.0, gnome, 1)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		pop.Organisms = append(pop.Organisms, org)
+	}
+
+	// run twice to check that species structure is rebuilt rather than appended
+	for i := 0; i < 2; i++ {
+		if err := pop.Speciate(&conf); err != nil {
+			t.Error(err)
+			return
+		}
+		if len(pop.Species) != 2 {
+			t.Error("len(pop.Species) != 2", len(pop.Species))
+			return
+		}
+		if pop.Organisms[0].Species != pop.Organisms[1].Species {
+			t.Error("Similar organisms assigned to different species")
+		}
+		if pop.Organisms[2].Species == pop.Organisms[0].Species {
+			t.Error("Distant organism assigned to the species of similar ones")
+		}
+		for _, sp := range pop.Species {
+			for _, org := range sp.Organisms {
+				if org.Species != sp {
+					t.Error("org.Species != sp", org.Genotype.Id, sp.Id)
+				}
+			}
+		}
+	}
+}
+
+func TestPopulation_speciate_maxSpecies(t *testing.T) {
+	rand.Seed(42)
+	conf := neat.NeatContext{
+		DisjointCoeff:1.0,
+		ExcessCoeff:1.0,
+		MutdiffCoeff:0.4,
+		CompatThreshold:0.1,
+		MaxSpecies:2,
+	}
+	// the near genomes differ only by mutation numbers
+	g1 := buildTestGenome(1)
+	g2 := buildTestGenome(2)
+	for _, gn := range g2.Genes {
+		gn.MutationNum += 1.0
+	}
+	g3 := buildTestGenome(3)
+	for _, gn := range g3.Genes {
+		gn.MutationNum += 10.0
+	}
+	organisms := make([]*Organism, 3)
+	for i, g := range []*Genome{g1, g3, g2} {
+		org, err := NewOrganism(1.0, g, 1)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		organisms[i] = org
+	}
+
+	pop := newPopulation()
+	if err := pop.speciate(organisms, &conf); err != nil {
+		t.Error(err)
+		return
+	}
+	if len(pop.Species) != 2 {
+		t.Error("len(pop.Species) != 2", len(pop.Species))
+		return
+	}
+	if organisms[2].Species != organisms[0].Species {
+		t.Error("The third genome expected to join the nearest species")
+	}
+
+	// unlimited
+	conf.MaxSpecies = 0
+	pop = newPopulation()
+	if err := pop.speciate(organisms, &conf); err != nil {
+		t.Error(err)
+		return
+	}
+	if len(pop.Species) != 3 {
+		t.Error("len(pop.Species) != 3", len(pop.Species))
+	}
+}
+
+func TestPopulation_Speciate_incremental(t *testing.T) {
+	conf := neat.NeatContext{
+		CompatThreshold:0.5,
+		DisjointCoeff:1.0,
+		ExcessCoeff:1.0,
+		MutdiffCoeff:0.4,
+		IncrementalSpeciation:true,
+	}
+	pop := newPopulation()
+	for i := 0; i < 2; i++ {
+		org, err := NewOrganism(0.0, buildTestGenome(i + 1), 1)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		pop.Organisms = append(pop.Organisms, org)
+	}
+	// initial speciation
+	if err := pop.Speciate(&conf); err != nil {
+		t.Error(err)
+		return
+	}
+	if len(pop.Species) != 1 {
+		t.Error("len(pop.Species) != 1", len(pop.Species))
+		return
+	}
+	if pop.compatChecks != 1 {
+		t.Error("pop.compatChecks != 1", pop.compatChecks)
+	}
+
+	// nothing changed - no compatibility tests expected
+	if err := pop.Speciate(&conf); err != nil {
+		t.Error(err)
+		return
+	}
+	if pop.compatChecks != 1 {
+		t.Error("pop.compatChecks != 1", pop.compatChecks)
+	}
+
+	// change representative - organism must be tested against new one
+	sp := pop.Species[0]
+	sp.Organisms[0], sp.Organisms[1] = sp.Organisms[1], sp.Organisms[0]
+	if err := pop.Speciate(&conf); err != nil {
+		t.Error(err)
+		return
+	}
+	if pop.compatChecks != 2 {
+		t.Error("pop.compatChecks != 2", pop.compatChecks)
+	}
+	if len(pop.Species) != 1 {
+		t.Error("len(pop.Species) != 1", len(pop.Species))
+	}
+
+	// put distant organism as representative - other organisms must drift out
+	distant, err := NewOrganism(0.0, buildTestModularGenome(3), 1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	distant.Species = sp
+	sp.Organisms = append([]*Organism{distant}, sp.Organisms...)
+	pop.Organisms = append(pop.Organisms, distant)
+	if err := pop.Speciate(&conf); err != nil {
+		t.Error(err)
+		return
+	}
+	if len(pop.Species) != 2 {
+		t.Error("len(pop.Species) != 2", len(pop.Species))
+		return
+	}
+	if len(sp.Organisms) != 1 {
+		t.Error("len(sp.Organisms) != 1", len(sp.Organisms))
+	}
+	if pop.Organisms[0].Species != pop.Organisms[1].Species || pop.Organisms[0].Species == sp {
+		t.Error("Drifted organisms was not speciated together")
+	}
+}
+
+func benchmarkPopulationSpeciate(incremental bool, b *testing.B) {
+	rand.Seed(42)
+	conf := neat.NeatContext{
+		CompatThreshold:3.0,
+		DisjointCoeff:1.0,
+		ExcessCoeff:1.0,
+		MutdiffCoeff:0.4,
+		PopSize:200,
+		IncrementalSpeciation:incremental,
+	}
+	gen := newGenomeRand(1, 3, 2, 3, 15, false, 0.8)
+	pop, err := NewPopulation(gen, &conf)
+	if err != nil {
+		b.Error(err)
+		return
+	}
+	pop.compatChecks = 0
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err = pop.Speciate(&conf); err != nil {
+			b.Error(err)
+			return
+		}
+	}
+	b.Logf("compatibility checks per speciation: %d", pop.compatChecks / b.N)
+}
+
+func BenchmarkPopulation_Speciate(b *testing.B) {
+	benchmarkPopulationSpeciate(false, b)
+}
+
+func BenchmarkPopulation_Speciate_incremental(b *testing.B) {
+	benchmarkPopulationSpeciate(true, b)
+}
+
+func TestPopulation_Merge(t *testing.T) {
+	rand.Seed(42)
+	conf := neat.NeatContext{
+		CompatThreshold:3.0,
+		DisjointCoeff:1.0,
+		ExcessCoeff:1.0,
+		MutdiffCoeff:0.4,
+		PopSize:5,
+	}
+	gen := newGenomeRand(1, 3, 2, 3, 5, false, 0.8)
+	pop, err := NewPopulation(gen, &conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	other, err := NewPopulation(gen, &conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	// collect innovations of this population
+	innovations := make(map[int64]bool)
+	for _, org := range pop.Organisms {
+		for _, gene := range org.Genotype.Genes {
+			innovations[gene.InnovationNum] = true
+		}
+	}
+	other_orgs := other.Organisms
+
+	if err = pop.Merge(other, &conf); err != nil {
+		t.Error(err)
+		return
+	}
+	if len(pop.Organisms) != conf.PopSize * 2 {
+		t.Error("len(pop.Organisms) != conf.PopSize * 2", len(pop.Organisms))
+	}
+	for _, org := range other_orgs {
+		for _, gene := range org.Genotype.Genes {
+			if innovations[gene.InnovationNum] {
+				t.Error("Duplicate innovation number found after merge", gene.InnovationNum)
+			}
+			if gene.InnovationNum >= pop.nextInnovNum {
+				t.Error("gene.InnovationNum >= pop.nextInnovNum", gene.InnovationNum, pop.nextInnovNum)
+			}
+		}
+		if org.Species == nil {
+			t.Error("Merged organism was not speciated", org.Genotype.Id)
+		}
+	}
+	if res, err := pop.Verify(); !res || err != nil {
+		t.Error("Population verification failed after merge", err)
+	}
+}
+
+func TestPopulation_SelectMigrants(t *testing.T) {
+	pop := newPopulation()
+	for i := 0; i < 3; i++ {
+		sp, err := buildSpeciesWithOrganisms(i + 1)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		for _, org := range sp.Organisms {
+			org.Species = sp
+			pop.Organisms = append(pop.Organisms, org)
+		}
+		pop.Species = append(pop.Species, sp)
+	}
+
+	migrants, err := pop.SelectMigrants(0, BestPerSpeciesMigration)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(migrants) != len(pop.Species) {
+		t.Error("len(migrants) != len(pop.Species)", len(migrants))
+		return
+	}
+	// migrants sorted by fitness, i.e. the champion of the last species goes first
+	for i, m := range migrants {
+		champ := pop.Species[len(pop.Species) - i - 1].FindChampion()
+		if m.Fitness != champ.Fitness {
+			t.Error("m.Fitness != champ.Fitness", m.Fitness, champ.Fitness)
+		}
+		if m.Genotype == champ.Genotype {
+			t.Error("Migrant genome is not a copy")
+		}
+		if equal, err := m.Genotype.IsEqual(champ.Genotype); !equal {
+			t.Error("Migrant genome is not equal to champion genome", err)
+		}
+	}
+
+	migrants, err = pop.SelectMigrants(2, BestNMigration)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(migrants) != 2 {
+		t.Error("len(migrants) != 2", len(migrants))
+		return
+	}
+	if migrants[0].Fitness != 45.0 || migrants[1].Fitness != 30.0 {
+		t.Error("Wrong best migrants selected", migrants[0].Fitness, migrants[1].Fitness)
+	}
+
+	if _, err = pop.SelectMigrants(2, MigrationStrategy(0)); err != ErrUnsupportedMigrationStrategy {
+		t.Error("err != ErrUnsupportedMigrationStrategy", err)
+	}
+}
+
+func TestDiffPopulations(t *testing.T) {
+	rand.Seed(42)
+	conf := neat.NeatContext{
+		CompatThreshold:0.5,
+		PopSize:10,
+	}
+	gen := newGenomeRand(1, 3, 2, 3, 5, false, 0.5)
+	pop, err := NewPopulation(gen, &conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	pop_buf := bytes.NewBufferString("")
+	pop.Write(pop_buf)
+
+	// read the copy and mutate weight of one organism
+	pop_copy, err := ReadPopulation(bytes.NewReader(pop_buf.Bytes()), &conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	changed := pop_copy.Organisms[3]
+	changed.Genotype.Genes[0].Link.Weight += 1.0
+	changed.Fitness = 10.0
+
+	diff := DiffPopulations(pop, pop_copy)
+	if len(diff.SpeciesAdded) != 0 || len(diff.SpeciesRemoved) != 0 {
+		t.Error("species changes found", diff.SpeciesAdded, diff.SpeciesRemoved)
+	}
+	if len(diff.ChangedOrganisms) != 1 {
+		t.Error("len(diff.ChangedOrganisms) != 1", diff.ChangedOrganisms)
+	} else if diff.ChangedOrganisms[0] != changed.Genotype.Id {
+		t.Error("diff.ChangedOrganisms[0] != changed.Genotype.Id", diff.ChangedOrganisms[0], changed.Genotype.Id)
+	}
+	if len(diff.FitnessDeltas) != conf.PopSize {
+		t.Error("len(diff.FitnessDeltas) != conf.PopSize", len(diff.FitnessDeltas))
+	}
+	for id, delta := range diff.FitnessDeltas {
+		if id == changed.Genotype.Id && delta != 10.0 {
+			t.Error("delta != 10.0", delta)
+		} else if id != changed.Genotype.Id && delta != 0 {
+			t.Error("delta != 0", id, delta)
+		}
+	}
+}
+
+func TestPopulation_EvaluateParallel(t *testing.T) {
+	rand.Seed(42)
+	in, out, nmax := 3, 2, 5
+	link_prob := 0.5
+	conf := neat.NeatContext{
+		CompatThreshold:0.5,
+		PopSize:10,
+	}
+	neat.LogLevel = neat.LogLevelInfo
+	pop, err := NewPopulationRandom(in, out, nmax, false, link_prob, &conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	slow := pop.Organisms[3]
+	eval := func(org *Organism) (float64, error) {
+		if org.Genotype == slow.Genotype {
+			// simulate pathological network
+			time.Sleep(500 * time.Millisecond)
+		}
+		org.IsWinner = true
+		return 1.0, nil
+	}
+
+	start := time.Now()
+	if err = pop.EvaluateParallel(eval, 50 * time.Millisecond); err != nil {
+		t.Error(err)
+		return
+	}
+	if time.Since(start) >= 500 * time.Millisecond {
+		t.Error("Evaluation is not interrupted by timeout", time.Since(start))
+	}
+	for _, org := range pop.Organisms {
+		if org == slow {
+			if !org.toEliminate || org.Fitness != 0.0 {
+				t.Error("Timed out organism is not flagged", org.toEliminate, org.Fitness)
+			}
+		} else if org.toEliminate || org.Fitness != 1.0 || !org.IsWinner {
+			t.Error("Organism is not evaluated", org.toEliminate, org.Fitness, org.IsWinner)
+		}
+	}
+
+	// wait for timed out evaluation to finish and check that its results are discarded
+	time.Sleep(time.Second)
+	if slow.IsWinner || slow.Fitness != 0.0 {
+		t.Error("Results of timed out evaluation are published", slow.IsWinner, slow.Fitness)
+	}
+
+	// check that errors returned
+	eval = func(org *Organism) (float64, error) {
+		return 0.0, errors.New("evaluation failed")
+	}
+	if err = pop.EvaluateParallel(eval, 0); err == nil {
+		t.Error("err == nil")
+	}
 }
@@ -2,12 +2,22 @@ package genetics
 
 import (
 	"github.com/yaricom/goNEAT/neat"
+	"github.com/yaricom/goNEAT/neat/utils"
 	"sort"
 	"math"
 	"fmt"
 	"errors"
 	"math/rand"
 	"io"
+	"encoding/csv"
+	"strconv"
+)
+
+// The bounds and step of adaptive species mutation rate multiplier
+const (
+	minMutateRateMultiplier = 0.5
+	maxMutateRateMultiplier = 2.0
+	mutateRateAdaptationStep = 1.1
 )
 
 // A Species is a group of similar Organisms.
@@ -32,6 +42,15 @@ type Species struct {
 
 	// Flag used for search optimization
 	IsChecked            bool
+
+	// The number of offspring produced by this Species during reproduction
+	OffspringProduced    int
+	// The number of produced offspring that was speciated back into this Species
+	OffspringRetained    int
+
+	// The multiplier of structural and weight mutation probabilities for offspring of this Species, adapted
+	// each generation if context.AdaptiveSpeciesMutation is set
+	MutateRateMultiplier float64
 }
 
 // Construct new species with specified ID
@@ -54,11 +73,25 @@ func newSpecies(id int) *Species {
 		Id:id,
 		Age:1,
 		Organisms:make([]*Organism, 0),
+		MutateRateMultiplier:1.0,
+		// the fitness may be negative when context.AllowNegativeFitness is set
+		MaxFitnessEver:-math.MaxFloat64,
 	}
 }
 
 // Writes species to the specified writer
 func (s Species) Write(w io.Writer) {
+	s.write(w, nil)
+}
+
+// Writes species to the specified writer annotating each organism with its compatibility distance to the species
+// representative (the first organism). It helps to find when species drifted and should be split.
+func (s Species) WriteVerbose(w io.Writer, context *neat.NeatContext) {
+	s.write(w, context)
+}
+
+// Writes species to the specified writer. If context provided the compatibility distances will be printed as well.
+func (s Species) write(w io.Writer, context *neat.NeatContext) {
 	_, avg := s.ComputeMaxAndAvgFitness()
 	// Print a comment on the Species info
 	fmt.Fprintf(w, "/* Species #%d : (Size %d) (AF %.3f) (Age %d)  */\n",
@@ -71,8 +104,13 @@ func (s Species) Write(w io.Writer) {
 
 	// Print all the Organisms' Genomes to the outFile
 	for _, org := range sorted_organisms {
-		fmt.Fprintf(w, "/* Organism #%d Fitness: %.3f Error: %.3f */\n",
-			org.Genotype.Id, org.Fitness, org.Error)
+		if context != nil {
+			fmt.Fprintf(w, "/* Organism #%d Fitness: %.3f Error: %.3f Compatibility: %.3f */\n",
+				org.Genotype.Id, org.Fitness, org.Error, org.Genotype.compatibility(s.Organisms[0].Genotype, context))
+		} else {
+			fmt.Fprintf(w, "/* Organism #%d Fitness: %.3f Error: %.3f */\n",
+				org.Genotype.Id, org.Fitness, org.Error)
+		}
 		if org.IsWinner {
 			fmt.Fprintf(w, "/* ## $ WINNER ORGANISM FOR SPECIES #%d $ ## */\n", s.Id)
 		}
@@ -80,6 +118,40 @@ func (s Species) Write(w io.Writer) {
 	}
 }
 
+// Writes organisms of this species to the specified writer as CSV table with header and one row per organism. Each
+// row holds genome ID, fitness, original fitness, error, number of nodes, number of enabled genes, champion flag and
+// elimination flag of the organism.
+func (s Species) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write([]string{"genome_id", "fitness", "original_fitness", "error", "nodes", "enabled_genes",
+		"champion", "eliminate"})
+	if err != nil {
+		return err
+	}
+	for _, org := range s.Organisms {
+		enabled_genes := 0
+		for _, gn := range org.Genotype.Genes {
+			if gn.IsEnabled {
+				enabled_genes++
+			}
+		}
+		record := []string{
+			strconv.Itoa(org.Genotype.Id),
+			strconv.FormatFloat(org.Fitness, 'g', -1, 64),
+			strconv.FormatFloat(org.originalFitness, 'g', -1, 64),
+			strconv.FormatFloat(org.Error, 'g', -1, 64),
+			strconv.Itoa(len(org.Genotype.Nodes)),
+			strconv.Itoa(enabled_genes),
+			strconv.FormatBool(org.isChampion),
+			strconv.FormatBool(org.toEliminate),
+		}
+		if err = cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
 
 // Adds new Organism to the group related to this Species
 func (s *Species) addOrganism(o *Organism) {
@@ -106,6 +178,12 @@ func (s *Species) removeOrganism(org *Organism) (bool, error) {
 // Divides the fitness by the size of the Species, so that fitness is "shared" by the species.
 // NOTE: Invocation of this method will result of species organisms sorted by fitness in descending order, i.e. most fit will be first.
 func (s *Species) adjustFitness(context *neat.NeatContext) {
+	s.adjustFitnessShifted(context, 0)
+}
+
+// Adjusts fitness of organisms the same way as adjustFitness but adds given shift to the fitness of each organism before
+// any other adjustment. The shift is used to make negative fitness values positive when context.AllowNegativeFitness is set.
+func (s *Species) adjustFitnessShifted(context *neat.NeatContext, fitness_shift float64) {
 	age_debt := (s.Age - s.AgeOfLastImprovement + 1) - context.DropOffAge
 	if age_debt == 0 {
 		age_debt = 1
@@ -114,12 +192,13 @@ func (s *Species) adjustFitness(context *neat.NeatContext) {
 	for _, org := range s.Organisms {
 		// Remember the original fitness before it gets modified
 		org.originalFitness = org.Fitness
+		org.Fitness += fitness_shift
 
 		// Make fitness decrease after a stagnation point dropoff_age
 		// Added as if to keep species pristine until the dropoff point
-		if age_debt >= 1 {
-			// Extreme penalty for a long period of stagnation (divide fitness by 100)
-			org.Fitness = org.Fitness * 0.01
+		if age_debt >= 1 && !context.RestartStagnant {
+			// Extreme penalty for a long period of stagnation (divide fitness by 100 by default)
+			org.Fitness = org.Fitness * context.StagnationPenalty
 		}
 
 		// Give a fitness boost up to some young age (niching)
@@ -129,16 +208,27 @@ func (s *Species) adjustFitness(context *neat.NeatContext) {
 			org.Fitness = org.Fitness * context.AgeSignificance
 		}
 		// Do not allow negative fitness
-		if org.Fitness < 0.0 {
-			org.Fitness = 0.0001
+		if org.Fitness < 0.0 && !context.AllowNegativeFitness {
+			org.Fitness = math.Max(context.MinAdjustedFitness, 0.0)
 		}
 
 		// Share fitness with the species
-		org.Fitness = org.Fitness / float64(len(s.Organisms))
+		if !context.DisableFitnessSharing {
+			org.Fitness = org.Fitness / float64(len(s.Organisms))
+		}
+
+		// Apply the floor of adjusted fitness
+		if context.MinAdjustedFitness > 0 && org.Fitness < context.MinAdjustedFitness {
+			org.Fitness = context.MinAdjustedFitness
+		}
 	}
 
 	// Sort the population (most fit first) and mark for death those after : survival_thresh * pop_size
-	sort.Sort(sort.Reverse(s.Organisms))
+	representative := s.Organisms[0]
+	s.SortByFitness()
+	if context.LogRepresentativeDrift && representative != s.Organisms[0] {
+		s.checkRepresentativeDrift(representative, context)
+	}
 
 	// Update age_of_last_improvement here
 	if s.Organisms[0].originalFitness > s.MaxFitnessEver {
@@ -150,10 +240,47 @@ func (s *Species) adjustFitness(context *neat.NeatContext) {
 	// Adding 1.0 ensures that at least one will survive
 	num_parents := int(math.Floor(context.SurvivalThresh * float64(len(s.Organisms)) + 1.0))
 
-	// Mark for death those who are ranked too low to be parents
 	s.Organisms[0].isChampion = true // Mark the champ as such
-	for c := num_parents; c < len(s.Organisms); c++ {
-		s.Organisms[c].toEliminate = true
+	if context.SurvivalSelection == neat.TournamentSurvival {
+		// Mark for death those who lost elimination tournaments
+		s.markTournamentLosers(len(s.Organisms) - num_parents)
+	} else {
+		// Mark for death those who are ranked too low to be parents
+		for c := num_parents; c < len(s.Organisms); c++ {
+			s.Organisms[c].toEliminate = true
+		}
+	}
+}
+
+// Logs warning if the current representative of this species is farther than compatibility threshold from the previous one
+func (s *Species) checkRepresentativeDrift(previous *Organism, context *neat.NeatContext) {
+	dist := previous.Genotype.compatibility(s.Organisms[0].Genotype, context)
+	if dist > context.CompatThreshold {
+		neat.WarnLog(fmt.Sprintf("SPECIES: Representative of species [%d] drifted from genome [%d] to genome [%d], distance: %f exceeds compatibility threshold: %f",
+			s.Id, previous.Genotype.Id, s.Organisms[0].Genotype.Id, dist, context.CompatThreshold))
+	}
+}
+
+// Runs specified number of elimination tournaments among organisms of this species. In each tournament two random
+// organisms still alive are compared and the less fit one is marked for death. The champion never takes part in tournaments.
+// NOTE: it is expected that organisms already sorted by fitness in descending order.
+func (s *Species) markTournamentLosers(tournaments int) {
+	// the candidates for elimination excluding the champion
+	candidates := make([]*Organism, len(s.Organisms) - 1)
+	copy(candidates, s.Organisms[1:])
+	for t := 0; t < tournaments && len(candidates) > 0; t++ {
+		loser := rand.Intn(len(candidates))
+		if len(candidates) > 1 {
+			other := rand.Intn(len(candidates) - 1)
+			if other >= loser {
+				other++
+			}
+			if candidates[other].Fitness < candidates[loser].Fitness {
+				loser = other
+			}
+		}
+		candidates[loser].toEliminate = true
+		candidates = append(candidates[:loser], candidates[loser + 1:]...)
 	}
 }
 
@@ -227,6 +354,33 @@ func (s Species) lastImproved() int {
 	return s.Age - s.AgeOfLastImprovement
 }
 
+// Adapts the mutation rate multiplier of this species: it is decreased if species improved in the last generation
+// and increased otherwise, within [minMutateRateMultiplier, maxMutateRateMultiplier]
+func (s *Species) adaptMutationRate() {
+	if s.MutateRateMultiplier <= 0 {
+		s.MutateRateMultiplier = 1.0
+	}
+	if s.lastImproved() == 0 {
+		s.MutateRateMultiplier = math.Max(minMutateRateMultiplier, s.MutateRateMultiplier / mutateRateAdaptationStep)
+	} else {
+		s.MutateRateMultiplier = math.Min(maxMutateRateMultiplier, s.MutateRateMultiplier * mutateRateAdaptationStep)
+	}
+}
+
+// Returns the copy of context with structural and weight mutation probabilities scaled by the mutation rate
+// multiplier of this species
+func (s *Species) mutationContext(context *neat.NeatContext) *neat.NeatContext {
+	scaled := *context
+	scale := func(prob float64) float64 {
+		return math.Min(1.0, prob * s.MutateRateMultiplier)
+	}
+	scaled.MutateAddNodeProb = scale(context.MutateAddNodeProb)
+	scaled.MutateAddLinkProb = scale(context.MutateAddLinkProb)
+	scaled.MutateConnectSensors = scale(context.MutateConnectSensors)
+	scaled.MutateLinkWeightsProb = scale(context.MutateLinkWeightsProb)
+	return &scaled
+}
+
 // Returns size of this Species, i.e. number of Organisms belonging to it
 func (s Species) size() int {
 	return len(s.Organisms)
@@ -234,16 +388,37 @@ func (s Species) size() int {
 
 // Returns Organism - champion among others (best fitness)
 func (s Species) findChampion() *Organism {
-	sort.Sort(sort.Reverse(s.Organisms))
+	s.SortByFitness()
 	return s.Organisms[0]
 }
 
+// Sorts organisms of this species by fitness in descending order, so the champion is always at Organisms[0].
+// Note, that after fitness adjustment the organisms are sorted by adjusted fitness.
+func (s *Species) SortByFitness() {
+	sort.Sort(sort.Reverse(s.Organisms))
+}
+
+// Finds the champion of this species and marks it as such without altering fitness of any organism. Returns the
+// champion or nil if species is empty.
+// NOTE: Invocation of this method will result of species organisms sorted by fitness in descending order.
+func (s *Species) MarkChampion() *Organism {
+	if len(s.Organisms) == 0 {
+		return nil
+	}
+	champ := s.findChampion()
+	for _, org := range s.Organisms {
+		org.isChampion = org == champ
+	}
+	return champ
+}
+
 // Perform mating and mutation to form next generation. The sorted_species is ordered to have best species in the beginning.
 // Returns list of baby organisms as a result of reproduction of all organisms in this species.
-func (s Species) reproduce(generation int, pop *Population, sorted_species []*Species, context *neat.NeatContext) ([]*Organism, error) {
+func (s *Species) reproduce(generation int, pop *Population, sorted_species []*Species, context *neat.NeatContext) ([]*Organism, error) {
 	//Check for a mistake
 	if s.ExpectedOffspring > 0 && len(s.Organisms) == 0 {
-		return nil, errors.New("SPECIES: ATTEMPT TO REPRODUCE OUT OF EMPTY SPECIES")
+		return nil, newReproductionError(EmptySpeciesReproductionError,
+			errors.New("SPECIES: ATTEMPT TO REPRODUCE OUT OF EMPTY SPECIES"))
 	}
 
 	// The number of Organisms in the old generation
@@ -257,6 +432,16 @@ func (s Species) reproduce(generation int, pop *Population, sorted_species []*Sp
 	// Flag the preservation of the champion
 	champ_clone_done := false
 
+	// Flag whether structural mutations are allowed in the current phase of search
+	complexify := !context.WeightsOnly && (!context.PhasedSearch || pop.Phase == ComplexifyingPhase)
+
+	// The context to mutate offspring with
+	mutation_context := context
+	if context.AdaptiveSpeciesMutation {
+		s.adaptMutationRate()
+		mutation_context = s.mutationContext(context)
+	}
+
 	// Create the designated number of offspring for the Species one at a time
 	for count := 0; count < s.ExpectedOffspring; count++ {
 		neat.DebugLog(fmt.Sprintf("SPECIES: Offspring #%d from %d, (species: %d)",
@@ -283,20 +468,24 @@ func (s Species) reproduce(generation int, pop *Population, sorted_species []*Sp
 			}
 
 			// Most superchamp offspring will have their connection weights mutated only
-			// The last offspring will be an exact duplicate of this super_champ
+			// The last offspring will be an exact duplicate of this super_champ with context.SuperChampCloneLastProb
 			// Note: Superchamp offspring only occur with stolen babies!
 			//      Settings used for published experiments did not use this
-			if the_champ.superChampOffspring > 1 {
-				if rand.Float64() < 0.8 || context.MutateAddLinkProb == 0.0 {
-					// Make sure no links get added when the system has link adding disabled
-					new_genome.mutateLinkWeights(context.WeightMutPower, 1.0, gaussianMutator)
+			mutate_last := the_champ.superChampOffspring == 1 && context.SuperChampCloneLastProb > 0 &&
+				rand.Float64() >= context.SuperChampCloneLastProb
+			if the_champ.superChampOffspring > 1 || mutate_last {
+				if rand.Float64() < 0.8 || context.MutateAddLinkProb == 0.0 || !complexify {
+					// Make sure no links get added when the system has link adding disabled or structural
+					// mutations are not allowed in the current phase of search
+					new_genome.mutateLinkWeights(context.EffectiveWeightMutPower(generation), 1.0, context.WeightCap, context.OutputWeightMutBias, gaussianMutator)
 				} else {
 					// Sometimes we add a link to a superchamp
 					new_genome.Genesis(generation)
-					if _, err = new_genome.mutateAddLink(pop, context); err != nil {
-						return nil, err
+					if mut_struct_baby, err = new_genome.mutateAddLink(pop, context); err != nil {
+						return nil, newReproductionError(MutationFailedReproductionError, err)
 					}
-					mut_struct_baby = true;
+					// the phenotype built before mutation is stale and will be rebuilt for baby
+					new_genome.Phenotype = nil
 				}
 			}
 
@@ -305,6 +494,8 @@ func (s Species) reproduce(generation int, pop *Population, sorted_species []*Sp
 			if err != nil {
 				return nil, err
 			}
+			baby.Meta = inheritMeta(mom, nil, context.MetaInheritance)
+			baby.origin = superChampOrigin
 
 			if the_champ.superChampOffspring == 1 {
 				if the_champ.isPopulationChampion {
@@ -314,7 +505,7 @@ func (s Species) reproduce(generation int, pop *Population, sorted_species []*Sp
 			}
 
 			the_champ.superChampOffspring--
-		} else if !champ_clone_done && s.ExpectedOffspring > 5 {
+		} else if !champ_clone_done && (s.ExpectedOffspring > 5 || context.AlwaysElitePerSpecies) {
 			neat.DebugLog("SPECIES: Clone species champion")
 
 			// If we have a Species champion, just clone it
@@ -331,6 +522,15 @@ func (s Species) reproduce(generation int, pop *Population, sorted_species []*Sp
 			if err != nil {
 				return nil, err
 			}
+			baby.Meta = inheritMeta(mom, nil, context.MetaInheritance)
+			baby.origin = cloneOrigin
+			if context.PreserveCloneFitness {
+				// The genome is identical, thus the fitness is the same
+				baby.Fitness = mom.originalFitness
+				baby.originalFitness = mom.originalFitness
+				baby.Error = mom.Error
+				baby.FitnessInherited = true
+			}
 
 		} else if rand.Float64() < context.MutateOnlyProb || pool_size == 1 {
 			neat.DebugLog("SPECIES: Reproduce by applying random mutation:")
@@ -344,39 +544,8 @@ func (s Species) reproduce(generation int, pop *Population, sorted_species []*Sp
 			}
 
 			// Do the mutation depending on probabilities of various mutations
-			if rand.Float64() < context.MutateAddNodeProb {
-				neat.DebugLog("SPECIES: ---> mutateAddNode")
-
-				// Mutate add node
-				if _, err = new_genome.mutateAddNode(pop, context); err != nil {
-					return nil, err
-				}
-				mut_struct_baby = true
-			} else if rand.Float64() < context.MutateAddLinkProb {
-				neat.DebugLog("SPECIES: ---> mutateAddLink")
-
-				// Mutate add link
-				new_genome.Genesis(generation)
-				if _, err = new_genome.mutateAddLink(pop, context); err != nil {
-					return nil, err
-				}
-				mut_struct_baby = true
-			} else if rand.Float64() < context.MutateConnectSensors {
-				neat.DebugLog("SPECIES: ---> mutateConnectSensors")
-				if link_added, err := new_genome.mutateConnectSensors(pop, context); err != nil {
-					return nil, err
-				} else {
-					mut_struct_baby = link_added
-				}
-			}
-
-			if !mut_struct_baby {
-				neat.DebugLog("SPECIES: ---> mutateAllNonstructural")
-
-				// If we didn't do a structural mutation, we do the other kinds
-				if _, err = new_genome.mutateAllNonstructural(context); err != nil {
-					return nil, err
-				}
+			if mut_struct_baby, err = s.mutateOffspring(new_genome, generation, pop, complexify, mutation_context); err != nil {
+				return nil, err
 			}
 
 			// Create the new baby organism
@@ -384,6 +553,8 @@ func (s Species) reproduce(generation int, pop *Population, sorted_species []*Sp
 			if err != nil {
 				return nil, err
 			}
+			baby.Meta = inheritMeta(mom, nil, context.MetaInheritance)
+			baby.origin = mutateOrigin
 		} else {
 			neat.DebugLog("SPECIES: Reproduce by mating:")
 
@@ -403,20 +574,7 @@ func (s Species) reproduce(generation int, pop *Population, sorted_species []*Sp
 				neat.DebugLog("SPECIES: ---> mate outside species")
 
 				// Mate outside Species
-				rand_species := s
-
-				// Select a random species
-				giveup := 0
-				for ; rand_species.Id == s.Id && giveup < 5; {
-					// Choose a random species tending towards better species
-					rand_mult := rand.Float64() / 4.0
-					// This tends to select better species
-					rand_species_num := int(math.Floor(rand_mult * float64(len(sorted_species))))
-					rand_species = *sorted_species[rand_species_num]
-
-					giveup++
-				}
-				dad = rand_species.Organisms[0]
+				dad = s.findOutsideMate(mom, sorted_species, context)
 			}
 
 			// Perform mating based on probabilities of different mating types
@@ -426,9 +584,10 @@ func (s Species) reproduce(generation int, pop *Population, sorted_species []*Sp
 				neat.DebugLog("SPECIES: ------> mateMultipoint")
 
 				// mate multipoint baby
-				new_genome, err = mom.Genotype.mateMultipoint(dad.Genotype, count, mom.originalFitness, dad.originalFitness)
+				new_genome, err = mom.Genotype.mateMultipoint(dad.Genotype, count, mom.originalFitness, dad.originalFitness,
+					context.DisjointInheritance)
 				if err != nil {
-					return nil, err
+					return nil, newReproductionError(MatingFailedReproductionError, err)
 				}
 			} else if rand.Float64() < context.MateMultipointAvgProb / (context.MateMultipointAvgProb + context.MateSinglepointProb) {
 				neat.DebugLog("SPECIES: ------> mateMultipointAvg")
@@ -436,17 +595,20 @@ func (s Species) reproduce(generation int, pop *Population, sorted_species []*Sp
 				// mate multipoint_avg baby
 				new_genome, err = mom.Genotype.mateMultipointAvg(dad.Genotype, count, mom.originalFitness, dad.originalFitness)
 				if err != nil {
-					return nil, err
+					return nil, newReproductionError(MatingFailedReproductionError, err)
 				}
 			} else {
 				neat.DebugLog("SPECIES: ------> mateSinglepoint")
 
 				new_genome, err = mom.Genotype.mateSinglepoint(dad.Genotype, count)
 				if err != nil {
-					return nil, err
+					return nil, newReproductionError(MatingFailedReproductionError, err)
 				}
 			}
 
+			if context.SyncMutationNumbers {
+				new_genome.SyncMutationNumbers()
+			}
 			mate_baby = true
 
 			// Determine whether to mutate the baby's Genome
@@ -457,39 +619,8 @@ func (s Species) reproduce(generation int, pop *Population, sorted_species []*Sp
 				neat.DebugLog("SPECIES: ------> Mutatte baby genome:")
 
 				// Do the mutation depending on probabilities of  various mutations
-				if rand.Float64() < context.MutateAddNodeProb {
-					neat.DebugLog("SPECIES: ---------> mutateAddNode")
-
-					// mutate_add_node
-					if _, err = new_genome.mutateAddNode(pop, context); err != nil {
-						return nil, err
-					}
-					mut_struct_baby = true
-				} else if rand.Float64() < context.MutateAddLinkProb {
-					neat.DebugLog("SPECIES: ---------> mutateAddLink")
-
-					// mutate_add_link
-					new_genome.Genesis(generation)
-					if _, err = new_genome.mutateAddLink(pop, context); err != nil {
-						return nil, err
-					}
-					mut_struct_baby = true
-				} else if rand.Float64() < context.MutateConnectSensors {
-					neat.DebugLog("SPECIES: ---> mutateConnectSensors")
-					if link_added, err := new_genome.mutateConnectSensors(pop, context); err != nil {
-						return nil, err
-					} else {
-						mut_struct_baby = link_added
-					}
-				}
-
-				if !mut_struct_baby {
-					neat.DebugLog("SPECIES: ---> mutateAllNonstructural")
-
-					// If we didn't do a structural mutation, we do the other kinds
-					if _, err := new_genome.mutateAllNonstructural(context); err != nil {
-						return nil, err
-					}
+				if mut_struct_baby, err = s.mutateOffspring(new_genome, generation, pop, complexify, mutation_context); err != nil {
+					return nil, err
 				}
 			}
 			// Create the new baby organism
@@ -497,17 +628,164 @@ func (s Species) reproduce(generation int, pop *Population, sorted_species []*Sp
 			if err != nil {
 				return nil, err
 			}
+			baby.Meta = inheritMeta(mom, dad, context.MetaInheritance)
+			baby.origin = mateOrigin
 		} // end else
 
 		baby.mutationStructBaby = mut_struct_baby
 		baby.mateBaby = mate_baby
+		baby.parentSpecies = s
 
 		babies = append(babies, baby)
+		s.OffspringProduced++
 
 	} // end for count := 0
 	return babies, nil
 }
 
+// Mutates the offspring genome. If context.MutationOperators is set, the single operator will be sampled from it
+// according to context.MutationOperatorsProb, otherwise the built-in mutations will be applied with probabilities
+// from the context. Only non structural mutations and disabling of genes are applied if complexify is false.
+// Returns true if structural mutation was done.
+func (s *Species) mutateOffspring(new_genome *Genome, generation int, pop *Population, complexify bool, context *neat.NeatContext) (bool, error) {
+	if complexify && len(context.MutationOperators) > 0 {
+		index := utils.SingleRouletteThrow(context.MutationOperatorsProb)
+		if index < 0 || index >= len(context.MutationOperators) {
+			return false, newReproductionError(MutationFailedReproductionError,
+				errors.New(fmt.Sprintf("SPECIES: Unexpected mutation operator index: %d", index)))
+		}
+		neat.DebugLog(fmt.Sprintf("SPECIES: ---> %s", context.MutationOperators[index]))
+
+		op, err := MutationOperatorByName(context.MutationOperators[index])
+		if err != nil {
+			return false, newReproductionError(MutationFailedReproductionError, err)
+		}
+		mut_struct_baby, err := op.Mutate(new_genome, pop, context)
+		if err != nil {
+			return false, newReproductionError(MutationFailedReproductionError, err)
+		}
+		// the phenotype built during mutation is stale and will be rebuilt for baby
+		new_genome.Phenotype = nil
+		return mut_struct_baby, nil
+	}
+
+	mut_struct_baby := false
+	var err error
+	if complexify && rand.Float64() < context.MutateAddNodeProb {
+		neat.DebugLog("SPECIES: ---> mutateAddNode")
+
+		// Mutate add node
+		if mut_struct_baby, err = new_genome.mutateAddNode(pop, context); err != nil {
+			return false, newReproductionError(MutationFailedReproductionError, err)
+		}
+	} else if complexify && rand.Float64() < context.MutateAddLinkProb {
+		neat.DebugLog("SPECIES: ---> mutateAddLink")
+
+		// Mutate add link
+		new_genome.Genesis(generation)
+		if mut_struct_baby, err = new_genome.mutateAddLink(pop, context); err != nil {
+			return false, newReproductionError(MutationFailedReproductionError, err)
+		}
+		// the phenotype built before mutation is stale and will be rebuilt for baby
+		new_genome.Phenotype = nil
+	} else if complexify && rand.Float64() < context.MutateConnectSensors {
+		neat.DebugLog("SPECIES: ---> mutateConnectSensors")
+		if mut_struct_baby, err = new_genome.mutateConnectSensors(pop, context); err != nil {
+			return false, newReproductionError(MutationFailedReproductionError, err)
+		}
+	}
+
+	if !mut_struct_baby {
+		neat.DebugLog("SPECIES: ---> mutateAllNonstructural")
+
+		// If we didn't do a structural mutation, we do the other kinds
+		if _, err = new_genome.mutateAllNonstructural(generation, context); err != nil {
+			return false, newReproductionError(MutationFailedReproductionError, err)
+		}
+		if complexify && context.MutateNeutralProb > 0 && rand.Float64() < context.MutateNeutralProb {
+			neat.DebugLog("SPECIES: ---> mutateNeutral")
+			if _, err = new_genome.mutateNeutral(pop, context); err != nil {
+				return false, newReproductionError(MutationFailedReproductionError, err)
+			}
+		}
+		if !complexify && !context.WeightsOnly {
+			// simplify genome during simplifying phase
+			if _, err = new_genome.mutateGeneDisable(); err != nil {
+				return false, newReproductionError(MutationFailedReproductionError, err)
+			}
+		}
+	}
+	return mut_struct_baby, nil
+}
+
+// Finds the mate for given organism among champions of other species tending towards better species. If the mate
+// found is too distant from the organism (see context.MaxInterspeciesCompat) the random mate within this species will
+// be returned to avoid destructive crossover.
+func (s *Species) findOutsideMate(mom *Organism, sorted_species []*Species, context *neat.NeatContext) *Organism {
+	rand_species := s
+
+	if context.InterspeciesCompatFactor > 0 {
+		// Keep only species compatible enough with this one
+		sorted_species = s.compatibleSpecies(sorted_species, context)
+		if len(sorted_species) == 0 {
+			neat.DebugLog("SPECIES: ---> no compatible species found, mate within species")
+
+			// Mate within Species
+			return s.Organisms[rand.Int31n(int32(len(s.Organisms)))]
+		}
+	}
+
+	bias := context.InterspeciesBias
+	if bias < 1.0 {
+		bias = 4.0
+	}
+
+	// Select a random species
+	giveup := 0
+	for ; rand_species.Id == s.Id && giveup < 5; {
+		// Choose a random species tending towards better species
+		rand_mult := rand.Float64() / bias
+		// This tends to select better species
+		rand_species_num := int(math.Floor(rand_mult * float64(len(sorted_species))))
+		rand_species = sorted_species[rand_species_num]
+
+		giveup++
+	}
+	if rand_species.Id == s.Id || len(rand_species.Organisms) == 0 {
+		neat.DebugLog("SPECIES: ---> no other species found, mate within species")
+
+		// Mate within Species
+		return s.Organisms[rand.Int31n(int32(len(s.Organisms)))]
+	}
+	dad := rand_species.Organisms[0]
+
+	if context.MaxInterspeciesCompat > 0 &&
+		mom.Genotype.compatibility(dad.Genotype, context) > context.MaxInterspeciesCompat {
+		neat.DebugLog("SPECIES: ---> the outside mate is too distant, mate within species")
+
+		// Mate within Species
+		dad = s.Organisms[rand.Int31n(int32(len(s.Organisms)))]
+	}
+	return dad
+}
+
+// Returns other species from provided list which representatives are within context.InterspeciesCompatFactor *
+// context.CompatThreshold from the representative of this species. The order of species preserved.
+func (s *Species) compatibleSpecies(sorted_species []*Species, context *neat.NeatContext) []*Species {
+	max_compat := context.InterspeciesCompatFactor * context.CompatThreshold
+	rep := s.firstOrganism()
+	compatible := make([]*Species, 0)
+	for _, sp := range sorted_species {
+		if sp.Id == s.Id || len(sp.Organisms) == 0 {
+			continue
+		}
+		if rep.Genotype.compatibility(sp.firstOrganism().Genotype, context) <= max_compat {
+			compatible = append(compatible, sp)
+		}
+	}
+	return compatible
+}
+
 func createFirstSpecies(pop *Population, baby *Organism) {
 	neat.DebugLog(fmt.Sprintf("SPECIES: Create first species for baby organism [%d]", baby.Genotype.Id))
 
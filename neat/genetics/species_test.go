@@ -2,10 +2,16 @@ package genetics
 
 import (
 	"math/rand"
+	"math"
 	"testing"
 	"github.com/yaricom/goNEAT/neat"
 	"sort"
 	"bytes"
+	"errors"
+	"strings"
+	"encoding/csv"
+	"strconv"
+	"fmt"
 )
 
 func buildSpeciesWithOrganisms(id int) (*Species, error) {
@@ -23,6 +29,23 @@ func buildSpeciesWithOrganisms(id int) (*Species, error) {
 	return sp, nil
 }
 
+// Registers mutation operator with given name, which tags mutated genomes, as the only operator of provided context.
+// The operator is unregistered when test finishes. Returns the map of tagged genomes.
+func registerTagMutation(t *testing.T, name string, conf *neat.NeatContext) map[*Genome]bool {
+	tagged := make(map[*Genome]bool)
+	RegisterMutationOperator(name, MutationOperatorFunc(
+		func(g *Genome, pop *Population, context *neat.NeatContext) (bool, error) {
+			tagged[g] = true
+			return false, nil
+		}))
+	t.Cleanup(func() {
+		UnregisterMutationOperator(name)
+	})
+	conf.MutationOperators = []string{name}
+	conf.MutationOperatorsProb = []float64{1.0}
+	return tagged
+}
+
 func TestSpecies_Write(t *testing.T) {
 	sp, err := buildSpeciesWithOrganisms(1)
 
@@ -35,6 +58,78 @@ func TestSpecies_Write(t *testing.T) {
 	sp.Write(out_buf)
 }
 
+func TestSpecies_WriteVerbose(t *testing.T) {
+	sp, err := buildSpeciesWithOrganisms(1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	// make the last organism different from representative
+	gnome, err := sp.Organisms[2].Genotype.duplicate(3)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	gnome.Genes[1].MutationNum = 2.0
+	sp.Organisms[2].Genotype = gnome
+
+	conf := neat.NeatContext{
+		DisjointCoeff:0.5,
+		ExcessCoeff:0.5,
+		MutdiffCoeff:0.5,
+	}
+	out_buf := bytes.NewBufferString("")
+	sp.WriteVerbose(out_buf, &conf)
+
+	out_str := out_buf.String()
+	if count := strings.Count(out_str, "Compatibility: "); count != len(sp.Organisms) {
+		t.Error("count != len(sp.Organisms)", count)
+	}
+	if !strings.Contains(out_str, "Compatibility: 0.333") {
+		t.Error("No distance of drifted organism found", out_str)
+	}
+}
+
+// Tests Species WriteCSV
+func TestSpecies_WriteCSV(t *testing.T) {
+	sp, err := buildSpeciesWithOrganisms(1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	champ := sp.MarkChampion()
+
+	out_buf := bytes.NewBufferString("")
+	if err = sp.WriteCSV(out_buf); err != nil {
+		t.Error(err)
+		return
+	}
+	records, err := csv.NewReader(out_buf).ReadAll()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(records) != len(sp.Organisms) + 1 {
+		t.Error("len(records) != len(sp.Organisms) + 1", len(records))
+		return
+	}
+	if records[0][0] != "genome_id" || records[0][6] != "champion" {
+		t.Error("Wrong CSV header", records[0])
+	}
+	for i, org := range sp.Organisms {
+		record := records[i + 1]
+		if record[1] != strconv.FormatFloat(org.Fitness, 'g', -1, 64) {
+			t.Error("Wrong fitness", record[1], org.Fitness)
+		}
+		if record[5] != "3" {
+			t.Error("Wrong number of enabled genes", record[5])
+		}
+		if is_champ := record[6] == "true"; is_champ != (org == champ) {
+			t.Error("Wrong champion flag", record[6], org.Fitness)
+		}
+	}
+}
+
 // Tests Species adjustFitness
 func TestSpecies_adjustFitness(t *testing.T)  {
 	sp, err := buildSpeciesWithOrganisms(1)
@@ -67,6 +162,155 @@ func TestSpecies_adjustFitness(t *testing.T)  {
 	}
 }
 
+// Tests Species adjustFitness with configured stagnation penalty
+func TestSpecies_adjustFitness_stagnationPenalty(t *testing.T) {
+	for _, penalty := range []float64{0.5, 0.0} {
+		sp, err := buildSpeciesWithOrganisms(1)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		// force stagnation
+		sp.Age = 20
+		sp.AgeOfLastImprovement = 1
+
+		conf := neat.NeatContext{
+			DropOffAge:5,
+			SurvivalThresh:0.5,
+			AgeSignificance:1.0,
+			StagnationPenalty:penalty,
+		}
+		sp.adjustFitness(&conf)
+
+		for _, org := range sp.Organisms {
+			expected := org.originalFitness * penalty / float64(len(sp.Organisms))
+			if math.Abs(org.Fitness - expected) > 1e-9 {
+				t.Error("org.Fitness != expected", org.Fitness, expected, penalty)
+			}
+		}
+	}
+}
+
+// Tests Species adjustFitness with disabled fitness sharing
+func TestSpecies_adjustFitness_noSharing(t *testing.T) {
+	sp, err := buildSpeciesWithOrganisms(1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	conf := neat.NeatContext{
+		DropOffAge:5,
+		SurvivalThresh:0.5,
+		AgeSignificance:0.5,
+		DisableFitnessSharing:true,
+	}
+	sp.adjustFitness(&conf)
+
+	for _, org := range sp.Organisms {
+		// young species age boost still applied
+		expected := org.originalFitness * conf.AgeSignificance
+		if math.Abs(org.Fitness - expected) > 1e-9 {
+			t.Error("org.Fitness != expected", org.Fitness, expected)
+		}
+	}
+}
+
+// Tests Species adjustFitness logs warning about representative drift
+func TestSpecies_adjustFitness_representativeDrift(t *testing.T) {
+	sp, err := buildSpeciesWithOrganisms(1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	// make the most fit organism far from the current representative
+	gnome := buildTestGenome(2)
+	for i := 0; i < 5; i++ {
+		gnome.Genes = append(gnome.Genes, NewGene(1.0, gnome.Nodes[0], gnome.Nodes[3], false, int64(10 + i), 0))
+	}
+	sp.Organisms[2].Genotype = gnome
+
+	var messages []string
+	warn_log := neat.WarnLog
+	neat.WarnLog = func(message string) {
+		messages = append(messages, message)
+	}
+	defer func() {
+		neat.WarnLog = warn_log
+	}()
+
+	conf := neat.NeatContext{
+		DropOffAge:5,
+		SurvivalThresh:0.5,
+		AgeSignificance:1.0,
+		CompatThreshold:1.0,
+		ExcessCoeff:1.0,
+		DisjointCoeff:1.0,
+		GenCompatMethod:1,
+		LogRepresentativeDrift:true,
+	}
+	sp.adjustFitness(&conf)
+
+	if len(messages) != 1 {
+		t.Error("len(messages) != 1", len(messages))
+	} else if !strings.Contains(messages[0], "Representative of species [1] drifted") {
+		t.Error("Unexpected warning", messages[0])
+	}
+
+	// no warning when representative is the same
+	messages = nil
+	sp.adjustFitness(&conf)
+	if len(messages) != 0 {
+		t.Error("len(messages) != 0", len(messages))
+	}
+}
+
+// Tests Species adjustFitness with tournament survival selection
+func TestSpecies_adjustFitness_tournament(t *testing.T) {
+	rand.Seed(42)
+	conf := neat.NeatContext{
+		DropOffAge:5,
+		SurvivalThresh:0.5,
+		AgeSignificance:1.0,
+		SurvivalSelection:neat.TournamentSurvival,
+	}
+	size := 10
+	num_parents := 6
+	low_rank_survived := false
+	for run := 0; run < 100; run++ {
+		sp := NewSpecies(1)
+		for i := 0; i < size; i++ {
+			org, err := NewOrganism(float64(i + 1), buildTestGenome(i + 1), 1)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			sp.addOrganism(org)
+		}
+		sp.adjustFitness(&conf)
+
+		eliminated := 0
+		for i, org := range sp.Organisms {
+			if org.toEliminate {
+				eliminated++
+			} else if i >= num_parents {
+				low_rank_survived = true
+			}
+		}
+		if sp.Organisms[0].toEliminate {
+			t.Error("Champion was eliminated at run:", run)
+			return
+		}
+		if eliminated != size - num_parents {
+			t.Error("eliminated != size - num_parents", eliminated)
+			return
+		}
+	}
+	if !low_rank_survived {
+		t.Error("No organism ranked below survival threshold survived")
+	}
+}
+
 // Tests Species countOffspring
 func TestSpecies_countOffspring(t *testing.T) {
 	sp, err := buildSpeciesWithOrganisms(1)
@@ -147,6 +391,58 @@ func TestSpecies_findChampion(t *testing.T) {
 
 }
 
+func TestSpecies_SortByFitness(t *testing.T) {
+	sp, err := buildSpeciesWithOrganisms(1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	sp.SortByFitness()
+	for i := 1; i < len(sp.Organisms); i++ {
+		if sp.Organisms[i - 1].Fitness < sp.Organisms[i].Fitness {
+			t.Error("Organisms not in descending fitness order at", i, sp.Organisms[i - 1].Fitness, sp.Organisms[i].Fitness)
+		}
+	}
+	if sp.Organisms[0].Fitness != 15.0 {
+		t.Error("sp.Organisms[0].Fitness != 15.0", sp.Organisms[0].Fitness)
+	}
+}
+
+func TestSpecies_MarkChampion(t *testing.T) {
+	sp, err := buildSpeciesWithOrganisms(1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	fitness := make(map[*Organism]float64)
+	for _, org := range sp.Organisms {
+		fitness[org] = org.Fitness
+	}
+
+	champ := sp.MarkChampion()
+	if champ == nil {
+		t.Error("champ == nil")
+		return
+	}
+	if champ.Fitness != 15.0 {
+		t.Error("champ.Fitness != 15.0", champ.Fitness)
+	}
+	for _, org := range sp.Organisms {
+		if org.isChampion != (org == champ) {
+			t.Error("org.isChampion != (org == champ)", org.isChampion)
+		}
+		if org.Fitness != fitness[org] {
+			t.Error("org.Fitness != fitness[org]", org.Fitness, fitness[org])
+		}
+	}
+
+	empty := NewSpecies(2)
+	if empty.MarkChampion() != nil {
+		t.Error("empty.MarkChampion() != nil")
+	}
+}
+
 func TestSpecies_removeOrganism(t *testing.T) {
 	sp, err := buildSpeciesWithOrganisms(1)
 	if err != nil {
@@ -200,6 +496,12 @@ func TestSpecies_reproduce_fail(t *testing.T) {
 	if err == nil {
 		t.Error("err == nil")
 	}
+	var r_err *ReproductionError
+	if !errors.As(err, &r_err) {
+		t.Error("err is not ReproductionError", err)
+	} else if r_err.Kind != EmptySpeciesReproductionError {
+		t.Error("r_err.Kind != EmptySpeciesReproductionError", r_err.Kind)
+	}
 }
 
 // Tests Species reproduce success
@@ -248,4 +550,492 @@ func TestSpecies_reproduce(t *testing.T) {
 	if len(babies) != pop.Species[0].ExpectedOffspring {
 		t.Error("Wrong number of babies was created", len(babies))
 	}
+	if pop.Species[0].OffspringProduced != len(babies) {
+		t.Error("pop.Species[0].OffspringProduced != len(babies)", pop.Species[0].OffspringProduced)
+	}
+}
+
+func TestSpecies_reproduce_phasedSearch(t *testing.T) {
+	rand.Seed(42)
+	in, out, nmax, n := 3, 2, 15, 3
+
+	// Configuration
+	conf := neat.NewNeatContext()
+	conf.DropOffAge = 5
+	conf.SurvivalThresh = 0.5
+	conf.AgeSignificance = 0.5
+	conf.PopSize = 30
+	conf.CompatThreshold = 0.6
+	conf.MutateOnlyProb = 1.0
+	conf.MutateAddNodeProb = 0.5
+	conf.MutateAddLinkProb = 1.0
+	conf.NewLinkTries = 20
+	conf.PhasedSearch = true
+	conf.SimplifyThreshold = 1000.0
+	conf.ComplexifyThreshold = 5.0
+	neat.LogLevel = neat.LogLevelInfo
+
+	gen := newGenomeRand(1, in, out, n, nmax, false, 0.8)
+	pop, err := NewPopulation(gen, conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	sorted_species := make([]*Species, len(pop.Species))
+	copy(sorted_species, pop.Species)
+	pop.Species[0].ExpectedOffspring = 5
+
+	// complexity below simplify threshold - structural mutations allowed
+	pop.updateSearchPhase(conf)
+	if pop.Phase != ComplexifyingPhase {
+		t.Error("pop.Phase != ComplexifyingPhase", pop.Phase)
+	}
+	babies, err := pop.Species[0].reproduce(1, pop, sorted_species, conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	struct_babies := 0
+	for _, baby := range babies {
+		if baby.mutationStructBaby {
+			struct_babies++
+		}
+	}
+	if struct_babies == 0 {
+		t.Error("No structural mutations in complexifying phase")
+	}
+
+	// drive complexity above simplify threshold
+	conf.SimplifyThreshold = pop.MeanComplexity() - 1.0
+	pop.updateSearchPhase(conf)
+	if pop.Phase != SimplifyingPhase {
+		t.Error("pop.Phase != SimplifyingPhase", pop.Phase)
+		return
+	}
+	// the super champion offspring also must not grow
+	pop.Species[0].ExpectedOffspring = 10
+	pop.Species[0].Organisms[0].superChampOffspring = 10
+	babies, err = pop.Species[0].reproduce(1, pop, sorted_species, conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for _, baby := range babies {
+		if baby.mutationStructBaby {
+			t.Error("Structural mutation in simplifying phase", baby.Genotype)
+		}
+		if len(baby.Genotype.Nodes) > len(gen.Nodes) || len(baby.Genotype.Genes) > len(gen.Genes) {
+			t.Error("Genome structure grown in simplifying phase", baby.Genotype)
+		}
+	}
+
+	// complexity below complexify threshold - switch back
+	conf.ComplexifyThreshold = pop.MeanComplexity() + 1.0
+	pop.updateSearchPhase(conf)
+	if pop.Phase != ComplexifyingPhase {
+		t.Error("pop.Phase != ComplexifyingPhase", pop.Phase)
+	}
+}
+
+func TestSpecies_reproduce_mutationOperators(t *testing.T) {
+	rand.Seed(42)
+	in, out, nmax, n := 3, 2, 15, 3
+
+	// Configuration
+	conf := neat.NewNeatContext()
+	conf.DropOffAge = 5
+	conf.SurvivalThresh = 0.5
+	conf.AgeSignificance = 0.5
+	conf.PopSize = 30
+	conf.CompatThreshold = 0.6
+	conf.MutateOnlyProb = 1.0
+
+	// register custom operator which tags mutated genomes
+	tagged := registerTagMutation(t, "TagMutation", conf)
+
+	gen := newGenomeRand(1, in, out, n, nmax, false, 0.8)
+	pop, err := NewPopulation(gen, conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	sorted_species := make([]*Species, len(pop.Species))
+	copy(sorted_species, pop.Species)
+	pop.Species[0].ExpectedOffspring = 5
+
+	babies, err := pop.Species[0].reproduce(1, pop, sorted_species, conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(babies) != 5 {
+		t.Error("len(babies) != 5", len(babies))
+	}
+	for _, baby := range babies {
+		if !tagged[baby.Genotype] {
+			t.Error("Custom mutation operator was not applied", baby.Genotype)
+		}
+	}
+
+	// unknown operator
+	conf.MutationOperators = []string{"UnknownMutation"}
+	if _, err = pop.Species[0].reproduce(1, pop, sorted_species, conf); err == nil {
+		t.Error("Error expected for unknown mutation operator")
+	}
+
+	// unregistered operator
+	UnregisterMutationOperator("TagMutation")
+	if _, err = MutationOperatorByName("TagMutation"); err == nil {
+		t.Error("Error expected for unregistered mutation operator")
+	}
+}
+
+func TestSpecies_reproduce_alwaysElite(t *testing.T) {
+	rand.Seed(42)
+	in, out, nmax, n := 3, 2, 15, 3
+
+	// Configuration
+	conf := neat.NewNeatContext()
+	conf.DropOffAge = 5
+	conf.SurvivalThresh = 0.5
+	conf.AgeSignificance = 0.5
+	conf.PopSize = 30
+	conf.CompatThreshold = 0.6
+	conf.MutateOnlyProb = 1.0
+	conf.AlwaysElitePerSpecies = true
+
+	// register custom operator which tags mutated genomes
+	tagged := registerTagMutation(t, "EliteTagMutation", conf)
+
+	gen := newGenomeRand(1, in, out, n, nmax, false, 0.8)
+	pop, err := NewPopulation(gen, conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	sorted_species := make([]*Species, len(pop.Species))
+	copy(sorted_species, pop.Species)
+	sp := pop.Species[0]
+	sp.ExpectedOffspring = 1
+	champ := sp.Organisms[0]
+
+	babies, err := sp.reproduce(1, pop, sorted_species, conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(babies) != 1 {
+		t.Error("len(babies) != 1", len(babies))
+		return
+	}
+	if tagged[babies[0].Genotype] {
+		t.Error("Champion clone was mutated")
+	}
+	if eq, err := champ.Genotype.IsEqual(babies[0].Genotype); !eq {
+		t.Error("Champion was not cloned verbatim", err)
+	}
+}
+
+func TestSpecies_reproduce_preserveCloneFitness(t *testing.T) {
+	rand.Seed(42)
+	in, out, nmax, n := 3, 2, 15, 3
+
+	// Configuration
+	conf := neat.NewNeatContext()
+	conf.DropOffAge = 5
+	conf.SurvivalThresh = 0.5
+	conf.AgeSignificance = 0.5
+	conf.PopSize = 30
+	conf.CompatThreshold = 0.6
+	conf.AlwaysElitePerSpecies = true
+	conf.PreserveCloneFitness = true
+
+	gen := newGenomeRand(1, in, out, n, nmax, false, 0.8)
+	pop, err := NewPopulation(gen, conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	sorted_species := make([]*Species, len(pop.Species))
+	copy(sorted_species, pop.Species)
+	sp := pop.Species[0]
+	sp.ExpectedOffspring = 1
+	champ := sp.Organisms[0]
+	champ.Fitness = 10.0
+	champ.originalFitness = 42.0
+	champ.Error = 0.5
+
+	babies, err := sp.reproduce(1, pop, sorted_species, conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(babies) != 1 {
+		t.Error("len(babies) != 1", len(babies))
+		return
+	}
+	baby := babies[0]
+	if baby.Fitness != champ.originalFitness || baby.originalFitness != champ.originalFitness {
+		t.Error("The champion fitness expected", baby.Fitness, baby.originalFitness)
+	}
+	if baby.Error != champ.Error {
+		t.Error("baby.Error != champ.Error", baby.Error, champ.Error)
+	}
+	if !baby.FitnessInherited {
+		t.Error("The clone expected to be marked as not requiring evaluation")
+	}
+}
+
+func TestSpecies_reproduce_adaptiveMutation(t *testing.T) {
+	rand.Seed(42)
+	in, out, nmax, n := 3, 2, 15, 3
+
+	// Configuration
+	conf := neat.NewNeatContext()
+	conf.DropOffAge = 5
+	conf.SurvivalThresh = 0.5
+	conf.AgeSignificance = 0.5
+	conf.PopSize = 30
+	conf.CompatThreshold = 0.6
+	conf.MutateAddLinkProb = 0.6
+	conf.AdaptiveSpeciesMutation = true
+
+	gen := newGenomeRand(1, in, out, n, nmax, false, 0.8)
+	pop, err := NewPopulation(gen, conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	sorted_species := make([]*Species, len(pop.Species))
+	copy(sorted_species, pop.Species)
+	sp := pop.Species[0]
+	sp.ExpectedOffspring = 2
+	// make species stagnant
+	sp.Age = 10
+	sp.AgeOfLastImprovement = 2
+
+	for i := 0; i < 3; i++ {
+		if _, err = sp.reproduce(1, pop, sorted_species, conf); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+	if sp.MutateRateMultiplier <= 1.0 {
+		t.Error("The mutation rate multiplier of stagnant species expected to rise above 1", sp.MutateRateMultiplier)
+	}
+	if m_conf := sp.mutationContext(conf); m_conf.MutateAddLinkProb <= conf.MutateAddLinkProb {
+		t.Error("The add link probability expected to be scaled up", m_conf.MutateAddLinkProb)
+	}
+
+	// improving species
+	sp.AgeOfLastImprovement = sp.Age
+	for i := 0; i < 20; i++ {
+		sp.adaptMutationRate()
+	}
+	if sp.MutateRateMultiplier != minMutateRateMultiplier {
+		t.Error("sp.MutateRateMultiplier != minMutateRateMultiplier", sp.MutateRateMultiplier)
+	}
+}
+
+func TestSpecies_reproduce_metaInheritance(t *testing.T) {
+	rand.Seed(42)
+	in, out, nmax, n := 3, 2, 15, 3
+
+	// Configuration
+	conf := neat.NewNeatContext()
+	conf.DropOffAge = 5
+	conf.SurvivalThresh = 0.5
+	conf.AgeSignificance = 0.5
+	conf.PopSize = 30
+	conf.CompatThreshold = 0.6
+	conf.MutateOnlyProb = 0.0
+	conf.MateMultipointProb = 1.0
+	conf.MetaInheritance = neat.MergeMetaInheritance
+
+	gen := newGenomeRand(1, in, out, n, nmax, false, 0.8)
+	pop, err := NewPopulation(gen, conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	sorted_species := make([]*Species, len(pop.Species))
+	copy(sorted_species, pop.Species)
+	sp := pop.Species[0]
+	for i, org := range sp.Organisms {
+		org.Meta = map[string]string{"experiment":"A", fmt.Sprintf("parent_%d", i):"true"}
+	}
+	sp.ExpectedOffspring = 5
+
+	babies, err := sp.reproduce(1, pop, sorted_species, conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	mated := 0
+	for _, baby := range babies {
+		if !baby.mateBaby {
+			continue
+		}
+		mated++
+		if baby.Meta["experiment"] != "A" {
+			t.Error("Mated baby doesn't inherit metadata", baby.Meta)
+		}
+		if len(baby.Meta) < 2 {
+			t.Error("Mated baby doesn't inherit parent's key", baby.Meta)
+		}
+	}
+	if mated == 0 {
+		t.Error("No mated babies produced")
+	}
+
+	// merge of both parents' metadata, mom's values take precedence
+	mom := &Organism{Meta:map[string]string{"experiment":"A", "mom":"true"}}
+	dad := &Organism{Meta:map[string]string{"experiment":"B", "dad":"true"}}
+	meta := inheritMeta(mom, dad, neat.MergeMetaInheritance)
+	if len(meta) != 3 || meta["experiment"] != "A" || meta["dad"] != "true" {
+		t.Error("Wrong merged metadata", meta)
+	}
+	meta = inheritMeta(mom, dad, neat.MomMetaInheritance)
+	if len(meta) != 2 || meta["dad"] != "" {
+		t.Error("Wrong mom's metadata", meta)
+	}
+}
+
+func TestSpecies_findOutsideMate(t *testing.T) {
+	rand.Seed(42)
+	sp1, err := buildSpeciesWithOrganisms(1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	// build highly incompatible species
+	sp2 := NewSpecies(2)
+	for i := 0; i < 3; i++ {
+		org, err := NewOrganism(100.0, newGenomeRand(i, 3, 2, 10, 10, false, 0.9), 1)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		sp2.addOrganism(org)
+	}
+	sorted_species := []*Species{sp2, sp1}
+	mom := sp1.Organisms[0]
+	conf := neat.NeatContext{
+		DisjointCoeff:1.0,
+		ExcessCoeff:1.0,
+		MutdiffCoeff:0.4,
+		GenCompatMethod:1,
+	}
+
+	// guard disabled - mate outside
+	dad := sp1.findOutsideMate(mom, sorted_species, &conf)
+	if dad != sp2.Organisms[0] {
+		t.Error("The champion of other species expected as mate")
+	}
+
+	// guard enabled - fallback to within species mating
+	conf.MaxInterspeciesCompat = 1.0
+	if comp := mom.Genotype.compatibility(sp2.Organisms[0].Genotype, &conf); comp <= conf.MaxInterspeciesCompat {
+		t.Error("Test species are compatible", comp)
+		return
+	}
+	dad = sp1.findOutsideMate(mom, sorted_species, &conf)
+	found := false
+	for _, org := range sp1.Organisms {
+		if org == dad {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("The mate within species expected")
+	}
+}
+
+func TestSpecies_findOutsideMate_compatibleSpecies(t *testing.T) {
+	rand.Seed(42)
+	sp1, err := buildSpeciesWithOrganisms(1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	// build near species
+	sp2, err := buildSpeciesWithOrganisms(2)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	sp2.Organisms[0].Genotype.Genes[0].Link.Weight += 1.0
+	// build distant species
+	sp3 := NewSpecies(3)
+	for i := 0; i < 3; i++ {
+		org, err := NewOrganism(100.0, newGenomeRand(i, 3, 2, 10, 10, false, 0.9), 1)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		sp3.addOrganism(org)
+	}
+	conf := neat.NeatContext{
+		DisjointCoeff:1.0,
+		ExcessCoeff:1.0,
+		MutdiffCoeff:0.4,
+		CompatThreshold:1.0,
+		InterspeciesCompatFactor:2.0,
+	}
+
+	inSpecies := func(org *Organism, sp *Species) bool {
+		for _, o := range sp.Organisms {
+			if o == org {
+				return true
+			}
+		}
+		return false
+	}
+
+	sorted_species := []*Species{sp3, sp2, sp1}
+	for i := 0; i < 20; i++ {
+		dad := sp1.findOutsideMate(sp1.Organisms[0], sorted_species, &conf)
+		if !inSpecies(dad, sp2) {
+			t.Error("The mate from near species expected", dad)
+			return
+		}
+	}
+
+	// no compatible species - fallback to within species mating
+	sorted_species = []*Species{sp3, sp1}
+	dad := sp1.findOutsideMate(sp1.Organisms[0], sorted_species, &conf)
+	if !inSpecies(dad, sp1) {
+		t.Error("The mate within species expected", dad)
+	}
+}
+
+func TestSpecies_findOutsideMate_singleSpecies(t *testing.T) {
+	rand.Seed(42)
+	sp, err := buildSpeciesWithOrganisms(1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	sorted_species := []*Species{sp}
+	conf := neat.NeatContext{
+		InterspeciesBias:1.0,
+	}
+	mates := make(map[*Organism]bool)
+	for i := 0; i < 20; i++ {
+		dad := sp.findOutsideMate(sp.Organisms[0], sorted_species, &conf)
+		found := false
+		for _, org := range sp.Organisms {
+			if org == dad {
+				found = true
+			}
+		}
+		if !found {
+			t.Error("The mate within species expected", dad)
+			return
+		}
+		mates[dad] = true
+	}
+	if len(mates) < 2 {
+		t.Error("The random mate within species expected", len(mates))
+	}
 }
@@ -12,6 +12,7 @@ import (
 	"github.com/yaricom/goNEAT/neat/utils"
 	"strings"
 	"strconv"
+	"math"
 )
 
 // LoggerLevel type to specify logger output level
@@ -64,6 +65,71 @@ var (
 )
 
 
+// SurvivalSelectionType defines how organisms allowed to reproduce are selected within species
+type SurvivalSelectionType byte
+
+const (
+	// The organisms ranked below survival threshold are eliminated
+	RankCutoffSurvival SurvivalSelectionType = iota
+	// The organisms to eliminate are selected by tournaments, the less fit of random pair of organisms is eliminated
+	TournamentSurvival
+)
+
+// SplitWeightsType defines how weights are assigned to the links created by splitting of link with add node mutation
+type SplitWeightsType byte
+
+const (
+	// The link into new node gets weight 1.0 and the link out of it gets the weight of split link
+	ConventionalSplitWeights SplitWeightsType = iota
+	// Both links get square root of the split link weight magnitude to approximate the split link
+	PreserveSplitWeights
+	// Both links get random weights
+	RandomSplitWeights
+)
+
+// MetaInheritanceType defines how organism's metadata is inherited by offspring
+type MetaInheritanceType byte
+
+const (
+	// The offspring inherits metadata of mom only
+	MomMetaInheritance MetaInheritanceType = iota
+	// The offspring of mating inherits merged metadata of both parents, mom's values take precedence
+	MergeMetaInheritance
+)
+
+// DisjointInheritanceType defines from which parent the disjoint and excess genes are inherited during multipoint mating
+type DisjointInheritanceType byte
+
+const (
+	// The disjoint and excess genes are inherited from the more fit parent
+	FitterDisjointInheritance DisjointInheritanceType = iota
+	// The disjoint and excess genes are inherited from the parent with more genes
+	MoreComplexDisjointInheritance
+	// Each disjoint and excess gene is inherited with probability 0.5 regardless of parent
+	RandomDisjointInheritance
+)
+
+// InitialConnectivityType defines how sensors are connected to the outputs of the minimal genome
+type InitialConnectivityType byte
+
+const (
+	// Every sensor connected to every output
+	FullyConnected InitialConnectivityType = iota
+	// Every link between sensor and output created with probability of InitialConnectionFraction
+	SparseConnected
+	// No input connected, the sensors are left to mutateConnectSensors
+	Unconnected
+)
+
+const (
+	// The default floor of organism adjusted fitness
+	DefaultMinAdjustedFitness = 0.0001
+	// The default fitness multiplier applied to organisms of species stagnated longer than DropOffAge
+	DefaultStagnationPenalty = 0.01
+	// The default multiplier of weight mutation power for links leading to the output nodes
+	DefaultOutputWeightMutBias = 1.0
+)
+
 // The NEAT execution context holding common configuration parameters, etc.
 type NeatContext struct {
 				       // Probability of mutating a single trait param
@@ -72,6 +138,14 @@ type NeatContext struct {
 	TraitMutationPower     float64
 				       // The power of a link weight mutation
 	WeightMutPower         float64
+				       // The per generation decay factor of link weight mutation power, values less than or equal
+				       // to zero or 1.0 mean no decay
+	WeightMutPowerDecay    float64
+				       // The maximal absolute value of a link weight, zero means no limit
+	WeightCap              float64
+				       // The multiplier of weight mutation power for links leading to the output nodes, the default
+				       // is DefaultOutputWeightMutBias which means no bias
+	OutputWeightMutBias    float64
 
 				       // These 3 global coefficients are used to determine the formula for
 				       // computing the compatibility between 2 genomes.  The formula is:
@@ -83,10 +157,23 @@ type NeatContext struct {
 	DisjointCoeff          float64
 	ExcessCoeff            float64
 	MutdiffCoeff           float64
+				       // The coefficient of the number of aligned nodes with different activation functions
+				       // added to the compatibility formula (activation_diff_coeff * adn), zero disable it
+	ActivationDiffCoeff    float64
+				       // If true the disjoint and excess genes contributions to compatibility will be divided by the
+				       // number of genes in the larger genome (if it has at least 20 genes)
+	NormalizeCompatByGenomeSize bool
 
 				       // This global tells compatibility threshold under which
 				       // two Genomes are considered the same species
 	CompatThreshold        float64
+				       // The floor of compatibility threshold, the threshold can not be adjusted below it
+	CompatThresholdMin     float64
+				       // The ceiling of compatibility threshold, values less than or equal to zero mean no ceiling
+	CompatThresholdMax     float64
+				       // The maximal number of species in population, when reached the organism not compatible with any
+				       // species joins the least incompatible one instead of creating new species. Zero means unlimited.
+	MaxSpecies             int
 
 				       /* Globals involved in the epoch cycle - mating, reproduction, etc.. */
 
@@ -95,24 +182,46 @@ type NeatContext struct {
 	AgeSignificance        float64
 				       // Percent of average fitness for survival, how many get to reproduce based on survival_thresh * pop_size
 	SurvivalThresh         float64
+				       // The method to select organisms allowed to reproduce (rank cutoff or tournament)
+	SurvivalSelection      SurvivalSelectionType
 
 				       // Probabilities of a non-mating reproduction
 	MutateOnlyProb         float64
 	MutateRandomTraitProb  float64
 	MutateLinkTraitProb    float64
 	MutateNodeTraitProb    float64
+	MutateAddTraitProb     float64 // probability of adding new trait with random parameters
+	MutateMergeTraitsProb  float64 // probability of merging two traits into one
 	MutateLinkWeightsProb  float64
 	MutateToggleEnableProb float64
 	MutateGeneReenableProb float64
+	MutateNodeBiasProb     float64
+	MutateRemoveLinkProb   float64 // probability of disabling link which is not critical for outputs connectivity
+	MutateNeutralProb      float64 // probability of adding disabled gene which changes genotype but not phenotype
 	MutateAddNodeProb      float64
 	MutateAddLinkProb      float64
 	MutateConnectSensors   float64 // probability of mutation involving disconnected inputs connection
+				       // The names of registered mutation operators to sample offspring mutation from instead of
+				       // applying the built-in mutations according to the probabilities above
+	MutationOperators      []string
+				       // The probabilities of selection of the specific mutation operator
+	MutationOperatorsProb  []float64
 
 				       // Probabilities of a mate being outside species
 	InterspeciesMateRate   float64
 	MateMultipointProb     float64
 	MateMultipointAvgProb  float64
 	MateSinglepointProb    float64
+				       // The maximal compatibility distance between parents from different species allowed for mating,
+				       // the mating within species will be used if exceeded. Zero or negative disables this check.
+	MaxInterspeciesCompat  float64
+				       // The factor of CompatThreshold limiting the distance between representatives of mom's species and
+				       // species of outside mate. Only such compatible enough species are candidates for interspecies mating.
+				       // Zero or negative disables this filter.
+	InterspeciesCompatFactor float64
+				       // The strength of bias towards better species when outside mate selected, only the best
+				       // 1/InterspeciesBias fraction of species are candidates. Values less than one mean the default bias of 4.
+	InterspeciesBias       float64
 
 				       // Prob. of mating without mutation
 	MateOnlyProb           float64
@@ -123,6 +232,34 @@ type NeatContext struct {
 	PopSize                int
 				       // Age when Species starts to be penalized
 	DropOffAge             int
+				       // The fitness multiplier applied to organisms of species stagnated longer than DropOffAge,
+				       // the default is DefaultStagnationPenalty and 1.0 disables the penalty
+	StagnationPenalty      float64
+				       // If true the fitness of organisms will not be shared within species, i.e. divided by species size,
+				       // only age related adjustments will be applied
+	DisableFitnessSharing  bool
+				       // If true the species stagnant longer than DropOffAge are not penalized, instead all their
+				       // organisms except the champion are replaced with fresh variants of the seed genome
+	RestartStagnant        bool
+				       // If true the negative fitness is not clamped, instead fitness of all organisms is shifted by the
+				       // population minimum before fitness sharing, so the ordering of organisms is preserved
+	AllowNegativeFitness   bool
+				       // The floor of organism fitness applied after all fitness adjustments, so that every organism keeps
+				       // non-zero share of offspring. The negative fitness is clamped to this value as well. The default
+				       // is DefaultMinAdjustedFitness, zero or negative disables the floor.
+	MinAdjustedFitness     float64
+				       // If true the fitness of organisms is assigned before fitness adjustment by the rank of their
+				       // Pareto front and crowding distance computed from Organism.Objectives (NSGA-II)
+	MultiObjective         bool
+				       // If true the warning will be logged when the species representative changed after sorting of organisms
+				       // by fitness and it is farther than CompatThreshold from the previous one
+	LogRepresentativeDrift bool
+				       // If true only link weights and other non structural mutations will be applied during reproduction,
+				       // i.e., the topology of seed genome will be fixed
+	WeightsOnly            bool
+				       // If true each species adapts the rate of its structural and weight mutations: improving species
+				       // mutate less and stagnant species mutate more aggressively, see Species.MutateRateMultiplier
+	AdaptiveSpeciesMutation bool
 				       // Number of tries mutate_add_link will attempt to find an open link
 	NewLinkTries           int
 
@@ -132,6 +269,28 @@ type NeatContext struct {
 				       // The number of babies to stolen off to the champions
 	BabiesStolen           int
 
+				       // The maximal fraction of population size a single species can produce as offspring, values
+				       // less than or equal to zero or not less than one mean no limit
+	MaxSpeciesOffspringFraction float64
+				       // If set the expected offspring of species are reconciled after allocation to sum up exactly
+				       // to PopSize, thus the population size does not drift due to fractional rounding
+	ReconcilePopSize       bool
+				       // The number of offspring specially treated for the population champion (mostly weight mutated
+				       // copies), zero disables it as in the published experiments
+	SuperChampOffspringCount int
+				       // The probability that the last super champion offspring is an exact clone rather than weight
+				       // mutated copy, values less than or equal to zero mean the last one is always cloned
+	SuperChampCloneLastProb float64
+				       // If true the champion of every species with at least one expected offspring is cloned verbatim
+				       // into the next generation, otherwise only champions of species with more than five offspring
+	AlwaysElitePerSpecies  bool
+				       // If true the verbatim clone of species champion inherits fitness of the champion and marked as
+				       // not requiring re-evaluation, which is useful only for deterministic fitness functions
+	PreserveCloneFitness   bool
+
+				       // The number of the best organisms of the whole population to be cloned into next generation
+	PopulationElitism      int
+
 				       // The number of runs to average over in an experiment
 	NumRuns                int
 
@@ -141,6 +300,45 @@ type NeatContext struct {
 	EpochExecutorType      int
 				       // The genome compatibility testing method to use (0 - linear, 1 - fast (make sense for large genomes))
 	GenCompatMethod        int
+				       // The name of registered compatibility metric to use instead of the classic excess, disjoint and
+				       // weight difference formula, the classic one is used if empty
+	CompatibilityMetric    string
+				       // If true than new recurrent links will never be created by add link mutation
+	FeedForwardOnly        bool
+				       // If true the bias node connected to all outputs will be added to the minimal genome
+	WithBiasNode           bool
+				       // The way weights are assigned to the links created by add node mutation
+	AddNodeSplitWeights    SplitWeightsType
+				       // The way sensors are connected to the outputs of the minimal genome
+	InitialConnectivity    InitialConnectivityType
+				       // The probability of link between sensor and output with sparse initial connectivity
+	InitialConnectionFraction float64
+				       // The way organisms metadata is inherited by offspring
+	MetaInheritance        MetaInheritanceType
+				       // The parent which disjoint and excess genes are inherited during multipoint mating
+	DisjointInheritance    DisjointInheritanceType
+				       // If true the mutation numbers of genes are set equal to their link weights after mating, thus
+				       // compatibility of offspring is not distorted by the mutation numbers inherited from parents
+	SyncMutationNumbers    bool
+				       // If true the search alternates between complexifying and simplifying phases depending on
+				       // the mean complexity of population
+	PhasedSearch           bool
+				       // The mean complexity of population above which the simplifying phase starts
+	SimplifyThreshold      float64
+				       // The mean complexity of population below which the complexifying phase starts again
+	ComplexifyThreshold    float64
+				       // If true only organisms which representative of species changed will be tested for compatibility
+				       // when population speciated. Otherwise all organisms will be speciated from scratch.
+	IncrementalSpeciation  bool
+
+				       // The maximal number of nodes allowed per genome, zero means unlimited
+	MaxNodes               int
+				       // The maximal number of genes allowed per genome, zero means unlimited
+	MaxGenes               int
+
+				       // The activation function of hidden nodes created by add node mutation. If not set the activation
+				       // function is selected randomly among NodeActivators.
+	DefaultHiddenActivation utils.NodeActivationType
 
 				       // The neuron nodes activation functions list to choose from
 	NodeActivators         []utils.NodeActivationType
@@ -150,14 +348,28 @@ type NeatContext struct {
 
 // Creates new empty NEAT context
 func NewNeatContext() *NeatContext {
-	nc := &NeatContext{}
+	nc := &NeatContext{
+		MinAdjustedFitness:DefaultMinAdjustedFitness,
+		StagnationPenalty:DefaultStagnationPenalty,
+		OutputWeightMutBias:DefaultOutputWeightMutBias,
+	}
 	nc.initDefaultNodeActivators()
 	return nc
 }
 
 // Loads context configuration from provided reader as YAML
 func (c *NeatContext) LoadContext(r io.Reader) error {
-	viper.SetConfigType("YAML")
+	return c.loadContext(r, "YAML")
+}
+
+// Loads context configuration from provided reader as JSON
+func (c *NeatContext) LoadContextJSON(r io.Reader) error {
+	return c.loadContext(r, "JSON")
+}
+
+// Loads context configuration from provided reader using given configuration type supported by viper
+func (c *NeatContext) loadContext(r io.Reader, config_type string) error {
+	viper.SetConfigType(config_type)
 	err := viper.ReadConfig(r)
 	if err != nil {
 		return err
@@ -170,23 +382,43 @@ func (c *NeatContext) LoadContext(r io.Reader) error {
 	c.TraitParamMutProb = v.GetFloat64("trait_param_mut_prob")
 	c.TraitMutationPower = v.GetFloat64("trait_mutation_power")
 	c.WeightMutPower = v.GetFloat64("weight_mut_power")
+	c.WeightMutPowerDecay = v.GetFloat64("weight_mut_power_decay")
+	c.WeightCap = v.GetFloat64("weight_cap")
+	if v.IsSet("output_weight_mut_bias") {
+		c.OutputWeightMutBias = v.GetFloat64("output_weight_mut_bias")
+	} else {
+		c.OutputWeightMutBias = DefaultOutputWeightMutBias
+	}
 	c.DisjointCoeff = v.GetFloat64("disjoint_coeff")
 	c.ExcessCoeff = v.GetFloat64("excess_coeff")
 	c.MutdiffCoeff = v.GetFloat64("mutdiff_coeff")
+	c.ActivationDiffCoeff = v.GetFloat64("activation_diff_coeff")
+	c.NormalizeCompatByGenomeSize = v.GetBool("normalize_compat_by_genome_size")
 	c.CompatThreshold = v.GetFloat64("compat_threshold")
+	c.CompatThresholdMin = v.GetFloat64("compat_threshold_min")
+	c.CompatThresholdMax = v.GetFloat64("compat_threshold_max")
+	c.MaxSpecies = v.GetInt("max_species")
 	c.AgeSignificance = v.GetFloat64("age_significance")
 	c.SurvivalThresh = v.GetFloat64("survival_thresh")
 	c.MutateOnlyProb = v.GetFloat64("mutate_only_prob")
 	c.MutateRandomTraitProb = v.GetFloat64("mutate_random_trait_prob")
 	c.MutateLinkTraitProb = v.GetFloat64("mutate_link_trait_prob")
 	c.MutateNodeTraitProb = v.GetFloat64("mutate_node_trait_prob")
+	c.MutateAddTraitProb = v.GetFloat64("mutate_add_trait_prob")
+	c.MutateMergeTraitsProb = v.GetFloat64("mutate_merge_traits_prob")
 	c.MutateLinkWeightsProb = v.GetFloat64("mutate_link_weights_prob")
 	c.MutateToggleEnableProb = v.GetFloat64("mutate_toggle_enable_prob")
 	c.MutateGeneReenableProb = v.GetFloat64("mutate_gene_reenable_prob")
+	c.MutateNodeBiasProb = v.GetFloat64("mutate_node_bias_prob")
+	c.MutateRemoveLinkProb = v.GetFloat64("mutate_remove_link_prob")
+	c.MutateNeutralProb = v.GetFloat64("mutate_neutral_prob")
 	c.MutateAddNodeProb = v.GetFloat64("mutate_add_node_prob")
 	c.MutateAddLinkProb = v.GetFloat64("mutate_add_link_prob")
 	c.MutateConnectSensors = v.GetFloat64("mutate_connect_sensors")
 	c.InterspeciesMateRate = v.GetFloat64("interspecies_mate_rate")
+	c.MaxInterspeciesCompat = v.GetFloat64("max_interspecies_compat")
+	c.InterspeciesCompatFactor = v.GetFloat64("interspecies_compat_factor")
+	c.InterspeciesBias = v.GetFloat64("interspecies_bias")
 	c.MateMultipointProb = v.GetFloat64("mate_multipoint_prob")
 	c.MateMultipointAvgProb = v.GetFloat64("mate_multipoint_avg_prob")
 	c.MateSinglepointProb = v.GetFloat64("mate_singlepoint_prob")
@@ -195,11 +427,44 @@ func (c *NeatContext) LoadContext(r io.Reader) error {
 
 	c.PopSize = v.GetInt("pop_size")
 	c.DropOffAge = v.GetInt("dropoff_age")
+	if v.IsSet("stagnation_penalty") {
+		c.StagnationPenalty = v.GetFloat64("stagnation_penalty")
+	} else {
+		c.StagnationPenalty = DefaultStagnationPenalty
+	}
+	c.DisableFitnessSharing = v.GetBool("disable_fitness_sharing")
+	c.RestartStagnant = v.GetBool("restart_stagnant")
+	c.AllowNegativeFitness = v.GetBool("allow_negative_fitness")
+	if v.IsSet("min_adjusted_fitness") {
+		c.MinAdjustedFitness = v.GetFloat64("min_adjusted_fitness")
+	} else {
+		c.MinAdjustedFitness = DefaultMinAdjustedFitness
+	}
+	c.MultiObjective = v.GetBool("multi_objective")
+	c.LogRepresentativeDrift = v.GetBool("log_representative_drift")
+	c.WeightsOnly = v.GetBool("weights_only")
+	c.AdaptiveSpeciesMutation = v.GetBool("adaptive_species_mutation")
 	c.NewLinkTries = v.GetInt("newlink_tries")
 	c.PrintEvery = v.GetInt("print_every")
 	c.BabiesStolen = v.GetInt("babies_stolen")
+	c.MaxSpeciesOffspringFraction = v.GetFloat64("max_species_offspring_fraction")
+	c.ReconcilePopSize = v.GetBool("reconcile_pop_size")
+	c.SuperChampOffspringCount = v.GetInt("super_champ_offspring_count")
+	c.SuperChampCloneLastProb = v.GetFloat64("super_champ_clone_last_prob")
+	c.AlwaysElitePerSpecies = v.GetBool("always_elite_per_species")
+	c.PreserveCloneFitness = v.GetBool("preserve_clone_fitness")
+	c.PopulationElitism = v.GetInt("population_elitism")
 	c.NumRuns = v.GetInt("num_runs")
 	c.NumGenerations = v.GetInt("num_generations")
+	c.MaxNodes = v.GetInt("max_nodes")
+	c.MaxGenes = v.GetInt("max_genes")
+	c.IncrementalSpeciation = v.GetBool("incremental_speciation")
+	c.SyncMutationNumbers = v.GetBool("sync_mutation_numbers")
+	c.FeedForwardOnly = v.GetBool("feed_forward_only")
+	c.WithBiasNode = v.GetBool("with_bias_node")
+	c.PhasedSearch = v.GetBool("phased_search")
+	c.SimplifyThreshold = v.GetFloat64("simplify_threshold")
+	c.ComplexifyThreshold = v.GetFloat64("complexify_threshold")
 
 	// read epoch executor type [sequential, parallel]
 	ep_exec := v.GetString("epoch_executor")
@@ -220,6 +485,64 @@ func (c *NeatContext) LoadContext(r io.Reader) error {
 	} else {
 		return errors.New(fmt.Sprintf("Unsupported genome compatibility method: %s", gen_compat))
 	}
+	c.CompatibilityMetric = v.GetString("compatibility_metric")
+
+	// read survival selection method [rank_cutoff, tournament]
+	survival := v.GetString("survival_selection")
+	if survival == "" || survival == "rank_cutoff" {
+		c.SurvivalSelection = RankCutoffSurvival
+	} else if survival == "tournament" {
+		c.SurvivalSelection = TournamentSurvival
+	} else {
+		return errors.New(fmt.Sprintf("Unsupported survival selection method: %s", survival))
+	}
+
+	// read add node split weights assignment [conventional, preserve, random]
+	split_weights := v.GetString("add_node_split_weights")
+	if split_weights == "" || split_weights == "conventional" {
+		c.AddNodeSplitWeights = ConventionalSplitWeights
+	} else if split_weights == "preserve" {
+		c.AddNodeSplitWeights = PreserveSplitWeights
+	} else if split_weights == "random" {
+		c.AddNodeSplitWeights = RandomSplitWeights
+	} else {
+		return errors.New(fmt.Sprintf("Unsupported add node split weights: %s", split_weights))
+	}
+
+	// read initial connectivity of minimal genome [fully_connected, sparse, unconnected]
+	connectivity := v.GetString("initial_connectivity")
+	if connectivity == "" || connectivity == "fully_connected" {
+		c.InitialConnectivity = FullyConnected
+	} else if connectivity == "sparse" {
+		c.InitialConnectivity = SparseConnected
+	} else if connectivity == "unconnected" {
+		c.InitialConnectivity = Unconnected
+	} else {
+		return errors.New(fmt.Sprintf("Unsupported initial connectivity: %s", connectivity))
+	}
+	c.InitialConnectionFraction = v.GetFloat64("initial_connection_fraction")
+
+	// read metadata inheritance [mom, merge]
+	meta_inheritance := v.GetString("meta_inheritance")
+	if meta_inheritance == "" || meta_inheritance == "mom" {
+		c.MetaInheritance = MomMetaInheritance
+	} else if meta_inheritance == "merge" {
+		c.MetaInheritance = MergeMetaInheritance
+	} else {
+		return errors.New(fmt.Sprintf("Unsupported meta inheritance: %s", meta_inheritance))
+	}
+
+	// read disjoint genes inheritance [fitter, more_complex, random]
+	disjoint_inheritance := v.GetString("disjoint_inheritance")
+	if disjoint_inheritance == "" || disjoint_inheritance == "fitter" {
+		c.DisjointInheritance = FitterDisjointInheritance
+	} else if disjoint_inheritance == "more_complex" {
+		c.DisjointInheritance = MoreComplexDisjointInheritance
+	} else if disjoint_inheritance == "random" {
+		c.DisjointInheritance = RandomDisjointInheritance
+	} else {
+		return errors.New(fmt.Sprintf("Unsupported disjoint inheritance: %s", disjoint_inheritance))
+	}
 
 	// read log level [Debug, Info, Warning, Error]
 	l_level := v.GetString("log_level")
@@ -236,6 +559,13 @@ func (c *NeatContext) LoadContext(r io.Reader) error {
 		return errors.New(fmt.Sprintf("Usupported log level: %s", l_level))
 	}
 
+	// read activation function of new hidden nodes
+	if hidden_act := v.GetString("default_hidden_activation"); hidden_act != "" {
+		if c.DefaultHiddenActivation, err = utils.NodeActivators.ActivationTypeFromName(hidden_act); err != nil {
+			return err
+		}
+	}
+
 	// read node activators
 	actFns := v.GetStringSlice("node_activators")
 	if actFns != nil {
@@ -257,6 +587,25 @@ func (c *NeatContext) LoadContext(r io.Reader) error {
 		c.initDefaultNodeActivators()
 	}
 
+	// read mutation operators
+	mutOps := v.GetStringSlice("mutation_operators")
+	if len(mutOps) > 0 {
+		c.MutationOperators = make([]string, len(mutOps))
+		c.MutationOperatorsProb = make([]float64, len(mutOps))
+		for i, line := range mutOps {
+			fields := strings.Fields(line)
+			if len(fields) != 2 {
+				return errors.New(fmt.Sprintf("Wrong mutation operator definition: %s", line))
+			}
+			c.MutationOperators[i] = fields[0]
+			if prob, err := strconv.ParseFloat(fields[1], 64); err != nil {
+				return err
+			} else {
+				c.MutationOperatorsProb[i] = prob
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -275,9 +624,33 @@ func (c *NeatContext) RandomNodeActivationType() (utils.NodeActivationType, erro
 	return c.NodeActivators[index], nil
 }
 
+// Returns the power of link weight mutation at given generation with WeightMutPowerDecay applied
+func (c *NeatContext) EffectiveWeightMutPower(generation int) float64 {
+	if c.WeightMutPowerDecay <= 0 || c.WeightMutPowerDecay == 1.0 {
+		return c.WeightMutPower
+	}
+	return c.WeightMutPower * math.Pow(c.WeightMutPowerDecay, float64(generation))
+}
+
+// Returns the compatibility threshold clamped by CompatThresholdMin and CompatThresholdMax
+func (c *NeatContext) EffectiveCompatThreshold() float64 {
+	threshold := c.CompatThreshold
+	if c.CompatThresholdMax > 0 && threshold > c.CompatThresholdMax {
+		threshold = c.CompatThresholdMax
+	}
+	if threshold < c.CompatThresholdMin {
+		threshold = c.CompatThresholdMin
+	}
+	return threshold
+}
+
 // Loads context configuration from provided reader
 func LoadContext(r io.Reader) *NeatContext {
-	c := NeatContext{}
+	c := NeatContext{
+		MinAdjustedFitness:DefaultMinAdjustedFitness,
+		StagnationPenalty:DefaultStagnationPenalty,
+		OutputWeightMutBias:DefaultOutputWeightMutBias,
+	}
 	// read configuration
 	var name string
 	var param float64;
@@ -293,18 +666,36 @@ func LoadContext(r io.Reader) *NeatContext {
 			c.TraitMutationPower = param
 		case "weight_mut_power":
 			c.WeightMutPower = param
+		case "weight_mut_power_decay":
+			c.WeightMutPowerDecay = param
+		case "weight_cap":
+			c.WeightCap = param
+		case "output_weight_mut_bias":
+			c.OutputWeightMutBias = param
 		case "disjoint_coeff":
 			c.DisjointCoeff = param
 		case "excess_coeff":
 			c.ExcessCoeff = param
 		case "mutdiff_coeff":
 			c.MutdiffCoeff = param
+		case "activation_diff_coeff":
+			c.ActivationDiffCoeff = param
+		case "normalize_compat_by_genome_size":
+			c.NormalizeCompatByGenomeSize = param > 0
 		case "compat_threshold":
 			c.CompatThreshold = param
+		case "compat_threshold_min":
+			c.CompatThresholdMin = param
+		case "compat_threshold_max":
+			c.CompatThresholdMax = param
+		case "max_species":
+			c.MaxSpecies = int(param)
 		case "age_significance":
 			c.AgeSignificance = param
 		case "survival_thresh":
 			c.SurvivalThresh = param
+		case "survival_selection":
+			c.SurvivalSelection = SurvivalSelectionType(param)
 		case "mutate_only_prob":
 			c.MutateOnlyProb = param
 		case "mutate_random_trait_prob":
@@ -313,12 +704,22 @@ func LoadContext(r io.Reader) *NeatContext {
 			c.MutateLinkTraitProb = param
 		case "mutate_node_trait_prob":
 			c.MutateNodeTraitProb = param
+		case "mutate_add_trait_prob":
+			c.MutateAddTraitProb = param
+		case "mutate_merge_traits_prob":
+			c.MutateMergeTraitsProb = param
 		case "mutate_link_weights_prob":
 			c.MutateLinkWeightsProb = param
 		case "mutate_toggle_enable_prob":
 			c.MutateToggleEnableProb = param
 		case "mutate_gene_reenable_prob":
 			c.MutateGeneReenableProb = param
+		case "mutate_node_bias_prob":
+			c.MutateNodeBiasProb = param
+		case "mutate_remove_link_prob":
+			c.MutateRemoveLinkProb = param
+		case "mutate_neutral_prob":
+			c.MutateNeutralProb = param
 		case "mutate_add_node_prob":
 			c.MutateAddNodeProb = param
 		case "mutate_add_link_prob":
@@ -327,6 +728,12 @@ func LoadContext(r io.Reader) *NeatContext {
 			c.MutateConnectSensors = param
 		case "interspecies_mate_rate":
 			c.InterspeciesMateRate = param
+		case "max_interspecies_compat":
+			c.MaxInterspeciesCompat = param
+		case "interspecies_compat_factor":
+			c.InterspeciesCompatFactor = param
+		case "interspecies_bias":
+			c.InterspeciesBias = param
 		case "mate_multipoint_prob":
 			c.MateMultipointProb = param
 		case "mate_multipoint_avg_prob":
@@ -341,12 +748,44 @@ func LoadContext(r io.Reader) *NeatContext {
 			c.PopSize = int(param)
 		case "dropoff_age":
 			c.DropOffAge = int(param)
+		case "stagnation_penalty":
+			c.StagnationPenalty = param
+		case "disable_fitness_sharing":
+			c.DisableFitnessSharing = param > 0
+		case "restart_stagnant":
+			c.RestartStagnant = param > 0
+		case "allow_negative_fitness":
+			c.AllowNegativeFitness = param > 0
+		case "min_adjusted_fitness":
+			c.MinAdjustedFitness = param
+		case "multi_objective":
+			c.MultiObjective = param > 0
+		case "log_representative_drift":
+			c.LogRepresentativeDrift = param > 0
+		case "weights_only":
+			c.WeightsOnly = param > 0
+		case "adaptive_species_mutation":
+			c.AdaptiveSpeciesMutation = param > 0
 		case "newlink_tries":
 			c.NewLinkTries = int(param)
 		case "print_every":
 			c.PrintEvery = int(param)
 		case "babies_stolen":
 			c.BabiesStolen = int(param)
+		case "max_species_offspring_fraction":
+			c.MaxSpeciesOffspringFraction = param
+		case "reconcile_pop_size":
+			c.ReconcilePopSize = param > 0
+		case "super_champ_offspring_count":
+			c.SuperChampOffspringCount = int(param)
+		case "super_champ_clone_last_prob":
+			c.SuperChampCloneLastProb = param
+		case "always_elite_per_species":
+			c.AlwaysElitePerSpecies = param > 0
+		case "preserve_clone_fitness":
+			c.PreserveCloneFitness = param > 0
+		case "population_elitism":
+			c.PopulationElitism = int(param)
 		case "num_runs":
 			c.NumRuns = int(param)
 		case "num_generations":
@@ -355,6 +794,36 @@ func LoadContext(r io.Reader) *NeatContext {
 			c.EpochExecutorType = int(param)
 		case "genome_compat_method":
 			c.GenCompatMethod = int(param)
+		case "max_nodes":
+			c.MaxNodes = int(param)
+		case "max_genes":
+			c.MaxGenes = int(param)
+		case "incremental_speciation":
+			c.IncrementalSpeciation = param > 0
+		case "feed_forward_only":
+			c.FeedForwardOnly = param > 0
+		case "with_bias_node":
+			c.WithBiasNode = param > 0
+		case "add_node_split_weights":
+			c.AddNodeSplitWeights = SplitWeightsType(param)
+		case "initial_connectivity":
+			c.InitialConnectivity = InitialConnectivityType(param)
+		case "initial_connection_fraction":
+			c.InitialConnectionFraction = param
+		case "meta_inheritance":
+			c.MetaInheritance = MetaInheritanceType(param)
+		case "disjoint_inheritance":
+			c.DisjointInheritance = DisjointInheritanceType(param)
+		case "sync_mutation_numbers":
+			c.SyncMutationNumbers = param > 0
+		case "phased_search":
+			c.PhasedSearch = param > 0
+		case "simplify_threshold":
+			c.SimplifyThreshold = param
+		case "complexify_threshold":
+			c.ComplexifyThreshold = param
+		case "default_hidden_activation":
+			c.DefaultHiddenActivation = utils.NodeActivationType(param)
 		case "log_level":
 			LogLevel = LoggerLevel(param)
 		default:
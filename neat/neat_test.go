@@ -4,6 +4,8 @@ import (
 	"testing"
 	"os"
 	"fmt"
+	"bytes"
+	"reflect"
 	"github.com/yaricom/goNEAT/neat/utils"
 )
 
@@ -51,6 +53,87 @@ func TestNeatContext_LoadContext(t *testing.T) {
 	}
 }
 
+func TestNeatContext_EffectiveWeightMutPower(t *testing.T) {
+	nc := NeatContext{WeightMutPower:2.5, WeightMutPowerDecay:0.9}
+	if power := nc.EffectiveWeightMutPower(0); power != 2.5 {
+		t.Error("power != 2.5", power)
+	}
+	if power := nc.EffectiveWeightMutPower(10); power >= 2.5 {
+		t.Error("power >= 2.5", power)
+	}
+
+	// no decay
+	for _, decay := range []float64{1.0, 0.0} {
+		nc.WeightMutPowerDecay = decay
+		if power := nc.EffectiveWeightMutPower(10); power != 2.5 {
+			t.Error("power != 2.5", power, decay)
+		}
+	}
+}
+
+func TestNeatContext_WriteYAML(t *testing.T) {
+	nc := loadTestContextForWrite(t)
+	if nc == nil {
+		return
+	}
+	buf := bytes.NewBufferString("")
+	if err := nc.WriteYAML(buf); err != nil {
+		t.Error(err)
+		return
+	}
+
+	r_nc := NewNeatContext()
+	if err := r_nc.LoadContext(buf); err != nil {
+		t.Error(err)
+		return
+	}
+	if !reflect.DeepEqual(nc, r_nc) {
+		t.Errorf("Contexts are not equal after YAML round-trip\n%v\n%v", nc, r_nc)
+	}
+}
+
+func TestNeatContext_WriteJSON(t *testing.T) {
+	nc := loadTestContextForWrite(t)
+	if nc == nil {
+		return
+	}
+	buf := bytes.NewBufferString("")
+	if err := nc.WriteJSON(buf); err != nil {
+		t.Error(err)
+		return
+	}
+
+	r_nc := NewNeatContext()
+	if err := r_nc.LoadContextJSON(buf); err != nil {
+		t.Error(err)
+		return
+	}
+	if !reflect.DeepEqual(nc, r_nc) {
+		t.Errorf("Contexts are not equal after JSON round-trip\n%v\n%v", nc, r_nc)
+	}
+}
+
+func loadTestContextForWrite(t *testing.T) *NeatContext {
+	config, err := os.Open("../data/xor_test.neat.yml")
+	if err != nil {
+		t.Error("Failed to open config file", err)
+		return nil
+	}
+	nc := NewNeatContext()
+	if err = nc.LoadContext(config); err != nil {
+		t.Error(err)
+		return nil
+	}
+	// set some non default values
+	nc.SurvivalSelection = TournamentSurvival
+	nc.FeedForwardOnly = true
+	nc.MaxNodes = 50
+	nc.MutateNodeBiasProb = 0.123456789
+	nc.SyncMutationNumbers = true
+	nc.DefaultHiddenActivation = utils.ReLUActivation
+	return nc
+}
+
 func checkNeatContext(nc *NeatContext, t *testing.T) {
 	if nc.TraitParamMutProb != 0.5 {
 		t.Error("nc.TraitParamMutProb != 0.5", nc.TraitParamMutProb)
@@ -73,6 +156,18 @@ func checkNeatContext(nc *NeatContext, t *testing.T) {
 	if nc.CompatThreshold != 3.0 {
 		t.Error("CompatThreshold", nc.CompatThreshold)
 	}
+	if nc.DisableFitnessSharing {
+		t.Error("nc.DisableFitnessSharing")
+	}
+	if nc.OutputWeightMutBias != DefaultOutputWeightMutBias {
+		t.Error("nc.OutputWeightMutBias != DefaultOutputWeightMutBias", nc.OutputWeightMutBias)
+	}
+	if nc.StagnationPenalty != DefaultStagnationPenalty {
+		t.Error("nc.StagnationPenalty != DefaultStagnationPenalty", nc.StagnationPenalty)
+	}
+	if nc.MinAdjustedFitness != DefaultMinAdjustedFitness {
+		t.Error("nc.MinAdjustedFitness != DefaultMinAdjustedFitness", nc.MinAdjustedFitness)
+	}
 	if nc.AgeSignificance != 1.0 {
 		t.Error("AgeSignificance", nc.AgeSignificance)
 	}
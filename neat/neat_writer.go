@@ -0,0 +1,231 @@
+package neat
+
+import (
+	"io"
+	"encoding/json"
+	"gopkg.in/yaml.v2"
+	"github.com/yaricom/goNEAT/neat/utils"
+	"strconv"
+	"errors"
+	"fmt"
+)
+
+// Writes this context configuration into provided writer as YAML. The produced output can be read back with
+// NeatContext.LoadContext and holds current log level as well.
+func (c *NeatContext) WriteYAML(w io.Writer) error {
+	c_map, err := c.encode()
+	if err != nil {
+		return err
+	}
+	enc := yaml.NewEncoder(w)
+	if err = enc.Encode(map[string]interface{}{"neat":c_map}); err == nil {
+		err = enc.Close()
+	}
+	return err
+}
+
+// Writes this context configuration into provided writer as JSON. The produced output can be read back with
+// NeatContext.LoadContextJSON and holds current log level as well.
+func (c *NeatContext) WriteJSON(w io.Writer) error {
+	c_map, err := c.encode()
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(map[string]interface{}{"neat":c_map})
+}
+
+// Encodes all parameters of this context into map using the same keys as expected by context loaders
+func (c *NeatContext) encode() (map[string]interface{}, error) {
+	c_map := make(map[string]interface{})
+	c_map["trait_param_mut_prob"] = c.TraitParamMutProb
+	c_map["trait_mutation_power"] = c.TraitMutationPower
+	c_map["weight_mut_power"] = c.WeightMutPower
+	c_map["weight_mut_power_decay"] = c.WeightMutPowerDecay
+	c_map["weight_cap"] = c.WeightCap
+	c_map["output_weight_mut_bias"] = c.OutputWeightMutBias
+	c_map["disjoint_coeff"] = c.DisjointCoeff
+	c_map["excess_coeff"] = c.ExcessCoeff
+	c_map["mutdiff_coeff"] = c.MutdiffCoeff
+	c_map["activation_diff_coeff"] = c.ActivationDiffCoeff
+	c_map["normalize_compat_by_genome_size"] = c.NormalizeCompatByGenomeSize
+	c_map["compat_threshold"] = c.CompatThreshold
+	c_map["compat_threshold_min"] = c.CompatThresholdMin
+	c_map["compat_threshold_max"] = c.CompatThresholdMax
+	c_map["max_species"] = c.MaxSpecies
+	c_map["age_significance"] = c.AgeSignificance
+	c_map["survival_thresh"] = c.SurvivalThresh
+	c_map["mutate_only_prob"] = c.MutateOnlyProb
+	c_map["mutate_random_trait_prob"] = c.MutateRandomTraitProb
+	c_map["mutate_link_trait_prob"] = c.MutateLinkTraitProb
+	c_map["mutate_node_trait_prob"] = c.MutateNodeTraitProb
+	c_map["mutate_add_trait_prob"] = c.MutateAddTraitProb
+	c_map["mutate_merge_traits_prob"] = c.MutateMergeTraitsProb
+	c_map["mutate_link_weights_prob"] = c.MutateLinkWeightsProb
+	c_map["mutate_toggle_enable_prob"] = c.MutateToggleEnableProb
+	c_map["mutate_gene_reenable_prob"] = c.MutateGeneReenableProb
+	c_map["mutate_node_bias_prob"] = c.MutateNodeBiasProb
+	c_map["mutate_remove_link_prob"] = c.MutateRemoveLinkProb
+	c_map["mutate_neutral_prob"] = c.MutateNeutralProb
+	c_map["mutate_add_node_prob"] = c.MutateAddNodeProb
+	c_map["mutate_add_link_prob"] = c.MutateAddLinkProb
+	c_map["mutate_connect_sensors"] = c.MutateConnectSensors
+	c_map["interspecies_mate_rate"] = c.InterspeciesMateRate
+	c_map["max_interspecies_compat"] = c.MaxInterspeciesCompat
+	c_map["interspecies_compat_factor"] = c.InterspeciesCompatFactor
+	c_map["interspecies_bias"] = c.InterspeciesBias
+	c_map["mate_multipoint_prob"] = c.MateMultipointProb
+	c_map["mate_multipoint_avg_prob"] = c.MateMultipointAvgProb
+	c_map["mate_singlepoint_prob"] = c.MateSinglepointProb
+	c_map["mate_only_prob"] = c.MateOnlyProb
+	c_map["recur_only_prob"] = c.RecurOnlyProb
+
+	c_map["pop_size"] = c.PopSize
+	c_map["dropoff_age"] = c.DropOffAge
+	c_map["stagnation_penalty"] = c.StagnationPenalty
+	c_map["disable_fitness_sharing"] = c.DisableFitnessSharing
+	c_map["restart_stagnant"] = c.RestartStagnant
+	c_map["allow_negative_fitness"] = c.AllowNegativeFitness
+	c_map["min_adjusted_fitness"] = c.MinAdjustedFitness
+	c_map["multi_objective"] = c.MultiObjective
+	c_map["log_representative_drift"] = c.LogRepresentativeDrift
+	c_map["weights_only"] = c.WeightsOnly
+	c_map["adaptive_species_mutation"] = c.AdaptiveSpeciesMutation
+	c_map["newlink_tries"] = c.NewLinkTries
+	c_map["print_every"] = c.PrintEvery
+	c_map["babies_stolen"] = c.BabiesStolen
+	c_map["max_species_offspring_fraction"] = c.MaxSpeciesOffspringFraction
+	c_map["reconcile_pop_size"] = c.ReconcilePopSize
+	c_map["super_champ_offspring_count"] = c.SuperChampOffspringCount
+	c_map["super_champ_clone_last_prob"] = c.SuperChampCloneLastProb
+	c_map["always_elite_per_species"] = c.AlwaysElitePerSpecies
+	c_map["preserve_clone_fitness"] = c.PreserveCloneFitness
+	c_map["population_elitism"] = c.PopulationElitism
+	c_map["num_runs"] = c.NumRuns
+	c_map["num_generations"] = c.NumGenerations
+	c_map["max_nodes"] = c.MaxNodes
+	c_map["max_genes"] = c.MaxGenes
+	c_map["incremental_speciation"] = c.IncrementalSpeciation
+	c_map["feed_forward_only"] = c.FeedForwardOnly
+	c_map["with_bias_node"] = c.WithBiasNode
+	c_map["initial_connection_fraction"] = c.InitialConnectionFraction
+	c_map["phased_search"] = c.PhasedSearch
+	c_map["simplify_threshold"] = c.SimplifyThreshold
+	c_map["complexify_threshold"] = c.ComplexifyThreshold
+	c_map["sync_mutation_numbers"] = c.SyncMutationNumbers
+
+	switch c.EpochExecutorType {
+	case 0:
+		c_map["epoch_executor"] = "sequential"
+	case 1:
+		c_map["epoch_executor"] = "parallel"
+	default:
+		return nil, errors.New(fmt.Sprintf("Unsupported epoch executor type: %d", c.EpochExecutorType))
+	}
+
+	switch c.GenCompatMethod {
+	case 0:
+		c_map["genome_compat_method"] = "linear"
+	case 1:
+		c_map["genome_compat_method"] = "fast"
+	default:
+		return nil, errors.New(fmt.Sprintf("Unsupported genome compatibility method: %d", c.GenCompatMethod))
+	}
+
+	switch c.SurvivalSelection {
+	case RankCutoffSurvival:
+		c_map["survival_selection"] = "rank_cutoff"
+	case TournamentSurvival:
+		c_map["survival_selection"] = "tournament"
+	default:
+		return nil, errors.New(fmt.Sprintf("Unsupported survival selection method: %d", c.SurvivalSelection))
+	}
+
+	switch c.AddNodeSplitWeights {
+	case ConventionalSplitWeights:
+		c_map["add_node_split_weights"] = "conventional"
+	case PreserveSplitWeights:
+		c_map["add_node_split_weights"] = "preserve"
+	case RandomSplitWeights:
+		c_map["add_node_split_weights"] = "random"
+	default:
+		return nil, errors.New(fmt.Sprintf("Unsupported add node split weights: %d", c.AddNodeSplitWeights))
+	}
+
+	switch c.InitialConnectivity {
+	case FullyConnected:
+		c_map["initial_connectivity"] = "fully_connected"
+	case SparseConnected:
+		c_map["initial_connectivity"] = "sparse"
+	case Unconnected:
+		c_map["initial_connectivity"] = "unconnected"
+	default:
+		return nil, errors.New(fmt.Sprintf("Unsupported initial connectivity: %d", c.InitialConnectivity))
+	}
+
+	switch c.MetaInheritance {
+	case MomMetaInheritance:
+		c_map["meta_inheritance"] = "mom"
+	case MergeMetaInheritance:
+		c_map["meta_inheritance"] = "merge"
+	default:
+		return nil, errors.New(fmt.Sprintf("Unsupported meta inheritance: %d", c.MetaInheritance))
+	}
+
+	switch c.DisjointInheritance {
+	case FitterDisjointInheritance:
+		c_map["disjoint_inheritance"] = "fitter"
+	case MoreComplexDisjointInheritance:
+		c_map["disjoint_inheritance"] = "more_complex"
+	case RandomDisjointInheritance:
+		c_map["disjoint_inheritance"] = "random"
+	default:
+		return nil, errors.New(fmt.Sprintf("Unsupported disjoint inheritance: %d", c.DisjointInheritance))
+	}
+
+	switch LogLevel {
+	case LogLevelDebug:
+		c_map["log_level"] = "Debug"
+	case LogLevelInfo:
+		c_map["log_level"] = "Info"
+	case LogLevelWarning:
+		c_map["log_level"] = "Warning"
+	case LogLevelError:
+		c_map["log_level"] = "Error"
+	default:
+		return nil, errors.New(fmt.Sprintf("Usupported log level: %d", LogLevel))
+	}
+
+	// encode node activators as pairs of activation function name and its probability
+	activators := make([]string, len(c.NodeActivators))
+	for i, a := range c.NodeActivators {
+		name, err := utils.NodeActivators.ActivationNameFromType(a)
+		if err != nil {
+			return nil, err
+		}
+		activators[i] = name + " " + strconv.FormatFloat(c.NodeActivatorsProb[i], 'g', -1, 64)
+	}
+	c_map["node_activators"] = activators
+
+	if c.DefaultHiddenActivation != 0 {
+		name, err := utils.NodeActivators.ActivationNameFromType(c.DefaultHiddenActivation)
+		if err != nil {
+			return nil, err
+		}
+		c_map["default_hidden_activation"] = name
+	}
+
+	if len(c.MutationOperators) > 0 {
+		mut_ops := make([]string, len(c.MutationOperators))
+		for i, name := range c.MutationOperators {
+			mut_ops[i] = name + " " + strconv.FormatFloat(c.MutationOperatorsProb[i], 'g', -1, 64)
+		}
+		c_map["mutation_operators"] = mut_ops
+	}
+	if len(c.CompatibilityMetric) > 0 {
+		c_map["compatibility_metric"] = c.CompatibilityMetric
+	}
+
+	return c_map, nil
+}
@@ -48,6 +48,35 @@ type NetworkSolver interface {
 	LinkCount() int
 }
 
+// NormMode defines how network outputs are normalized when read
+type NormMode byte
+
+// The output normalization modes
+const (
+	// The raw outputs
+	NormNone NormMode = iota
+	// The outputs are clamped to the [0, 1] range
+	NormClamp01
+	// The softmax function applied to the outputs, i.e., the outputs sum up to 1
+	NormSoftmax
+)
+
+// ActivationOrder defines the order in which network neurons are activated during each activation step
+type ActivationOrder byte
+
+// The neurons activation orders
+const (
+	// The activation sums of all neurons computed before their activation, but neuron becomes active as soon as any
+	// its input activated during the same step, thus the result depends on the order in which nodes are stored
+	InStoredOrder ActivationOrder = iota
+	// All neurons read activations of the previous step and then their new activations committed together, which makes
+	// recurrent network activation independent of the nodes order
+	Simultaneous
+	// Each neuron is activated right after its inputs summed in order of its depth, i.e., the feed-forward layers
+	// first, so the signal passes through the whole feed-forward part of network in one step
+	ByDepth
+)
+
 // NNodeType defines the type of NNode to create
 type NodeType byte
 
@@ -118,10 +147,11 @@ func NeuronTypeByName(name string) (NodeNeuronType, error) {
 	}
 }
 
-// Method to calculate activation for specified neuron node based on it's ActivationType field value.
+// Method to calculate activation for specified neuron node based on it's ActivationType field value. The activation
+// sum is multiplied by the node's activation steepness before applying activation function.
 // Will return error and set -0.0 activation if unsupported activation type requested.
 func ActivateNode(node *NNode, a *utils.NodeActivatorsFactory) (err error) {
-	out, err := a.ActivateByType(node.ActivationSum, node.Params, node.ActivationType)
+	out, err := a.ActivateByType(node.ActivationSum * node.ActivationSteepness(), node.Params, node.ActivationType)
 	if err == nil {
 		node.setActivation(out)
 	}
@@ -45,6 +45,11 @@ type FastModularNetworkSolver struct {
 	activationFunctions         []utils.NodeActivationType
 	// The bias values associated with neurons
 	biasList                    []float64
+	// The number of links from bias neurons folded into the biasList
+	biasLinkCount               int
+	// The activation steepness of neurons applied to the signal before activation function, nil means the default
+	// steepness of 1.0 for all neurons
+	steepnessList               []float64
 	// The control nodes relaying between network modules
 	modules                     []*FastControlNode
 	// The connections
@@ -217,7 +222,7 @@ func (fmm *FastModularNetworkSolver) recursiveActivateNode(currentNode int) (res
 
 	// Set this signal after running it through the activation function
 	if fmm.neuronSignals[currentNode], err = utils.NodeActivators.ActivateByType(
-		fmm.neuronSignalsBeingProcessed[currentNode], nil,
+		fmm.neuronSignalsBeingProcessed[currentNode] * fmm.steepness(currentNode), nil,
 		fmm.activationFunctions[currentNode]); err != nil {
 		// failed to activate
 		res = false
@@ -225,6 +230,14 @@ func (fmm *FastModularNetworkSolver) recursiveActivateNode(currentNode int) (res
 	return res, err
 }
 
+// Returns the activation steepness of neuron at given index
+func (fmm *FastModularNetworkSolver) steepness(index int) float64 {
+	if fmm.steepnessList == nil {
+		return 1.0
+	}
+	return fmm.steepnessList[index]
+}
+
 // Attempts to relax network given amount of steps until giving up. The network considered relaxed when absolute
 // value of the change at any given point is less than maxAllowedSignalDelta during activation waves propagation.
 // If maxAllowedSignalDelta value is less than or equal to 0, the method will return true without checking for relaxation.
@@ -252,13 +265,13 @@ func (fmm *FastModularNetworkSolver) forwardStep(maxAllowedSignalDelta float64)
 	// Pass the signals through the single-valued activation functions
 	for i := fmm.sensorNeuronCount; i < fmm.totalNeuronCount; i++ {
 		signal := fmm.neuronSignalsBeingProcessed[i]
-		if fmm.biasNeuronCount > 0 {
+		if fmm.biasList != nil {
 			// append BIAS value to the signal if appropriate
 			signal += fmm.biasList[i]
 		}
 
 		if fmm.neuronSignalsBeingProcessed[i], err = utils.NodeActivators.ActivateByType(
-			signal, nil, fmm.activationFunctions[i]); err != nil {
+			signal * fmm.steepness(i), nil, fmm.activationFunctions[i]); err != nil {
 			return false, err
 		}
 	}
@@ -333,17 +346,8 @@ func (fmm *FastModularNetworkSolver) NodeCount() int {
 }
 // Returns the total number of links between nodes in the network
 func (fmm *FastModularNetworkSolver) LinkCount() int {
-	// count all connections
-	num_links := len(fmm.connections)
-
-	// count all bias links if any
-	if fmm.biasNeuronCount > 0 {
-		for _, b := range fmm.biasList {
-			if b != 0 {
-				num_links++
-			}
-		}
-	}
+	// count all connections and bias links, the own biases of neurons are not links
+	num_links := len(fmm.connections) + fmm.biasLinkCount
 
 	// count all modules links
 	if len(fmm.modules) != 0 {
@@ -5,6 +5,8 @@ import (
 	"bytes"
 	"errors"
 	"github.com/yaricom/goNEAT/neat/utils"
+	"math"
+	"sort"
 )
 
 // A NETWORK is a LIST of input NODEs and a LIST of output NODEs.
@@ -28,6 +30,11 @@ type Network struct {
 
 	// NNodes that connect network modules
 	control_nodes []*NNode
+
+	// The order of neurons activation
+	activation_order ActivationOrder
+	// The neurons sorted by depth for ByDepth activation order
+	depth_order   []*NNode
 }
 
 // Creates new network
@@ -49,6 +56,37 @@ func NewModularNetwork(in, out, all, control []*NNode, net_id int) *Network {
 	return n
 }
 
+// Sets the order in which neurons of this network are activated during each activation step. By default neurons are
+// activated InStoredOrder.
+func (n *Network) SetActivationOrder(order ActivationOrder) {
+	n.activation_order = order
+	n.depth_order = nil
+	if order == ByDepth {
+		depths := n.NodeDepths()
+		for _, np := range n.all_nodes {
+			if np.IsNeuron() {
+				n.depth_order = append(n.depth_order, np)
+			}
+		}
+		sort.SliceStable(n.depth_order, func(i, j int) bool {
+			return depths[n.depth_order[i]] < depths[n.depth_order[j]]
+		})
+	}
+}
+
+// Enables or disables use of the first parameter of node's trait as the gain applied to the activation sum of the node
+// before activation function, see NNode.ActivationSteepness. It is disabled by default, thus the trait parameters don't
+// affect activation of the network. The setting is carried over to the fast network solver created afterwards.
+func (n *Network) SetTraitSteepness(enabled bool) {
+	nodes := append(append(make([]*NNode, 0, len(n.all_nodes) + len(n.control_nodes)), n.all_nodes...), n.control_nodes...)
+	for _, node := range nodes {
+		node.steepness = 0
+		if enabled && node.Trait != nil && len(node.Trait.Params) > 0 && node.Trait.Params[0] > 0 {
+			node.steepness = node.Trait.Params[0]
+		}
+	}
+}
+
 // Creates fast network solver based on the architecture of this network. It's primarily aimed for big networks to improve
 // processing speed.
 func (n *Network) FastNetworkSolver() (NetworkSolver, error) {
@@ -133,8 +171,29 @@ func (n *Network) FastNetworkSolver() (NetworkSolver, error) {
 		modules[i] = &FastControlNode{InputIndxs:inputs, OutputIndxs:outputs, ActivationType:cn.ActivationType}
 	}
 
-	return NewFastModularNetworkSolver(biasNeuronCount, inputNeuronCount, outputNeuronCount, totalNeuronCount,
-		activations, connections, biases, modules), nil
+	solver := NewFastModularNetworkSolver(biasNeuronCount, inputNeuronCount, outputNeuronCount, totalNeuronCount,
+		activations, connections, biases, modules)
+
+	// count links from bias neurons folded into the biases
+	for _, ne := range n.all_nodes {
+		for _, in := range ne.Incoming {
+			if in.InNode.NeuronType == BiasNeuron {
+				solver.biasLinkCount++
+			}
+		}
+	}
+
+	// collect activation steepness of neurons if any differs from default
+	for _, ne := range n.all_nodes {
+		if ne.ActivationSteepness() != 1.0 {
+			solver.steepnessList = make([]float64, totalNeuronCount)
+			for _, nd := range n.all_nodes {
+				solver.steepnessList[neuronLookup[nd.Id]] = nd.ActivationSteepness()
+			}
+			break
+		}
+	}
+	return solver, nil
 }
 
 func processList(startIndex int, nList []*NNode, activations[]utils.NodeActivationType, neuronLookup map[int]int) int {
@@ -150,6 +209,8 @@ func processIncomingConnections(nList []*NNode, biases []float64, neuronLookup m
 	connections = make([]*FastNetworkLink, 0)
 	for _, ne := range nList {
 		if targetIndex, ok := neuronLookup[ne.Id]; ok {
+			// store own bias of target neuron
+			biases[targetIndex] += ne.Bias
 			for _, in := range ne.Incoming {
 				if sourceIndex, ok := neuronLookup[in.InNode.Id]; ok {
 					if in.InNode.NeuronType == BiasNeuron {
@@ -230,8 +291,6 @@ func (n *Network) OutputIsOff() bool {
 
 // Attempts to activate the network given number of steps before returning error.
 func (n *Network) ActivateSteps(max_steps int) (bool, error) {
-	// For adding to the activesum
-	add_amount := 0.0
 	// Make sure we at least activate once
 	one_time := false
 	// Used in case the output is somehow truncated from the network
@@ -245,26 +304,58 @@ func (n *Network) ActivateSteps(max_steps int) (bool, error) {
 			return false, NetErrExceededMaxActivationAttempts
 		}
 
+		if err := n.activateStep(); err != nil {
+			return false, err
+		}
+
+		one_time = true
+		abort_count += 1
+	}
+	return true, nil
+}
+
+// Activates each neuron of the network once off the current state of its inputs and propagates activation through
+// the MIMO control nodes.
+func (n *Network) activateStep() error {
+	switch n.activation_order {
+	case ByDepth:
+		// Activate each neuron right after its incoming activation summed, the shallow neurons go first
+		for _, np := range n.depth_order {
+			if sumIncomingActivation(np) {
+				np.isActive = true
+			}
+			if np.isActive {
+				if err := ActivateNode(np, utils.NodeActivators); err != nil {
+					return err
+				}
+			}
+		}
+	case Simultaneous:
+		// Compute the sum of incoming activation of each neuron off the previous step state
+		activated := make([]*NNode, 0)
+		for _, np := range n.all_nodes {
+			if np.IsNeuron() && sumIncomingActivation(np) {
+				activated = append(activated, np)
+			}
+		}
+		// Commit the state of all neurons
+		for _, np := range activated {
+			np.isActive = true
+		}
+		for _, np := range n.all_nodes {
+			if np.IsNeuron() && np.isActive {
+				if err := ActivateNode(np, utils.NodeActivators); err != nil {
+					return err
+				}
+			}
+		}
+	default:
 		// For each neuron node, compute the sum of its incoming activation
 		for _, np := range n.all_nodes {
-			if np.IsNeuron() {
-				np.ActivationSum = 0.0 // reset activation value
-
-				// For each node's incoming connection, add the activity from the connection to the activesum
-				for _, link := range np.Incoming {
-					// Handle possible time delays
-					if !link.IsTimeDelayed {
-						add_amount = link.Weight * link.InNode.GetActiveOut()
-						if link.InNode.isActive || link.InNode.IsSensor() {
-							np.isActive = true
-						}
-					} else {
-						add_amount = link.Weight * link.InNode.GetActiveOutTd()
-					}
-					np.ActivationSum += add_amount
-				} // End {for} over incoming links
-			} // End if != SENSOR
-		}  // End {for} over all nodes
+			if np.IsNeuron() && sumIncomingActivation(np) {
+				np.isActive = true
+			}
+		}
 
 		// Now activate all the neuron nodes off their incoming activation
 		for _, np := range n.all_nodes {
@@ -274,28 +365,61 @@ func (n *Network) ActivateSteps(max_steps int) (bool, error) {
 					// Now run the net activation through an activation function
 					err := ActivateNode(np, utils.NodeActivators)
 					if err != nil {
-						return false, err
+						return err
 					}
 				}
 			}
 		}
+	}
 
-		// Now activate all MIMO control genes to propagate activation through genome modules
-		for _, cn := range n.control_nodes {
-			cn.isActive = false
-			// Activate control MIMO node as control module
-			err := ActivateModule(cn, utils.NodeActivators)
-			if err != nil {
-				return false, err
-			}
-			// mark control node as active
-			cn.isActive = true
+	// Now activate all MIMO control genes to propagate activation through genome modules
+	for _, cn := range n.control_nodes {
+		cn.isActive = false
+		// Activate control MIMO node as control module
+		err := ActivateModule(cn, utils.NodeActivators)
+		if err != nil {
+			return err
 		}
+		// mark control node as active
+		cn.isActive = true
+	}
+	return nil
+}
 
-		one_time = true
-		abort_count += 1
+// Activates the network given number of steps in a row off the sensor values already loaded. In contrast to Activate,
+// it doesn't continue until all outputs become active, thus the number of steps should be not less than the depth of
+// network for the input signal to reach the outputs.
+func (n *Network) ActivateN(times int) error {
+	if times <= 0 {
+		return errors.New(fmt.Sprintf("Wrong number of activation steps: %d", times))
 	}
-	return true, nil
+	for i := 0; i < times; i++ {
+		if err := n.activateStep(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Computes the sum of incoming activation of the neuron node starting from its bias. Returns true if any of its non
+// time delayed inputs is active, i.e. the node should become active.
+func sumIncomingActivation(np *NNode) bool {
+	np.ActivationSum = np.Bias // reset activation value to the node's bias
+	active := false
+
+	// For each node's incoming connection, add the activity from the connection to the activesum
+	for _, link := range np.Incoming {
+		// Handle possible time delays
+		if !link.IsTimeDelayed {
+			np.ActivationSum += link.Weight * link.InNode.GetActiveOut()
+			if link.InNode.isActive || link.InNode.IsSensor() {
+				active = true
+			}
+		} else {
+			np.ActivationSum += link.Weight * link.InNode.GetActiveOutTd()
+		}
+	}
+	return active
 }
 
 // Activates the net such that all outputs are active
@@ -303,6 +427,48 @@ func (n *Network) Activate() (bool, error) {
 	return n.ActivateSteps(20)
 }
 
+// Activates the net as continuous time recurrent neural network (CTRNN) for one integration step with given time
+// step dt. The state of each neuron is integrated as state += dt/tau * (-state + input_sum) with the node's time
+// constant tau and the activation function applied to the integrated state. The neurons with time constant less
+// than or equal to zero respond instantaneously, i.e. their state is equal to the input sum.
+func (n *Network) ActivateContinuous(dt float64) (bool, error) {
+	if dt <= 0 {
+		return false, errors.New(fmt.Sprintf("Wrong integration time step: %f", dt))
+	}
+	// For each neuron node, compute the sum of its incoming activation
+	for _, np := range n.all_nodes {
+		if np.IsNeuron() {
+			sumIncomingActivation(np)
+		}
+	}
+
+	// Integrate the state of all neuron nodes and activate them off the integrated state
+	for _, np := range n.all_nodes {
+		if np.IsNeuron() {
+			if np.TimeConstant > 0 {
+				np.continuousState += dt / np.TimeConstant * (np.ActivationSum - np.continuousState)
+			} else {
+				np.continuousState = np.ActivationSum
+			}
+			np.ActivationSum = np.continuousState
+			if err := ActivateNode(np, utils.NodeActivators); err != nil {
+				return false, err
+			}
+			np.isActive = true
+		}
+	}
+
+	// Now activate all MIMO control genes to propagate activation through genome modules
+	for _, cn := range n.control_nodes {
+		cn.isActive = false
+		if err := ActivateModule(cn, utils.NodeActivators); err != nil {
+			return false, err
+		}
+		cn.isActive = true
+	}
+	return true, nil
+}
+
 // Propagates activation wave through all network nodes provided number of steps in forward direction.
 // Returns true if activation wave passed from all inputs to outputs.
 func (n *Network) ForwardSteps(steps int) (res bool, err error) {
@@ -329,7 +495,9 @@ func (n *Network) Relax(maxSteps int, maxAllowedSignalDelta float64) (bool, erro
 	return false, errors.New("Relax Not Implemented")
 }
 
-// Takes an array of sensor values and loads it into SENSOR inputs ONLY
+// Takes an array of sensor values and loads it into SENSOR inputs ONLY. The number of provided values should be either
+// equal to the number of all sensors including BIAS (see InputCount) or to the number of INPUT sensors only, in later case
+// the default BIAS value will be used. Returns error if provided values doesn't match the sensors of this network.
 func (n *Network) LoadSensors(sensors []float64) error {
 	counter := 0
 	if len(sensors) == len(n.inputs) {
@@ -341,6 +509,10 @@ func (n *Network) LoadSensors(sensors []float64) error {
 			}
 		}
 	} else {
+		if in_count := n.inputNeuronCount(); len(sensors) != in_count {
+			return errors.New(fmt.Sprintf("Wrong number of sensor values: %d, expected: %d or %d without BIAS",
+				len(sensors), len(n.inputs), in_count))
+		}
 		// use default BIAS value
 		for _, node := range n.inputs {
 			if node.NeuronType == InputNeuron {
@@ -355,6 +527,27 @@ func (n *Network) LoadSensors(sensors []float64) error {
 	return nil
 }
 
+// Returns the number of sensor nodes of this network including BIAS
+func (n *Network) InputCount() int {
+	return len(n.inputs)
+}
+
+// Returns the number of output nodes of this network
+func (n *Network) OutputCount() int {
+	return len(n.Outputs)
+}
+
+// Returns the number of INPUT sensors of this network excluding BIAS
+func (n *Network) inputNeuronCount() int {
+	count := 0
+	for _, node := range n.inputs {
+		if node.NeuronType == InputNeuron {
+			count++
+		}
+	}
+	return count
+}
+
 // Read output values from the output nodes of the network
 func (n *Network) ReadOutputs() []float64 {
 	outs := make([]float64, len(n.Outputs))
@@ -364,6 +557,87 @@ func (n *Network) ReadOutputs() []float64 {
 	return outs
 }
 
+// Read output values from the output nodes of the network normalized according to the given mode
+func (n *Network) ReadOutputsNormalized(mode NormMode) []float64 {
+	outs := n.ReadOutputs()
+	switch mode {
+	case NormClamp01:
+		for i, o := range outs {
+			outs[i] = math.Max(0.0, math.Min(1.0, o))
+		}
+	case NormSoftmax:
+		if len(outs) == 0 {
+			break
+		}
+		// subtract maximal value for numerical stability
+		max := outs[0]
+		for _, o := range outs {
+			max = math.Max(max, o)
+		}
+		sum := 0.0
+		for i, o := range outs {
+			outs[i] = math.Exp(o - max)
+			sum += outs[i]
+		}
+		for i := range outs {
+			outs[i] /= sum
+		}
+	}
+	return outs
+}
+
+// Activates the network for each row of input values and returns corresponding rows of output values. If flush is
+// true, the network will be flushed before each row, otherwise the state of the network will carry over between rows.
+func (n *Network) ActivateBatch(inputs [][]float64, flush bool) ([][]float64, error) {
+	outputs := make([][]float64, len(inputs))
+	for i, row := range inputs {
+		if flush {
+			if _, err := n.Flush(); err != nil {
+				return nil, err
+			}
+		}
+		if err := n.LoadSensors(row); err != nil {
+			return nil, err
+		}
+		if res, err := n.Activate(); err != nil {
+			return nil, err
+		} else if !res {
+			return nil, errors.New(fmt.Sprintf("Failed to activate network for input row: %d", i))
+		}
+		outputs[i] = n.ReadOutputs()
+	}
+	return outputs, nil
+}
+
+// Loads provided input values into sensors and activates the network until the input signal settles through its
+// deepest node. Returns the output values along with the trace of final activation values of all network nodes
+// keyed by node ID, which is useful for debugging of evolved networks.
+func (n *Network) ActivateTraced(inputs []float64) (outputs []float64, trace map[int]float64, err error) {
+	if err = n.LoadSensors(inputs); err != nil {
+		return nil, nil, err
+	}
+	// activate as many steps as needed for the input signal to reach the deepest node
+	steps := 1
+	for _, d := range n.NodeDepths() {
+		if d > steps {
+			steps = d
+		}
+	}
+	for i := 0; i < steps; i++ {
+		if res, err := n.Activate(); err != nil {
+			return nil, nil, err
+		} else if !res {
+			return nil, nil, errors.New(fmt.Sprintf("Failed to activate network at step: %d", i))
+		}
+	}
+
+	trace = make(map[int]float64, len(n.all_nodes))
+	for _, node := range n.all_nodes {
+		trace[node.Id] = node.Activation
+	}
+	return n.ReadOutputs(), trace, nil
+}
+
 // Counts the number of nodes in the net
 func (n *Network) NodeCount() int {
 	if len(n.control_nodes) == 0 {
@@ -445,6 +719,110 @@ func (n *Network) MaxDepth() (int, error) {
 	return max, nil
 }
 
+// Returns the depth of each node in the network, i.e., the length of the longest path from any input sensor to the node.
+// Only the feed-forward portion of the topology is taken into account - the recurrent links (and links closing loops) are
+// ignored, thus the nodes fed only by recurrent links get the depth of their non-recurrent predecessors.
+func (n *Network) NodeDepths() map[*NNode]int {
+	// collect non-recurrent predecessors of each node including links of control nodes
+	predecessors := make(map[*NNode][]*NNode)
+	nodes := append(append(make([]*NNode, 0, len(n.all_nodes) + len(n.control_nodes)), n.all_nodes...), n.control_nodes...)
+	for _, node := range nodes {
+		for _, l := range node.Incoming {
+			if !l.IsRecurrent {
+				predecessors[node] = append(predecessors[node], l.InNode)
+			}
+		}
+	}
+	for _, node := range n.control_nodes {
+		for _, l := range node.Outgoing {
+			if !l.IsRecurrent {
+				predecessors[l.OutNode] = append(predecessors[l.OutNode], node)
+			}
+		}
+	}
+
+	depths := make(map[*NNode]int)
+	in_progress := make(map[*NNode]bool)
+	var depth func(node *NNode) int
+	depth = func(node *NNode) int {
+		if d, ok := depths[node]; ok {
+			return d
+		}
+		in_progress[node] = true
+		d := 0
+		if !node.IsSensor() {
+			for _, p := range predecessors[node] {
+				if in_progress[p] {
+					// the link closes loop - skipping
+					continue
+				}
+				if p_d := depth(p) + 1; p_d > d {
+					d = p_d
+				}
+			}
+		}
+		in_progress[node] = false
+		depths[node] = d
+		return d
+	}
+	for _, node := range nodes {
+		depth(node)
+	}
+	return depths
+}
+
+// Checks whether output node at given index has path of connections from any input sensor of the network. The bias
+// sensors are not taken into account because output connected only to them doesn't depend on network inputs.
+func (n *Network) OutputIsConnected(index int) bool {
+	if index < 0 || index >= len(n.Outputs) {
+		return false
+	}
+	return n.LiveOutputs()[index]
+}
+
+// Returns flags indicating for each output node whether it has path of connections from any input sensor of the
+// network, i.e., whether its activation value depends on the network inputs.
+func (n *Network) LiveOutputs() []bool {
+	reached := make(map[*NNode]bool)
+	for _, node := range n.inputs {
+		if node.NeuronType == InputNeuron {
+			reached[node] = true
+		}
+	}
+	nodes := append(append(make([]*NNode, 0, len(n.all_nodes) + len(n.control_nodes)), n.all_nodes...), n.control_nodes...)
+
+	// propagate reachability until no more nodes found. Both incoming and outgoing links are checked because
+	// links of control nodes stored only at the control node's side
+	for changed := true; changed; {
+		changed = false
+		for _, node := range nodes {
+			if !reached[node] {
+				for _, l := range node.Incoming {
+					if reached[l.InNode] {
+						reached[node] = true
+						changed = true
+						break
+					}
+				}
+			}
+			if reached[node] {
+				for _, l := range node.Outgoing {
+					if !reached[l.OutNode] {
+						reached[l.OutNode] = true
+						changed = true
+					}
+				}
+			}
+		}
+	}
+
+	live := make([]bool, len(n.Outputs))
+	for i, o := range n.Outputs {
+		live[i] = reached[o]
+	}
+	return live
+}
+
 // Returns all nodes in the network
 func (n *Network) AllNodes() []*NNode {
 	return n.all_nodes
@@ -3,6 +3,9 @@ package network
 import (
 	"testing"
 	"github.com/yaricom/goNEAT/neat/utils"
+	"github.com/yaricom/goNEAT/neat"
+	"strings"
+	"math"
 )
 
 func buildNetwork() *Network {
@@ -98,6 +101,30 @@ func TestModularNetwork_Activate(t *testing.T) {
 	}
 }
 
+// Tests that node bias shifts the output of the Network
+func TestNetwork_Activate_bias(t *testing.T) {
+	netw := buildModularNetwork()
+	netw.Outputs[0].Bias = 5.0
+	data := []float64{1.0, 2.0, 0.5}
+	netw.LoadSensors(data)
+
+	for i := 0; i < 5; i++ {
+		if res, err := netw.Activate(); err != nil {
+			t.Error(err)
+			return
+		} else if !res {
+			t.Error("failed to activate")
+			return
+		}
+	}
+	if netw.Outputs[0].Activation != 950 {
+		t.Error("netw.Outputs[0].Activation != 950", netw.Outputs[0].Activation)
+	}
+	if netw.Outputs[1].Activation != 2730 {
+		t.Error("netw.Outputs[1].Activation != 2730", netw.Outputs[1].Activation)
+	}
+}
+
 // Tests Network MaxDepth
 func TestNetwork_MaxDepth(t *testing.T) {
 	netw := buildNetwork()
@@ -111,6 +138,76 @@ func TestNetwork_MaxDepth(t *testing.T) {
 	}
 }
 
+func TestNetwork_NodeDepths(t *testing.T) {
+	all_nodes := []*NNode{
+		NewNNode(1, InputNeuron),
+		NewNNode(2, InputNeuron),
+		NewNNode(3, HiddenNeuron),
+		NewNNode(4, HiddenNeuron),
+		NewNNode(5, OutputNeuron),
+	}
+	all_nodes[2].addIncoming(all_nodes[0], 1.0)
+	all_nodes[2].addIncoming(all_nodes[1], 1.0)
+	all_nodes[3].addIncoming(all_nodes[1], 1.0)
+	all_nodes[4].addIncoming(all_nodes[2], 1.0)
+	all_nodes[4].addIncoming(all_nodes[3], 1.0)
+	// recurrent link from output to hidden
+	all_nodes[3].Incoming = append(all_nodes[3].Incoming, NewLink(1.0, all_nodes[4], all_nodes[3], true))
+	netw := NewNetwork(all_nodes[0:2], all_nodes[4:5], all_nodes, 0)
+
+	depths := netw.NodeDepths()
+	expected := []int{0, 0, 1, 1, 2}
+	for i, node := range all_nodes {
+		if depths[node] != expected[i] {
+			t.Error("Wrong depth of node", node.Id, expected[i], depths[node])
+		}
+	}
+
+	// check that max depth agrees
+	netw = buildNetwork()
+	depths = netw.NodeDepths()
+	max_depth, err := netw.MaxDepth()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for _, o := range netw.Outputs {
+		if depths[o] != max_depth {
+			t.Error("depths[o] != max_depth", depths[o], max_depth)
+		}
+	}
+}
+
+// Tests Network LiveOutputs
+func TestNetwork_LiveOutputs(t *testing.T) {
+	netw := buildNetwork()
+	// disconnect OUTPUT 8 from inputs by feeding it only from bias
+	netw.Outputs[1].Incoming = []*Link{NewLink(1.0, netw.inputs[2], netw.Outputs[1], false)}
+
+	live := netw.LiveOutputs()
+	if len(live) != 2 {
+		t.Error("len(live) != 2", len(live))
+		return
+	}
+	if !live[0] || !netw.OutputIsConnected(0) {
+		t.Error("The connected output reported as not connected")
+	}
+	if live[1] || netw.OutputIsConnected(1) {
+		t.Error("The disconnected output reported as connected")
+	}
+	if netw.OutputIsConnected(2) {
+		t.Error("The output out of range reported as connected")
+	}
+
+	// check modular
+	netw = buildModularNetwork()
+	for i, l := range netw.LiveOutputs() {
+		if !l {
+			t.Error("The modular network output reported as not connected", i)
+		}
+	}
+}
+
 // Tests Network OutputIsOff
 func TestNetwork_OutputIsOff(t *testing.T) {
 	netw := buildNetwork()
@@ -121,6 +218,59 @@ func TestNetwork_OutputIsOff(t *testing.T) {
 	}
 }
 
+// Tests that activation steepness defined by node trait changes the output of the Network only if enabled
+func TestNetwork_Activate_steepness(t *testing.T) {
+	build := func(steepness float64, enabled bool) *Network {
+		in, out := NewNNode(1, InputNeuron), NewNNode(2, OutputNeuron)
+		out.Trait = neat.NewTrait()
+		out.Trait.Params[0] = steepness
+		out.addIncoming(in, 0.5)
+		netw := NewNetwork([]*NNode{in}, []*NNode{out}, []*NNode{in, out}, 0)
+		netw.SetTraitSteepness(enabled)
+		return netw
+	}
+	activate := func(netw *Network) float64 {
+		netw.LoadSensors([]float64{0.5})
+		if res, err := netw.Activate(); err != nil || !res {
+			t.Error("failed to activate", err)
+		}
+		return netw.Outputs[0].Activation
+	}
+	activateFast := func(netw *Network) float64 {
+		solver, err := netw.FastNetworkSolver()
+		if err != nil {
+			t.Error(err)
+			return 0
+		}
+		solver.LoadSensors([]float64{0.5})
+		if _, err = solver.ForwardSteps(1); err != nil {
+			t.Error(err)
+		}
+		return solver.ReadOutputs()[0]
+	}
+
+	default_out := activate(build(0.0, true))
+	plain_out := activate(build(1.0, true))
+	steep_out := activate(build(3.0, true))
+	if default_out != plain_out {
+		t.Error("default_out != plain_out", default_out, plain_out)
+	}
+	if steep_out <= plain_out {
+		t.Error("steep_out <= plain_out", steep_out, plain_out)
+	}
+	if disabled_out := activate(build(3.0, false)); disabled_out != plain_out {
+		t.Error("The trait steepness applied when disabled", disabled_out, plain_out)
+	}
+
+	// the fast solver agrees with the network
+	if fast_out := activateFast(build(3.0, true)); fast_out != steep_out {
+		t.Error("fast_out != steep_out", fast_out, steep_out)
+	}
+	if fast_out := activateFast(build(3.0, false)); fast_out != plain_out {
+		t.Error("fast_out != plain_out", fast_out, plain_out)
+	}
+}
+
 // Tests Network Activate
 func TestNetwork_Activate(t *testing.T) {
 	netw := buildNetwork()
@@ -149,6 +299,240 @@ func TestNetwork_Activate(t *testing.T) {
 	}
 }
 
+func TestNetwork_ActivateContinuous(t *testing.T) {
+	build := func(tau float64) *Network {
+		all_nodes := []*NNode{
+			NewNNode(1, InputNeuron),
+			NewNNode(2, OutputNeuron),
+		}
+		all_nodes[1].ActivationType = utils.LinearActivation
+		all_nodes[1].TimeConstant = tau
+		all_nodes[1].addIncoming(all_nodes[0], 1.0)
+		return NewNetwork(all_nodes[0:1], all_nodes[1:2], all_nodes, 0)
+	}
+	activate := func(netw *Network) float64 {
+		if err := netw.LoadSensors([]float64{1.0}); err != nil {
+			t.Error(err)
+			return 0
+		}
+		if res, err := netw.ActivateContinuous(0.1); !res || err != nil {
+			t.Error("failed to activate", err)
+		}
+		return netw.Outputs[0].Activation
+	}
+
+	// instantaneous response when time constant is not set
+	instant := build(0)
+	if out := activate(instant); out != 1.0 {
+		t.Error("out != 1.0", out)
+	}
+
+	// sluggish response with large time constant
+	sluggish := build(10.0)
+	out := activate(sluggish)
+	if math.Abs(out - 0.01) > 1e-9 {
+		t.Error("out != 0.01", out)
+	}
+	if next := activate(sluggish); next <= out || next >= 1.0 {
+		t.Error("The state is not integrated", out, next)
+	}
+
+	// wrong time step
+	if _, err := sluggish.ActivateContinuous(0); err == nil {
+		t.Error("Error expected for zero time step")
+	}
+}
+
+func TestNetwork_SetActivationOrder(t *testing.T) {
+	// builds recurrent network with nodes stored in given order
+	build := func(order []int) *Network {
+		nodes := []*NNode{
+			NewNNode(1, InputNeuron),
+			NewNNode(2, HiddenNeuron),
+			NewNNode(3, HiddenNeuron),
+			NewNNode(4, OutputNeuron),
+		}
+		for _, n := range nodes[1:] {
+			n.ActivationType = utils.LinearActivation
+		}
+		nodes[1].addIncoming(nodes[0], 1.0)
+		nodes[2].addIncoming(nodes[1], 2.0)
+		nodes[1].addIncoming(nodes[2], 0.5)
+		nodes[1].Incoming[1].IsRecurrent = true
+		nodes[3].addIncoming(nodes[2], 1.0)
+
+		all_nodes := make([]*NNode, len(order))
+		for i, idx := range order {
+			all_nodes[i] = nodes[idx]
+		}
+		return NewNetwork(nodes[0:1], nodes[3:4], all_nodes, 0)
+	}
+	activate := func(netw *Network, order ActivationOrder) float64 {
+		netw.SetActivationOrder(order)
+		if err := netw.LoadSensors([]float64{1.0}); err != nil {
+			t.Error(err)
+			return 0
+		}
+		for i := 0; i < 4; i++ {
+			if res, err := netw.Activate(); !res || err != nil {
+				t.Error("failed to activate", err)
+				return 0
+			}
+		}
+		return netw.Outputs[0].Activation
+	}
+
+	orders := [][]int{{0, 1, 2, 3}, {0, 3, 2, 1}}
+	simultaneous := activate(build(orders[0]), Simultaneous)
+	if out := activate(build(orders[1]), Simultaneous); out != simultaneous {
+		t.Error("Simultaneous activation depends on nodes order", simultaneous, out)
+	}
+
+	by_depth := activate(build(orders[1]), ByDepth)
+	if by_depth == simultaneous {
+		t.Error("ByDepth activation is the same as Simultaneous", by_depth)
+	}
+	if out := activate(build(orders[0]), ByDepth); out != by_depth {
+		t.Error("ByDepth activation depends on nodes order", by_depth, out)
+	}
+}
+
+func TestNetwork_ActivateBatch(t *testing.T) {
+	inputs := [][]float64{{1.0, 2.0, 1.0}, {0.5, 0.5, 1.0}, {2.0, 0.0, 1.0}}
+
+	// row by row results
+	netw := buildNetwork()
+	expected := make([][]float64, len(inputs))
+	for i, row := range inputs {
+		netw.Flush()
+		netw.LoadSensors(row)
+		if _, err := netw.Activate(); err != nil {
+			t.Error(err)
+			return
+		}
+		expected[i] = netw.ReadOutputs()
+	}
+
+	netw = buildNetwork()
+	outputs, err := netw.ActivateBatch(inputs, true)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(outputs) != len(inputs) {
+		t.Error("len(outputs) != len(inputs)", len(outputs))
+		return
+	}
+	for i, row := range outputs {
+		for j, v := range row {
+			if v != expected[i][j] {
+				t.Error("v != expected[i][j]", v, expected[i][j], i, j)
+			}
+		}
+	}
+
+	// recurrent state carries over without flush
+	in, out := NewNNode(1, InputNeuron), NewNNode(2, OutputNeuron)
+	out.ActivationType = utils.LinearActivation
+	out.addIncoming(in, 1.0)
+	out.addIncoming(out, 1.0)
+	rec_netw := NewNetwork([]*NNode{in}, []*NNode{out}, []*NNode{in, out}, 0)
+	rows := [][]float64{{1.0}, {1.0}}
+	if outputs, err = rec_netw.ActivateBatch(rows, true); err != nil {
+		t.Error(err)
+		return
+	}
+	if outputs[0][0] != outputs[1][0] {
+		t.Error("outputs[0][0] != outputs[1][0] with flush", outputs[0][0], outputs[1][0])
+	}
+	if outputs, err = rec_netw.ActivateBatch(rows, false); err != nil {
+		t.Error(err)
+		return
+	}
+	if outputs[1][0] <= outputs[0][0] {
+		t.Error("outputs[1][0] <= outputs[0][0] without flush", outputs[0][0], outputs[1][0])
+	}
+}
+
+func TestNetwork_ActivateTraced(t *testing.T) {
+	in_1, in_2 := NewNNode(1, InputNeuron), NewNNode(2, InputNeuron)
+	hidden, out := NewNNode(3, HiddenNeuron), NewNNode(4, OutputNeuron)
+	hidden.ActivationType = utils.LinearActivation
+	out.ActivationType = utils.LinearActivation
+	hidden.addIncoming(in_1, 0.5)
+	hidden.addIncoming(in_2, 1.0)
+	out.addIncoming(hidden, 2.0)
+	// the hidden node stored after output to require more than one activation step
+	netw := NewNetwork([]*NNode{in_1, in_2}, []*NNode{out}, []*NNode{in_1, in_2, out, hidden}, 0)
+
+	outputs, trace, err := netw.ActivateTraced([]float64{1.0, 2.0})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	expected := map[int]float64{1:1.0, 2:2.0, 3:2.5, 4:5.0}
+	if len(trace) != len(expected) {
+		t.Error("len(trace) != len(expected)", len(trace))
+	}
+	for id, v := range expected {
+		if trace[id] != v {
+			t.Error("trace[id] != v", id, trace[id], v)
+		}
+	}
+	if len(outputs) != 1 || outputs[0] != 5.0 {
+		t.Error("outputs[0] != 5.0", outputs)
+	}
+
+	if _, _, err = netw.ActivateTraced([]float64{1.0}); err == nil {
+		t.Error("Error expected for wrong number of inputs")
+	}
+}
+
+func TestNetwork_ActivateN(t *testing.T) {
+	in, hidden, out := NewNNode(1, InputNeuron), NewNNode(2, HiddenNeuron), NewNNode(3, OutputNeuron)
+	hidden.ActivationType = utils.LinearActivation
+	out.ActivationType = utils.LinearActivation
+	hidden.addIncoming(in, 1.5)
+	out.addIncoming(hidden, 2.0)
+	netw := NewNetwork([]*NNode{in}, []*NNode{out}, []*NNode{in, hidden, out}, 0)
+
+	depth, err := netw.MaxDepth()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if depth != 2 {
+		t.Error("depth != 2", depth)
+		return
+	}
+
+	// the input signal doesn't reach output until activated depth times
+	for steps := 1; steps <= depth; steps++ {
+		if _, err = netw.Flush(); err != nil {
+			t.Error(err)
+			return
+		}
+		if err = netw.LoadSensors([]float64{0.5}); err != nil {
+			t.Error(err)
+			return
+		}
+		if err = netw.ActivateN(steps); err != nil {
+			t.Error(err)
+			return
+		}
+		output := netw.ReadOutputs()[0]
+		if steps < depth && output != 0.0 {
+			t.Error("Output activated before input signal reached it", steps, output)
+		} else if steps == depth && output != 1.5 {
+			t.Error("output != 1.5", output)
+		}
+	}
+
+	if err = netw.ActivateN(0); err == nil {
+		t.Error("Error expected for zero activation steps")
+	}
+}
+
 // Test Network LoadSensors
 func TestNetwork_LoadSensors(t *testing.T) {
 	netw := buildNetwork()
@@ -170,6 +554,114 @@ func TestNetwork_LoadSensors(t *testing.T) {
 	}
 }
 
+func TestNetwork_ReadOutputsNormalized(t *testing.T) {
+	netw := buildNetwork()
+	netw.Outputs[0].Activation = 2.5
+	netw.Outputs[1].Activation = -0.5
+
+	outs := netw.ReadOutputsNormalized(NormNone)
+	if outs[0] != 2.5 || outs[1] != -0.5 {
+		t.Error("Raw outputs expected", outs)
+	}
+
+	outs = netw.ReadOutputsNormalized(NormClamp01)
+	if outs[0] != 1.0 || outs[1] != 0.0 {
+		t.Error("Outputs not clamped", outs)
+	}
+
+	outs = netw.ReadOutputsNormalized(NormSoftmax)
+	sum := 0.0
+	for _, o := range outs {
+		if o < 0 || o > 1 {
+			t.Error("Softmax output out of range", o)
+		}
+		sum += o
+	}
+	if math.Abs(sum - 1.0) > 1e-9 {
+		t.Error("sum != 1.0", sum)
+	}
+	if outs[0] <= outs[1] {
+		t.Error("outs[0] <= outs[1]", outs)
+	}
+	// the activations of output nodes are intact
+	if netw.Outputs[0].Activation != 2.5 {
+		t.Error("netw.Outputs[0].Activation != 2.5", netw.Outputs[0].Activation)
+	}
+}
+
+func TestNetwork_LoadSensors_wrongSize(t *testing.T) {
+	netw := buildNetwork()
+	if netw.InputCount() != 3 {
+		t.Error("netw.InputCount() != 3", netw.InputCount())
+	}
+	if netw.OutputCount() != 2 {
+		t.Error("netw.OutputCount() != 2", netw.OutputCount())
+	}
+
+	// too few sensor values
+	err := netw.LoadSensors([]float64{1.0})
+	if err == nil {
+		t.Error("Error expected for wrong number of sensor values")
+	} else if !strings.Contains(err.Error(), "Wrong number of sensor values: 1") {
+		t.Error("Unexpected error message", err)
+	}
+
+	// BIAS value omitted
+	if err = netw.LoadSensors([]float64{1.0, 3.4}); err != nil {
+		t.Error(err)
+	}
+}
+
+// Test Network Flush clears the state of recurrent connections between episodes
+func TestNetwork_Flush_recurrent(t *testing.T) {
+	all_nodes := []*NNode{
+		NewNNode(1, InputNeuron),
+		NewNNode(2, HiddenNeuron),
+		NewNNode(3, OutputNeuron),
+	}
+	all_nodes[1].addIncoming(all_nodes[0], 1.5)
+	// recurrent self loop of the hidden node
+	all_nodes[1].Incoming = append(all_nodes[1].Incoming, NewLink(0.8, all_nodes[1], all_nodes[1], true))
+	all_nodes[2].addIncoming(all_nodes[1], 2.0)
+	netw := NewNetwork(all_nodes[0:1], all_nodes[2:3], all_nodes, 0)
+
+	episode := func() []float64 {
+		outputs := make([]float64, 0)
+		for i := 0; i < 3; i++ {
+			if err := netw.LoadSensors([]float64{0.5}); err != nil {
+				t.Error(err)
+			}
+			if _, err := netw.Activate(); err != nil {
+				t.Error(err)
+			}
+			outputs = append(outputs, netw.Outputs[0].Activation)
+		}
+		return outputs
+	}
+
+	first := episode()
+	if res, err := netw.Flush(); err != nil || !res {
+		t.Error("Network flush failed", err)
+		return
+	}
+	for _, node := range netw.AllNodes() {
+		if node.ActivationsCount != 0 || node.Activation != 0 || node.GetActiveOut() != 0 || node.GetActiveOutTd() != 0 {
+			t.Error("Node state not reset", node)
+		}
+	}
+	if len(all_nodes[1].Incoming) != 2 || all_nodes[1].Incoming[1].Weight != 0.8 {
+		t.Error("The topology or weights changed after flush")
+	}
+
+	second := episode()
+	for i := range first {
+		if first[i] != second[i] {
+			t.Error("The recurrent state leaked between episodes", first, second)
+			break
+		}
+	}
+}
+
 // Test Network Flush
 func TestNetwork_Flush(t *testing.T) {
 	netw := buildNetwork()
@@ -270,4 +762,18 @@ func TestNetwork_FastNetworkSolver(t *testing.T) {
 	if solver.LinkCount() != netw.LinkCount() {
 		t.Error("solver.LinkCount() != netw.LinkCount()", solver.LinkCount(), netw.LinkCount())
 	}
+
+	// the own biases of neurons are not links
+	for _, n := range netw.AllNodes() {
+		if n.IsNeuron() {
+			n.Bias = 0.5
+		}
+	}
+	if solver, err = netw.FastNetworkSolver(); err != nil {
+		t.Error(err)
+		return
+	}
+	if solver.LinkCount() != netw.LinkCount() {
+		t.Error("solver.LinkCount() != netw.LinkCount() with neuron biases", solver.LinkCount(), netw.LinkCount())
+	}
 }
@@ -27,6 +27,11 @@ type NNode struct {
 	ActivationsCount  int32
 	// The activation sum
 	ActivationSum     float64
+	// The bias value added to the activation sum before activation function applied
+	Bias              float64
+	// The time constant of the node integration during continuous time activation, values less than or equal to zero
+	// mean instantaneous response to the input
+	TimeConstant      float64
 
 	// The list of all incoming connections
 	Incoming          []*Link
@@ -53,8 +58,14 @@ type NNode struct {
 	// The innode then needs to send from TWO time steps ago
 	lastActivation2   float64
 
+	// The gain applied to the activation sum before activation function, values less than or equal to zero mean
+	// the default gain of 1.0 (see Network.SetTraitSteepness)
+	steepness         float64
+
 	// If true the node is active - used during node activation
 	isActive          bool
+	// The integrated state of the node during continuous time activation
+	continuousState   float64
 }
 
 // Creates new node with specified ID and neuron type associated (INPUT, HIDDEN, OUTPUT, BIAS)
@@ -71,6 +82,8 @@ func NewNNodeCopy(n *NNode, t *neat.Trait) *NNode {
 	node.Id = n.Id
 	node.NeuronType = n.NeuronType
 	node.ActivationType = n.ActivationType
+	node.Bias = n.Bias
+	node.TimeConstant = n.TimeConstant
 	node.Trait = t
 	return node
 }
@@ -119,6 +132,15 @@ func (n *NNode) GetActiveOutTd() float64 {
 	}
 }
 
+// Returns the gain applied to the activation sum of this node before activation function. It is taken from the first
+// parameter of node's trait if enabled by Network.SetTraitSteepness, otherwise the default value of 1.0 is returned.
+func (n *NNode) ActivationSteepness() float64 {
+	if n.steepness > 0 {
+		return n.steepness
+	}
+	return 1.0
+}
+
 // Returns true if this node is SENSOR
 func (n *NNode) IsSensor() bool {
 	return n.NeuronType == InputNeuron || n.NeuronType == BiasNeuron
@@ -163,6 +185,7 @@ func (n *NNode) Flushback() {
 	n.lastActivation2 = 0
 	n.isActive = false
 	n.visited = false
+	n.continuousState = 0
 }
 
 // Verify flushing for debuging
@@ -170,13 +193,13 @@ func (n *NNode) FlushbackCheck() error {
 	if n.ActivationsCount > 0 {
 		return errors.New(fmt.Sprintf("NNODE: %s has activation count %d", n, n.ActivationsCount))
 	}
-	if n.Activation > 0 {
+	if n.Activation != 0 {
 		return errors.New(fmt.Sprintf("NNODE: %s has activation %f", n, n.Activation))
 	}
-	if n.lastActivation > 0 {
+	if n.lastActivation != 0 {
 		return errors.New(fmt.Sprintf("NNODE: %s has last_activation %f", n, n.lastActivation))
 	}
-	if n.lastActivation2 > 0 {
+	if n.lastActivation2 != 0 {
 		return errors.New(fmt.Sprintf("NNODE: %s has last_activation2 %f", n, n.lastActivation2))
 	}
 	return nil
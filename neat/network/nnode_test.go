@@ -2,6 +2,8 @@ package network
 
 import (
 	"testing"
+	"math"
+	"github.com/yaricom/goNEAT/neat/utils"
 )
 
 // Tests NNode SensorLoad
@@ -151,3 +153,30 @@ func TestNNode_Flushback(t *testing.T) {
 		t.Error("GetActiveOutTd", 0, node.GetActiveOutTd())
 	}
 }
+
+// Tests activation of NNode with CPPN activation functions
+func TestNNode_ActivateNode_CPPN(t *testing.T) {
+	inputs := []float64{-1.0, 0.0, 0.5}
+	activations := []utils.NodeActivationType{utils.GaussianActivation, utils.SineActivation,
+		utils.LinearAbsActivation, utils.LinearActivation}
+	expected := [][]float64{
+		{math.Exp(-1.0), 1.0, math.Exp(-0.25)},
+		{math.Sin(-2.0), 0.0, math.Sin(1.0)},
+		{1.0, 0.0, 0.5},
+		{-1.0, 0.0, 0.5},
+	}
+	for i, a := range activations {
+		for j, in := range inputs {
+			node := NewNNode(1, HiddenNeuron)
+			node.ActivationType = a
+			node.ActivationSum = in
+			if err := ActivateNode(node, utils.NodeActivators); err != nil {
+				t.Error(err)
+				return
+			}
+			if node.Activation != expected[i][j] {
+				t.Error("node.Activation != expected", a, in, node.Activation, expected[i][j])
+			}
+		}
+	}
+}
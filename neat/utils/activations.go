@@ -38,6 +38,10 @@ const (
 	MultiplyModuleActivation
 	MaxModuleActivation
 	MinModuleActivation
+
+	// The gaussian and rectified linear activators, appended last to keep the codes of existing activators stable
+	GaussianActivation
+	ReLUActivation
 )
 
 // The neuron node activation function type
@@ -89,6 +93,8 @@ func NewNodeActivatorsFactory() *NodeActivatorsFactory {
 	af.Register(SignActivation, signFunction, "SignActivation")
 	af.Register(SineActivation, sineFunction, "SineActivation")
 	af.Register(StepActivation, stepFunction, "StepActivation")
+	af.Register(GaussianActivation, plainGaussian, "GaussianActivation")
+	af.Register(ReLUActivation, rectifiedLinear, "ReLUActivation")
 
 	// register neuron modules activators
 	af.RegisterModule(MultiplyModuleActivation, multiplyModule, "MultiplyModuleActivation")
@@ -225,6 +231,10 @@ var (
 	bipolarGaussian = func(input float64, aux_params[]float64) float64 {
 		return 2.0 * math.Exp(-math.Pow(input * 2.5, 2.0)) - 1.0
 	}
+	// The plain Gaussian activator xrange->[-inf,inf] yrange->[0,1], as used by CPPNs
+	plainGaussian = func(input float64, aux_params[]float64) float64 {
+		return math.Exp(-input * input)
+	}
 	// The absolute linear
 	absoluteLinear = func(input float64, aux_params[]float64) float64 {
 		return math.Abs(input)
@@ -244,6 +254,10 @@ var (
 	linear = func(input float64, aux_params[]float64) float64 {
 		return input
 	}
+	// The rectified linear activation x<0 ? 0.0 : x
+	rectifiedLinear = func(input float64, aux_params[]float64) float64 {
+		return math.Max(0.0, input)
+	}
 	// The null activator
 	nullFunctor = func(input float64, aux_params[]float64) float64 {
 		return 0.0